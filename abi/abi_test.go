@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/miniBamboo/workshare/abi"
 	"github.com/miniBamboo/workshare/consensus/builtin/gen"
 	"github.com/miniBamboo/workshare/workshare"
@@ -84,3 +85,61 @@ func TestABI(t *testing.T) {
 
 	}
 }
+
+func TestUnpackRevert(t *testing.T) {
+	// Error("not enough balance")
+	data, _ := hexutil.Decode("0x08c379a0000000000000000000000000000000000000000000000000000000000000002000000000000000000000000000000000000000000000000000000000000000126e6f7420656e6f7567682062616c616e63650000000000000000000000000000")
+	reason, err := abi.UnpackRevert(data)
+	assert.Nil(t, err)
+	assert.Equal(t, "not enough balance", reason)
+
+	_, err = abi.UnpackRevert([]byte{0x08, 0xc3})
+	assert.NotNil(t, err)
+
+	_, err = abi.UnpackRevert(append([]byte{0x01, 0x02, 0x03, 0x04}, data[4:]...))
+	assert.NotNil(t, err)
+}
+
+func TestDecodeArgs(t *testing.T) {
+	abi, err := abi.New(gen.MustAsset("compiled/Energy.abi"))
+	assert.Nil(t, err)
+
+	event, found := abi.EventByName("Transfer")
+	assert.True(t, found)
+	assert.Equal(t, "Transfer(address,address,uint256)", event.Signature())
+
+	from := workshare.BytesToAddress([]byte("from"))
+	to := workshare.BytesToAddress([]byte("to"))
+	value := big.NewInt(100)
+	data, err := event.Encode(value)
+	assert.Nil(t, err)
+
+	topics := []workshare.Bytes32{event.ID(), workshare.BytesToBytes32(from.Bytes()), workshare.BytesToBytes32(to.Bytes())}
+	args, err := event.DecodeArgs(topics, data)
+	assert.Nil(t, err)
+	if assert.Equal(t, 3, len(args)) {
+		assert.Equal(t, "address", args[0].Type)
+		assert.True(t, args[0].Indexed)
+		assert.Equal(t, common.Address(from), args[0].Value)
+		assert.Equal(t, common.Address(to), args[1].Value)
+		assert.False(t, args[2].Indexed)
+		assert.Equal(t, value, args[2].Value)
+	}
+
+	_, err = event.DecodeArgs(topics[:2], data)
+	assert.NotNil(t, err)
+	_, err = event.DecodeArgs(topics[1:], data)
+	assert.NotNil(t, err)
+
+	method, found := abi.MethodByName("balanceOf")
+	assert.True(t, found)
+	assert.Equal(t, "balanceOf(address)", method.Signature())
+	output, err := method.EncodeOutput(value)
+	assert.Nil(t, err)
+	args, err = method.DecodeOutputArgs(output)
+	assert.Nil(t, err)
+	if assert.Equal(t, 1, len(args)) {
+		assert.Equal(t, "uint256", args[0].Type)
+		assert.Equal(t, value, args[0].Value)
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package abi
+
+import (
+	"errors"
+
+	ethabi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/miniBamboo/workshare/workshare"
+)
+
+// Arg is a decoded argument of an event or a method.
+type Arg struct {
+	Name    string
+	Type    string
+	Indexed bool
+	// Value is the go value of the argument. For indexed args of dynamic types,
+	// it's the topic (hash of the value) as workshare.Bytes32.
+	Value interface{}
+}
+
+// Signature returns the canonical signature of the event, e.g. 'Transfer(address,address,uint256)'.
+func (e *Event) Signature() string {
+	return signature(e.event.Name, e.event.Inputs)
+}
+
+// Anonymous returns whether the event is anonymous, which has no topic of the event ID.
+func (e *Event) Anonymous() bool {
+	return e.event.Anonymous
+}
+
+// DecodeArgs decodes indexed args from topics and others from data, in the order of the event inputs.
+func (e *Event) DecodeArgs(topics []workshare.Bytes32, data []byte) ([]*Arg, error) {
+	if !e.event.Anonymous {
+		if len(topics) == 0 || topics[0] != e.id {
+			return nil, errors.New("event id mismatch")
+		}
+		topics = topics[1:]
+	}
+	values, err := e.argsWithoutIndexed.UnpackValues(data)
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]*Arg, 0, len(e.event.Inputs))
+	for _, input := range e.event.Inputs {
+		arg := &Arg{Name: input.Name, Type: input.Type.String(), Indexed: input.Indexed}
+		if input.Indexed {
+			if len(topics) == 0 {
+				return nil, errors.New("topics too few")
+			}
+			topic := topics[0]
+			topics = topics[1:]
+			switch input.Type.T {
+			case ethabi.StringTy, ethabi.BytesTy, ethabi.SliceTy, ethabi.ArrayTy:
+				// only hash of the value is recorded
+				arg.Value = topic
+			default:
+				vs, err := ethabi.Arguments{{Type: input.Type}}.UnpackValues(topic[:])
+				if err != nil {
+					return nil, err
+				}
+				arg.Value = vs[0]
+			}
+		} else {
+			arg.Value = values[0]
+			values = values[1:]
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
+
+// Signature returns the canonical signature of the method, e.g. 'balanceOf(address)'.
+func (m *Method) Signature() string {
+	return m.method.Sig()
+}
+
+// DecodeOutputArgs decodes the output in the order of the method outputs.
+func (m *Method) DecodeOutputArgs(output []byte) ([]*Arg, error) {
+	values, err := m.method.Outputs.UnpackValues(output)
+	if err != nil {
+		return nil, err
+	}
+	args := make([]*Arg, len(values))
+	for i, v := range values {
+		args[i] = &Arg{
+			Name:  m.method.Outputs[i].Name,
+			Type:  m.method.Outputs[i].Type.String(),
+			Value: v,
+		}
+	}
+	return args, nil
+}
+
+func signature(name string, inputs ethabi.Arguments) string {
+	sig := name + "("
+	for i, input := range inputs {
+		if i > 0 {
+			sig += ","
+		}
+		sig += input.Type.String()
+	}
+	return sig + ")"
+}
@@ -0,0 +1,37 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package abi
+
+import (
+	"bytes"
+	"errors"
+
+	ethabi "github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// revertSelector is the method id of 'Error(string)', which solidity uses to encode revert reasons.
+var revertSelector = []byte{0x08, 0xc3, 0x79, 0xa0}
+
+var stringArgs = func() ethabi.Arguments {
+	typ, err := ethabi.NewType("string")
+	if err != nil {
+		panic(err)
+	}
+	return ethabi.Arguments{{Type: typ}}
+}()
+
+// UnpackRevert decodes the revert reason from the output of a reverted execution.
+// An error returned if the output is not encoded as 'Error(string)'.
+func UnpackRevert(output []byte) (string, error) {
+	if len(output) < len(revertSelector) || !bytes.Equal(output[:len(revertSelector)], revertSelector) {
+		return "", errors.New("invalid revert data")
+	}
+	var reason string
+	if err := stringArgs.Unpack(&reason, output[len(revertSelector):]); err != nil {
+		return "", err
+	}
+	return reason, nil
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package abis
+
+import (
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/miniBamboo/workshare/api/utils"
+	"github.com/pkg/errors"
+)
+
+// ABIs serves management of the ABI registry.
+type ABIs struct {
+	registry *Registry
+}
+
+func New(registry *Registry) *ABIs {
+	return &ABIs{
+		registry,
+	}
+}
+
+func (a *ABIs) handleList(w http.ResponseWriter, req *http.Request) error {
+	return utils.WriteJSON(w, a.registry.List())
+}
+
+func (a *ABIs) handleGet(w http.ResponseWriter, req *http.Request) error {
+	key := mux.Vars(req)["key"]
+	if _, err := ParseKey(key); err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "key"))
+	}
+	data, ok := a.registry.Get(key)
+	if !ok {
+		return utils.HTTPError(errors.New("not found"), http.StatusNotFound)
+	}
+	w.Header().Set("Content-Type", utils.JSONContentType)
+	_, err := w.Write(data)
+	return err
+}
+
+func (a *ABIs) handlePut(w http.ResponseWriter, req *http.Request) error {
+	key := mux.Vars(req)["key"]
+	if _, err := ParseKey(key); err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "key"))
+	}
+	data, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "body"))
+	}
+	if err := a.registry.Register(key, data); err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "body"))
+	}
+	return utils.WriteJSON(w, &Result{true})
+}
+
+func (a *ABIs) handleDelete(w http.ResponseWriter, req *http.Request) error {
+	key := mux.Vars(req)["key"]
+	if _, err := ParseKey(key); err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "key"))
+	}
+	removed, err := a.registry.Remove(key)
+	if err != nil {
+		return utils.BadRequest(err)
+	}
+	return utils.WriteJSON(w, &Result{removed})
+}
+
+func (a *ABIs) Mount(root *mux.Router, pathPrefix string) {
+	sub := root.PathPrefix(pathPrefix).Subrouter()
+
+	sub.Path("").Methods(http.MethodGet).HandlerFunc(utils.WrapHandlerFunc(a.handleList))
+	sub.Path("/{key}").Methods(http.MethodGet).HandlerFunc(utils.WrapHandlerFunc(a.handleGet))
+	sub.Path("/{key}").Methods(http.MethodPut).HandlerFunc(utils.WrapHandlerFunc(a.handlePut))
+	sub.Path("/{key}").Methods(http.MethodDelete).HandlerFunc(utils.WrapHandlerFunc(a.handleDelete))
+}
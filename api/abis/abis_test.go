@@ -0,0 +1,146 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package abis_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gorilla/mux"
+	"github.com/miniBamboo/workshare/api/abis"
+	"github.com/miniBamboo/workshare/consensus/builtin"
+	"github.com/miniBamboo/workshare/workshare"
+	"github.com/stretchr/testify/assert"
+)
+
+const abiJSON = `[{"constant":true,"inputs":[],"name":"name","outputs":[{"name":"","type":"string"}],"type":"function"}]`
+
+func TestABIs(t *testing.T) {
+	dir := t.TempDir()
+	registry, err := abis.NewRegistry(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := mux.NewRouter()
+	abis.New(registry).Mount(router, "/admin/abis")
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	codeHash := workshare.Blake2b([]byte("code"))
+	energy := builtin.Energy.Address.String()
+
+	_, code := httpDo(t, "PUT", ts.URL+"/admin/abis/"+codeHash.String(), []byte(abiJSON))
+	assert.Equal(t, http.StatusOK, code)
+
+	_, code = httpDo(t, "PUT", ts.URL+"/admin/abis/"+codeHash.String(), []byte("{}"))
+	assert.Equal(t, http.StatusBadRequest, code, "invalid ABI")
+	_, code = httpDo(t, "PUT", ts.URL+"/admin/abis/0x01", []byte(abiJSON))
+	assert.Equal(t, http.StatusBadRequest, code, "invalid key")
+	_, code = httpDo(t, "PUT", ts.URL+"/admin/abis/"+energy, []byte(abiJSON))
+	assert.Equal(t, http.StatusBadRequest, code, "builtin")
+
+	res, code := httpDo(t, "GET", ts.URL+"/admin/abis/"+codeHash.String(), nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, abiJSON, string(res))
+
+	res, _ = httpDo(t, "GET", ts.URL+"/admin/abis", nil)
+	var items []*abis.Item
+	if err := json.Unmarshal(res, &items); err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, items, &abis.Item{Key: codeHash.String(), Builtin: false})
+	assert.Contains(t, items, &abis.Item{Key: energy, Builtin: true})
+
+	// persisted
+	reloaded, err := abis.NewRegistry(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, ok := reloaded.Get(codeHash.String())
+	assert.True(t, ok)
+
+	res, _ = httpDo(t, "DELETE", ts.URL+"/admin/abis/"+codeHash.String(), nil)
+	var result abis.Result
+	if err := json.Unmarshal(res, &result); err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, result.Success)
+	_, code = httpDo(t, "GET", ts.URL+"/admin/abis/"+codeHash.String(), nil)
+	assert.Equal(t, http.StatusNotFound, code)
+	_, code = httpDo(t, "DELETE", ts.URL+"/admin/abis/"+energy, nil)
+	assert.Equal(t, http.StatusBadRequest, code, "builtin")
+}
+
+func TestDecoder(t *testing.T) {
+	registry, err := abis.NewRegistry("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoder := registry.NewDecoder(nil)
+
+	transfer, _ := builtin.Energy.ABI.EventByName("Transfer")
+	from := workshare.BytesToAddress([]byte("from"))
+	to := workshare.BytesToAddress([]byte("to"))
+	data, _ := transfer.Encode(big.NewInt(100))
+	topics := []workshare.Bytes32{transfer.ID(), workshare.BytesToBytes32(from.Bytes()), workshare.BytesToBytes32(to.Bytes())}
+
+	decoded, err := decoder.DecodeEvent(builtin.Energy.Address, topics, data)
+	assert.Nil(t, err)
+	assert.Equal(t, &abis.DecodedEvent{
+		Name:      "Transfer",
+		Signature: "Transfer(address,address,uint256)",
+		Args: []*abis.Arg{
+			{Name: "_from", Type: "address", Indexed: true, Value: from.String()},
+			{Name: "_to", Type: "address", Indexed: true, Value: to.String()},
+			{Name: "_value", Type: "uint256", Value: "100"},
+		},
+	}, decoded)
+
+	// unknown contract
+	decoded, err = decoder.DecodeEvent(to, topics, data)
+	assert.Nil(t, err)
+	assert.Nil(t, decoded)
+
+	balanceOf, _ := builtin.Energy.ABI.MethodByName("balanceOf")
+	input, _ := balanceOf.EncodeInput(from)
+	output, _ := balanceOf.EncodeOutput(big.NewInt(1))
+	call, err := decoder.DecodeCall(&builtin.Energy.Address, input, output, false)
+	assert.Nil(t, err)
+	assert.Equal(t, &abis.DecodedCall{
+		Method:    "balanceOf",
+		Signature: "balanceOf(address)",
+		Outputs:   []*abis.Arg{{Name: "balance", Type: "uint256", Value: "1"}},
+	}, call)
+
+	// Error("not enough balance")
+	revert, _ := hexutil.Decode("0x08c379a0000000000000000000000000000000000000000000000000000000000000002000000000000000000000000000000000000000000000000000000000000000126e6f7420656e6f7567682062616c616e63650000000000000000000000000000")
+	call, err = decoder.DecodeCall(&to, nil, revert, true)
+	assert.Nil(t, err)
+	assert.Equal(t, &abis.DecodedCall{RevertReason: "not enough balance"}, call)
+}
+
+func httpDo(t *testing.T, method, url string, body []byte) ([]byte, int) {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r, res.StatusCode
+}
@@ -0,0 +1,167 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package abis
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/miniBamboo/workshare/abi"
+	"github.com/miniBamboo/workshare/state"
+	"github.com/miniBamboo/workshare/workshare"
+)
+
+// Decoder decodes events and call results with ABIs in the registry.
+// It's not safe for concurrent use.
+type Decoder struct {
+	registry *Registry
+	state    *state.State
+	cache    map[workshare.Address]*abi.ABI
+}
+
+// resolve returns the ABI of the contract, by address first, then by code hash.
+// Nil is returned if not registered.
+func (d *Decoder) resolve(addr workshare.Address) (*abi.ABI, error) {
+	if parsed, ok := d.cache[addr]; ok {
+		return parsed, nil
+	}
+	parsed := d.registry.lookup(addr.String())
+	if parsed == nil && d.state != nil {
+		codeHash, err := d.state.GetCodeHash(addr)
+		if err != nil {
+			return nil, err
+		}
+		if !codeHash.IsZero() {
+			parsed = d.registry.lookup(codeHash.String())
+		}
+	}
+	d.cache[addr] = parsed
+	return parsed, nil
+}
+
+// DecodeEvent decodes the event. Nil is returned if the ABI of the emitter is not registered,
+// or the event doesn't match the ABI.
+func (d *Decoder) DecodeEvent(addr workshare.Address, topics []workshare.Bytes32, data []byte) (*DecodedEvent, error) {
+	parsed, err := d.resolve(addr)
+	if err != nil || parsed == nil {
+		return nil, err
+	}
+	for _, event := range parsed.Events() {
+		// anonymous events have no id in topics, which are tried at last
+		if event.Anonymous() || len(topics) == 0 || topics[0] != event.ID() {
+			continue
+		}
+		if args, err := event.DecodeArgs(topics, data); err == nil {
+			return newDecodedEvent(event, args), nil
+		}
+	}
+	for _, event := range parsed.Events() {
+		if !event.Anonymous() {
+			continue
+		}
+		if args, err := event.DecodeArgs(topics, data); err == nil {
+			return newDecodedEvent(event, args), nil
+		}
+	}
+	return nil, nil
+}
+
+// DecodeCall decodes the output of calling the contract with the input.
+// Revert reason is decoded if the call is reverted with 'Error(string)', regardless of the ABI.
+// Nil is returned if nothing can be decoded.
+func (d *Decoder) DecodeCall(to *workshare.Address, input []byte, output []byte, reverted bool) (*DecodedCall, error) {
+	if reverted {
+		if reason, err := abi.UnpackRevert(output); err == nil {
+			return &DecodedCall{RevertReason: reason}, nil
+		}
+		return nil, nil
+	}
+	if to == nil {
+		return nil, nil
+	}
+	parsed, err := d.resolve(*to)
+	if err != nil || parsed == nil {
+		return nil, err
+	}
+	method, err := parsed.MethodByInput(input)
+	if err != nil {
+		return nil, nil
+	}
+	args, err := method.DecodeOutputArgs(output)
+	if err != nil {
+		return nil, nil
+	}
+	call := &DecodedCall{
+		Method:    method.Name(),
+		Signature: method.Signature(),
+		Outputs:   make([]*Arg, len(args)),
+	}
+	for i, arg := range args {
+		call.Outputs[i] = newArg(arg)
+	}
+	return call, nil
+}
+
+func newDecodedEvent(event *abi.Event, args []*abi.Arg) *DecodedEvent {
+	decoded := &DecodedEvent{
+		Name:      event.Name(),
+		Signature: event.Signature(),
+		Args:      make([]*Arg, len(args)),
+	}
+	for i, arg := range args {
+		decoded.Args[i] = newArg(arg)
+	}
+	return decoded
+}
+
+func newArg(arg *abi.Arg) *Arg {
+	return &Arg{
+		Name:    arg.Name,
+		Type:    arg.Type,
+		Indexed: arg.Indexed,
+		Value:   formatValue(arg.Value),
+	}
+}
+
+// formatValue converts decoded go values into JSON friendly ones.
+// Integers are formatted in decimal strings, and bytes in hex.
+func formatValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case *big.Int:
+		return v.String()
+	case common.Address:
+		return workshare.Address(v).String()
+	case workshare.Bytes32:
+		return v.String()
+	case []byte:
+		return hexutil.Encode(v)
+	case bool, string:
+		return v
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return fmt.Sprint(v)
+	case reflect.Array, reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			// fixed bytes
+			b := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(b), rv)
+			return hexutil.Encode(b)
+		}
+		values := make([]interface{}, rv.Len())
+		for i := range values {
+			values[i] = formatValue(rv.Index(i).Interface())
+		}
+		return values
+	}
+	return v
+}
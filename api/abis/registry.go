@@ -0,0 +1,227 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package abis
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/miniBamboo/workshare/abi"
+	"github.com/miniBamboo/workshare/consensus/builtin"
+	"github.com/miniBamboo/workshare/consensus/builtin/gen"
+	"github.com/miniBamboo/workshare/state"
+	"github.com/miniBamboo/workshare/workshare"
+	"github.com/pkg/errors"
+)
+
+const fileExt = ".json"
+
+type entry struct {
+	abi     *abi.ABI
+	data    json.RawMessage
+	builtin bool
+}
+
+// Registry is the local registry of contract ABIs, keyed by contract address or code hash.
+// ABIs of builtin contracts are pre-registered and can't be changed.
+//
+// If the dir is set, ABIs are loaded from files named '<key>.json' in it, and registered ABIs
+// are persisted into it.
+type Registry struct {
+	dir     string
+	lock    sync.RWMutex
+	entries map[string]*entry
+}
+
+// NewRegistry creates the registry, and loads ABIs from the dir if not empty.
+func NewRegistry(dir string) (*Registry, error) {
+	r := &Registry{
+		dir:     dir,
+		entries: make(map[string]*entry),
+	}
+	for _, b := range []struct {
+		address workshare.Address
+		asset   string
+	}{
+		{builtin.Params.Address, "Params"},
+		{builtin.Auworkshareity.Address, "Authority"},
+		{builtin.Energy.Address, "Energy"},
+		{builtin.Executor.Address, "Executor"},
+		{builtin.Prototype.Address, "Prototype"},
+		{builtin.Extension.Address, "ExtensionV2"},
+	} {
+		data := gen.MustAsset("compiled/" + b.asset + ".abi")
+		parsed, err := abi.New(data)
+		if err != nil {
+			return nil, errors.Wrap(err, "load builtin ABI "+b.asset)
+		}
+		r.entries[b.address.String()] = &entry{parsed, data, true}
+	}
+
+	if dir == "" {
+		return r, nil
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return r, nil
+		}
+		return nil, err
+	}
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != fileExt {
+			continue
+		}
+		key, err := ParseKey(strings.TrimSuffix(file.Name(), fileExt))
+		if err != nil {
+			return nil, errors.WithMessage(err, file.Name())
+		}
+		data, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := abi.New(data)
+		if err != nil {
+			return nil, errors.WithMessage(err, file.Name())
+		}
+		if e, ok := r.entries[key]; ok && e.builtin {
+			return nil, errors.New(file.Name() + ": builtin ABI can't be changed")
+		}
+		r.entries[key] = &entry{parsed, data, false}
+	}
+	return r, nil
+}
+
+// ParseKey parses the key of an ABI, which is either a contract address or a code hash.
+// The normalized key is returned.
+func ParseKey(s string) (string, error) {
+	switch len(s) {
+	case 2 + workshare.AddressLength*2:
+		addr, err := workshare.ParseAddress(s)
+		if err != nil {
+			return "", err
+		}
+		return addr.String(), nil
+	case 2 + 32*2:
+		hash, err := workshare.ParseBytes32(s)
+		if err != nil {
+			return "", err
+		}
+		return hash.String(), nil
+	}
+	return "", errors.New("should be contract address or code hash")
+}
+
+// Register registers the ABI JSON with the key, overwriting the existing one.
+func (r *Registry) Register(key string, data []byte) error {
+	key, err := ParseKey(key)
+	if err != nil {
+		return err
+	}
+	parsed, err := abi.New(data)
+	if err != nil {
+		return err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if e, ok := r.entries[key]; ok && e.builtin {
+		return errors.New("builtin ABI can't be changed")
+	}
+	if r.dir != "" {
+		if err := os.MkdirAll(r.dir, 0700); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(r.dir, key+fileExt), data, 0600); err != nil {
+			return err
+		}
+	}
+	r.entries[key] = &entry{parsed, data, false}
+	return nil
+}
+
+// Remove removes the ABI with the key. It returns false if the key is not registered.
+func (r *Registry) Remove(key string) (bool, error) {
+	key, err := ParseKey(key)
+	if err != nil {
+		return false, err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	e, ok := r.entries[key]
+	if !ok {
+		return false, nil
+	}
+	if e.builtin {
+		return false, errors.New("builtin ABI can't be changed")
+	}
+	if r.dir != "" {
+		if err := os.Remove(filepath.Join(r.dir, key+fileExt)); err != nil && !os.IsNotExist(err) {
+			return false, err
+		}
+	}
+	delete(r.entries, key)
+	return true, nil
+}
+
+// Get returns the ABI JSON with the key.
+func (r *Registry) Get(key string) (json.RawMessage, bool) {
+	key, err := ParseKey(key)
+	if err != nil {
+		return nil, false
+	}
+
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if e, ok := r.entries[key]; ok {
+		return e.data, true
+	}
+	return nil, false
+}
+
+// List lists all registered ABIs, sorted by key.
+func (r *Registry) List() []*Item {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	items := make([]*Item, 0, len(r.entries))
+	for key, e := range r.entries {
+		items = append(items, &Item{key, e.builtin})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Key < items[j].Key
+	})
+	return items
+}
+
+func (r *Registry) lookup(key string) *abi.ABI {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if e, ok := r.entries[key]; ok {
+		return e.abi
+	}
+	return nil
+}
+
+// NewDecoder creates a decoder, which resolves ABIs of contracts by address, or by code hash
+// in the given state. The state can be nil if resolving by code hash is not desired.
+func (r *Registry) NewDecoder(state *state.State) *Decoder {
+	return &Decoder{
+		registry: r,
+		state:    state,
+		cache:    make(map[workshare.Address]*abi.ABI),
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package abis
+
+// Item is the summary of a registered ABI.
+type Item struct {
+	Key     string `json:"key"`
+	Builtin bool   `json:"builtin"`
+}
+
+type Result struct {
+	Success bool `json:"success"`
+}
+
+// Arg decoded argument.
+type Arg struct {
+	Name    string      `json:"name"`
+	Type    string      `json:"type"`
+	Indexed bool        `json:"indexed,omitempty"`
+	Value   interface{} `json:"value"`
+}
+
+// DecodedEvent event decoded with the ABI.
+type DecodedEvent struct {
+	Name      string `json:"name"`
+	Signature string `json:"signature"`
+	Args      []*Arg `json:"args"`
+}
+
+// DecodedCall call result decoded with the ABI.
+type DecodedCall struct {
+	Method       string `json:"method,omitempty"`
+	Signature    string `json:"signature,omitempty"`
+	Outputs      []*Arg `json:"outputs,omitempty"`
+	RevertReason string `json:"revertReason,omitempty"`
+}
@@ -10,26 +10,33 @@ import (
 	"fmt"
 	"math/big"
 	"net/http"
-	"strconv"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/gorilla/mux"
+	"github.com/miniBamboo/workshare/abi"
+	"github.com/miniBamboo/workshare/api/abis"
 	"github.com/miniBamboo/workshare/api/utils"
 	"github.com/miniBamboo/workshare/chain"
 	"github.com/miniBamboo/workshare/runtime"
 	"github.com/miniBamboo/workshare/state"
 	"github.com/miniBamboo/workshare/tx"
+	"github.com/miniBamboo/workshare/vm"
 	"github.com/miniBamboo/workshare/workshare"
 	"github.com/miniBamboo/workshare/xenv"
 	"github.com/pkg/errors"
 )
 
+// maxProofKeys is the max number of storage keys to be proven in a request.
+const maxProofKeys = 100
+
 type Accounts struct {
 	repo         *chain.Repository
 	stater       *state.Stater
 	callGasLimit uint64
 	forkConfig   workshare.ForkConfig
+	abis         *abis.Registry
 }
 
 func New(
@@ -37,12 +44,14 @@ func New(
 	stater *state.Stater,
 	callGasLimit uint64,
 	forkConfig workshare.ForkConfig,
+	abis *abis.Registry,
 ) *Accounts {
 	return &Accounts{
 		repo,
 		stater,
 		callGasLimit,
 		forkConfig,
+		abis,
 	}
 }
 
@@ -62,7 +71,7 @@ func (a *Accounts) handleGetCode(w http.ResponseWriter, req *http.Request) error
 	if err != nil {
 		return utils.BadRequest(errors.WithMessage(err, "address"))
 	}
-	summary, err := a.handleRevision(req.URL.Query().Get("revision"))
+	summary, err := utils.ParseRevision(a.repo, req.URL.Query().Get("revision"))
 	if err != nil {
 		return err
 	}
@@ -111,7 +120,7 @@ func (a *Accounts) handleGetAccount(w http.ResponseWriter, req *http.Request) er
 	if err != nil {
 		return utils.BadRequest(errors.WithMessage(err, "address"))
 	}
-	summary, err := a.handleRevision(req.URL.Query().Get("revision"))
+	summary, err := utils.ParseRevision(a.repo, req.URL.Query().Get("revision"))
 	if err != nil {
 		return err
 	}
@@ -131,7 +140,7 @@ func (a *Accounts) handleGetStorage(w http.ResponseWriter, req *http.Request) er
 	if err != nil {
 		return utils.BadRequest(errors.WithMessage(err, "key"))
 	}
-	summary, err := a.handleRevision(req.URL.Query().Get("revision"))
+	summary, err := utils.ParseRevision(a.repo, req.URL.Query().Get("revision"))
 	if err != nil {
 		return err
 	}
@@ -142,12 +151,49 @@ func (a *Accounts) handleGetStorage(w http.ResponseWriter, req *http.Request) er
 	return utils.WriteJSON(w, map[string]string{"value": storage.String()})
 }
 
+func (a *Accounts) handleGetProof(w http.ResponseWriter, req *http.Request) error {
+	addr, err := workshare.ParseAddress(mux.Vars(req)["address"])
+	if err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "address"))
+	}
+	var keys []workshare.Bytes32
+	if s := req.URL.Query().Get("keys"); s != "" {
+		parts := strings.Split(s, ",")
+		if len(parts) > maxProofKeys {
+			return utils.BadRequest(errors.New("keys: too many keys"))
+		}
+		for _, part := range parts {
+			key, err := workshare.ParseBytes32(part)
+			if err != nil {
+				return utils.BadRequest(errors.WithMessage(err, "keys"))
+			}
+			keys = append(keys, key)
+		}
+	}
+	summary, err := utils.ParseRevision(a.repo, req.URL.Query().Get("revision"))
+	if err != nil {
+		return err
+	}
+	header := summary.Header
+	proof, err := a.stater.
+		NewState(header.StateRoot(), header.Number(), summary.Conflicts, summary.SteadyNum).
+		Prove(addr, keys)
+	if err != nil {
+		return err
+	}
+	return utils.WriteJSON(w, convertProof(addr, proof, header))
+}
+
 func (a *Accounts) handleCallContract(w http.ResponseWriter, req *http.Request) error {
 	callData := &CallData{}
 	if err := utils.ParseJSON(req.Body, &callData); err != nil {
 		return utils.BadRequest(errors.WithMessage(err, "body"))
 	}
-	summary, err := a.handleRevision(req.URL.Query().Get("revision"))
+	summary, err := utils.ParseRevision(a.repo, req.URL.Query().Get("revision"))
+	if err != nil {
+		return err
+	}
+	decoded, err := utils.ParseDecoded(req)
 	if err != nil {
 		return err
 	}
@@ -167,11 +213,12 @@ func (a *Accounts) handleCallContract(w http.ResponseWriter, req *http.Request)
 				Data:  callData.Data,
 			},
 		},
-		Gas:      callData.Gas,
-		GasPrice: callData.GasPrice,
-		Caller:   callData.Caller,
+		Gas:            callData.Gas,
+		GasPrice:       callData.GasPrice,
+		Caller:         callData.Caller,
+		StateOverrides: callData.StateOverrides,
 	}
-	results, err := a.batchCall(req.Context(), batchCallData, summary)
+	results, err := a.batchCall(req.Context(), batchCallData, summary, decoded)
 	if err != nil {
 		return err
 	}
@@ -183,36 +230,35 @@ func (a *Accounts) handleCallBatchCode(w http.ResponseWriter, req *http.Request)
 	if err := utils.ParseJSON(req.Body, &batchCallData); err != nil {
 		return utils.BadRequest(errors.WithMessage(err, "body"))
 	}
-	h, err := a.handleRevision(req.URL.Query().Get("revision"))
+	h, err := utils.ParseRevision(a.repo, req.URL.Query().Get("revision"))
 	if err != nil {
 		return err
 	}
-	results, err := a.batchCall(req.Context(), batchCallData, h)
+	decoded, err := utils.ParseDecoded(req)
+	if err != nil {
+		return err
+	}
+	results, err := a.batchCall(req.Context(), batchCallData, h, decoded)
 	if err != nil {
 		return err
 	}
 	return utils.WriteJSON(w, results)
 }
 
-func (a *Accounts) batchCall(ctx context.Context, batchCallData *BatchCallData, summary *chain.BlockSummary) (results BatchCallResults, err error) {
+func (a *Accounts) batchCall(ctx context.Context, batchCallData *BatchCallData, summary *chain.BlockSummary, decoded bool) (results BatchCallResults, err error) {
 	txCtx, gas, clauses, err := a.handleBatchCallData(batchCallData)
 	if err != nil {
 		return nil, err
 	}
-	header := summary.Header
-	state := a.stater.NewState(header.StateRoot(), header.Number(), summary.Conflicts, summary.SteadyNum)
-
-	signer, _ := header.Signer()
-	rt := runtime.New(a.repo.NewChain(header.ParentID()), state,
-		&xenv.BlockContext{
-			Beneficiary: header.Beneficiary(),
-			Signer:      signer,
-			Number:      header.Number(),
-			Time:        header.Timestamp(),
-			GasLimit:    header.GasLimit(),
-			TotalScore:  header.TotalScore(),
-		},
-		a.forkConfig)
+	rt := utils.NewRuntime(a.repo, a.stater, summary, a.forkConfig)
+	if err := applyStateOverrides(rt.State(), batchCallData.StateOverrides, summary.Header.Timestamp()); err != nil {
+		return nil, err
+	}
+	var decoder *abis.Decoder
+	if decoded {
+		// contracts are resolved by code hash in the call state, so that overridden code applies
+		decoder = a.abis.NewDecoder(rt.State())
+	}
 	results = make(BatchCallResults, 0)
 	resultCh := make(chan interface{}, 1)
 	for i, clause := range clauses {
@@ -233,7 +279,13 @@ func (a *Accounts) batchCall(ctx context.Context, batchCallData *BatchCallData,
 			case error:
 				return nil, v
 			case *runtime.Output:
-				results = append(results, convertCallResultWithInputGas(v, gas))
+				result := convertCallResultWithInputGas(v, gas)
+				if decoder != nil {
+					if err := decodeCallResult(decoder, clause, v, result); err != nil {
+						return nil, err
+					}
+				}
+				results = append(results, result)
 				if v.VMErr != nil {
 					return results, nil
 				}
@@ -244,6 +296,175 @@ func (a *Accounts) batchCall(ctx context.Context, batchCallData *BatchCallData,
 	return results, nil
 }
 
+// runClauses executes clauses in order as a tx does, the execution gas is shared by clauses.
+// It returns the output and index of the last executed clause.
+func (a *Accounts) runClauses(ctx context.Context, summary *chain.BlockSummary, txCtx *xenv.TransactionContext, gas uint64, clauses []*tx.Clause) (*runtime.Output, int, error) {
+	type result struct {
+		output *runtime.Output
+		err    error
+	}
+	var (
+		rt       = utils.NewRuntime(a.repo, a.stater, summary, a.forkConfig)
+		resultCh = make(chan result, 1)
+		output   *runtime.Output
+	)
+	for i, clause := range clauses {
+		exec, interrupt := rt.PrepareClause(clause, uint32(i), gas, txCtx)
+		go func() {
+			out, _, err := exec()
+			resultCh <- result{out, err}
+		}()
+		select {
+		case <-ctx.Done():
+			interrupt()
+			return nil, 0, ctx.Err()
+		case r := <-resultCh:
+			if r.err != nil {
+				return nil, 0, r.err
+			}
+			output = r.output
+		}
+		if output.VMErr != nil {
+			return output, i, nil
+		}
+		gas = output.LeftOverGas
+	}
+	return output, len(clauses) - 1, nil
+}
+
+// estimateGas estimates the gas for the clauses to all succeed, or reports the failing clause if they
+// never do within the call gas limit.
+func (a *Accounts) estimateGas(ctx context.Context, estimateGasData *EstimateGasData, summary *chain.BlockSummary) (*EstimateGasResult, error) {
+	gasPayer := estimateGasData.Caller
+	if estimateGasData.Delegator != nil {
+		gasPayer = estimateGasData.Delegator
+	}
+	txCtx, _, clauses, err := a.handleBatchCallData(&BatchCallData{
+		Clauses:    estimateGasData.Clauses,
+		GasPrice:   estimateGasData.GasPrice,
+		ProvedWork: estimateGasData.ProvedWork,
+		Caller:     estimateGasData.Caller,
+		GasPayer:   gasPayer,
+		Expiration: estimateGasData.Expiration,
+		BlockRef:   estimateGasData.BlockRef,
+	})
+	if err != nil {
+		return nil, err
+	}
+	intrinsicGas, err := tx.IntrinsicGas(clauses...)
+	if err != nil {
+		return nil, utils.BadRequest(errors.WithMessage(err, "clauses"))
+	}
+	result := &EstimateGasResult{IntrinsicGas: intrinsicGas}
+	if len(clauses) == 0 {
+		result.Gas = intrinsicGas
+		return result, nil
+	}
+
+	hi := a.callGasLimit
+	output, index, err := a.runClauses(ctx, summary, txCtx, hi, clauses)
+	if err != nil {
+		return nil, err
+	}
+	if output.VMErr != nil {
+		// never succeeds within the limit
+		clauseIndex := uint32(index)
+		result.Gas = intrinsicGas + hi - output.LeftOverGas
+		result.Reverted = true
+		result.VMError = output.VMErr.Error()
+		result.ClauseIndex = &clauseIndex
+		if output.VMErr == vm.ErrExecutionReverted {
+			if reason, err := abi.UnpackRevert(output.Data); err == nil {
+				result.RevertReason = reason
+			}
+		}
+		return result, nil
+	}
+
+	gas, err := utils.SearchGas(hi, hi-output.LeftOverGas, func(gas uint64) (bool, error) {
+		output, _, err := a.runClauses(ctx, summary, txCtx, gas, clauses)
+		if err != nil {
+			return false, err
+		}
+		return output.VMErr == nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Gas = intrinsicGas + gas
+	return result, nil
+}
+
+func (a *Accounts) handleEstimateGas(w http.ResponseWriter, req *http.Request) error {
+	estimateGasData := &EstimateGasData{}
+	if err := utils.ParseJSON(req.Body, &estimateGasData); err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "body"))
+	}
+	summary, err := utils.ParseRevision(a.repo, req.URL.Query().Get("revision"))
+	if err != nil {
+		return err
+	}
+	result, err := a.estimateGas(req.Context(), estimateGasData, summary)
+	if err != nil {
+		return err
+	}
+	return utils.WriteJSON(w, result)
+}
+
+// applyStateOverrides applies overrides on the throwaway state before executing clauses.
+func applyStateOverrides(st *state.State, overrides StateOverrides, blockTime uint64) error {
+	for key, override := range overrides {
+		addr, err := workshare.ParseAddress(key)
+		if err != nil {
+			return utils.BadRequest(errors.WithMessage(err, "stateOverrides"))
+		}
+		if override == nil {
+			continue
+		}
+		if override.Storage != nil && override.StorageDiff != nil {
+			return utils.BadRequest(fmt.Errorf("stateOverrides[%v]: storage and storageDiff are mutually exclusive", addr))
+		}
+		if override.Balance != nil {
+			if err := st.SetBalance(addr, (*big.Int)(override.Balance)); err != nil {
+				return err
+			}
+		}
+		if override.Energy != nil {
+			if err := st.SetEnergy(addr, (*big.Int)(override.Energy), blockTime); err != nil {
+				return err
+			}
+		}
+		if override.Code != nil {
+			code, err := hexutil.Decode(*override.Code)
+			if err != nil {
+				return utils.BadRequest(errors.WithMessage(err, fmt.Sprintf("stateOverrides[%v].code", addr)))
+			}
+			if err := st.SetCode(addr, code); err != nil {
+				return err
+			}
+		}
+		slots := override.StorageDiff
+		if override.Storage != nil {
+			if err := st.ClearStorage(addr); err != nil {
+				return err
+			}
+			slots = override.Storage
+		}
+		for k, v := range slots {
+			key, err := workshare.ParseBytes32(k)
+			if err != nil {
+				return utils.BadRequest(errors.WithMessage(err, fmt.Sprintf("stateOverrides[%v].storage", addr)))
+			}
+			value, err := workshare.ParseBytes32(v)
+			if err != nil {
+				return utils.BadRequest(errors.WithMessage(err, fmt.Sprintf("stateOverrides[%v].storage[%v]", addr, key)))
+			}
+			st.SetStorage(addr, key, value)
+		}
+	}
+	return nil
+}
+
 func (a *Accounts) handleBatchCallData(batchCallData *BatchCallData) (txCtx *xenv.TransactionContext, gas uint64, clauses []*tx.Clause, err error) {
 	if batchCallData.Gas > a.callGasLimit {
 		return nil, 0, nil, utils.Forbidden(errors.New("gas: exceeds limit"))
@@ -311,48 +532,15 @@ func (a *Accounts) handleBatchCallData(batchCallData *BatchCallData) (txCtx *xen
 	return
 }
 
-func (a *Accounts) handleRevision(revision string) (*chain.BlockSummary, error) {
-	if revision == "" || revision == "best" {
-		return a.repo.BestBlockSummary(), nil
-	}
-	if len(revision) == 66 || len(revision) == 64 {
-		blockID, err := workshare.ParseBytes32(revision)
-		if err != nil {
-			return nil, utils.BadRequest(errors.WithMessage(err, "revision"))
-		}
-		summary, err := a.repo.GetBlockSummary(blockID)
-		if err != nil {
-			if a.repo.IsNotFound(err) {
-				return nil, utils.BadRequest(errors.WithMessage(err, "revision"))
-			}
-			return nil, err
-		}
-		return summary, nil
-	}
-	n, err := strconv.ParseUint(revision, 0, 0)
-	if err != nil {
-		return nil, utils.BadRequest(errors.WithMessage(err, "revision"))
-	}
-	if n > math.MaxUint32 {
-		return nil, utils.BadRequest(errors.WithMessage(errors.New("block number out of max uint32"), "revision"))
-	}
-	summary, err := a.repo.NewBestChain().GetBlockSummary(uint32(n))
-	if err != nil {
-		if a.repo.IsNotFound(err) {
-			return nil, utils.BadRequest(errors.WithMessage(err, "revision"))
-		}
-		return nil, err
-	}
-	return summary, nil
-}
-
 func (a *Accounts) Mount(root *mux.Router, pathPrefix string) {
 	sub := root.PathPrefix(pathPrefix).Subrouter()
 
 	sub.Path("/*").Methods("POST").HandlerFunc(utils.WrapHandlerFunc(a.handleCallBatchCode))
 	sub.Path("/{address}").Methods(http.MethodGet).HandlerFunc(utils.WrapHandlerFunc(a.handleGetAccount))
 	sub.Path("/{address}/code").Methods(http.MethodGet).HandlerFunc(utils.WrapHandlerFunc(a.handleGetCode))
+	sub.Path("/{address}/proof").Methods(http.MethodGet).HandlerFunc(utils.WrapHandlerFunc(a.handleGetProof))
 	sub.Path("/{address}/storage/{key}").Methods("GET").HandlerFunc(utils.WrapHandlerFunc(a.handleGetStorage))
+	sub.Path("/estimate-gas").Methods("POST").HandlerFunc(utils.WrapHandlerFunc(a.handleEstimateGas))
 	sub.Path("").Methods("POST").HandlerFunc(utils.WrapHandlerFunc(a.handleCallContract))
 	sub.Path("/{address}").Methods("POST").HandlerFunc(utils.WrapHandlerFunc(a.handleCallContract))
 
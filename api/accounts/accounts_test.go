@@ -21,6 +21,7 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/gorilla/mux"
 	ABI "github.com/miniBamboo/workshare/abi"
+	"github.com/miniBamboo/workshare/api/abis"
 	"github.com/miniBamboo/workshare/api/accounts"
 	"github.com/miniBamboo/workshare/chain"
 	"github.com/miniBamboo/workshare/genesis"
@@ -105,9 +106,12 @@ func TestAccount(t *testing.T) {
 	getAccount(t)
 	getCode(t)
 	getStorage(t)
+	getProof(t)
 	deployContractWithCall(t)
 	callContract(t)
 	batchCall(t)
+	estimateGas(t)
+	callWithStateOverrides(t)
 }
 
 func getAccount(t *testing.T) {
@@ -173,6 +177,25 @@ func getStorage(t *testing.T) {
 	assert.Equal(t, http.StatusOK, statusCode, "OK")
 }
 
+func getProof(t *testing.T) {
+	_, statusCode := httpGet(t, ts.URL+"/accounts/"+contractAddr.String()+"/proof?keys="+invalidBytes32)
+	assert.Equal(t, http.StatusBadRequest, statusCode, "bad storage key")
+
+	missingKey := workshare.BytesToBytes32([]byte("missing"))
+	res, statusCode := httpGet(t, ts.URL+"/accounts/"+contractAddr.String()+"/proof?keys="+storageKey.String()+","+missingKey.String())
+	assert.Equal(t, http.StatusOK, statusCode)
+	var proof accounts.Proof
+	if err := json.Unmarshal(res, &proof); err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, proof.Verify(proof.Meta.StateRoot))
+	assert.Equal(t, workshare.BytesToBytes32([]byte{storageValue}), proof.StorageProof[0].Value)
+	assert.Equal(t, workshare.Bytes32{}, proof.StorageProof[1].Value)
+
+	proof.StorageProof[0].Value = workshare.Bytes32{}
+	assert.NotNil(t, proof.Verify(proof.Meta.StateRoot), "should fail with tampered value")
+}
+
 func initAccountServer(t *testing.T) {
 	db := muxdb.NewMem()
 	stater := state.NewStater(db)
@@ -200,8 +223,15 @@ func initAccountServer(t *testing.T) {
 	transactionCall := buildTxWithClauses(t, repo.ChainTag(), claCall)
 	packTx(repo, stater, transactionCall, t)
 
+	abiRegistry, err := abis.NewRegistry("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := abiRegistry.Register(contractAddr.String(), []byte(abiJSON)); err != nil {
+		t.Fatal(err)
+	}
 	router := mux.NewRouter()
-	accounts.New(repo, stater, math.MaxUint64, workshare.NoFork).Mount(router, "/accounts")
+	accounts.New(repo, stater, math.MaxUint64, workshare.NoFork, abiRegistry).Mount(router, "/accounts")
 	ts = httptest.NewServer(router)
 }
 
@@ -308,6 +338,21 @@ func callContract(t *testing.T) {
 	}
 	assert.Equal(t, http.StatusOK, statusCode)
 	assert.Equal(t, a+b, ret)
+	assert.Nil(t, output.Decoded)
+
+	res, statusCode = httpPost(t, ts.URL+"/accounts/"+contractAddr.String()+"?decoded=true", reqBody)
+	assert.Equal(t, http.StatusOK, statusCode)
+	if err = json.Unmarshal(res, &output); err != nil {
+		t.Fatal(err)
+	}
+	if assert.NotNil(t, output.Decoded) {
+		assert.Equal(t, "add", output.Decoded.Method)
+		assert.Equal(t, "add(uint8,uint8)", output.Decoded.Signature)
+		assert.Equal(t, "3", output.Decoded.Outputs[0].Value)
+	}
+
+	_, statusCode = httpPost(t, ts.URL+"/accounts/"+contractAddr.String()+"?decoded=1", reqBody)
+	assert.Equal(t, http.StatusBadRequest, statusCode, "invalid decoded")
 }
 
 func batchCall(t *testing.T) {
@@ -387,6 +432,93 @@ func batchCall(t *testing.T) {
 	assert.Equal(t, http.StatusOK, statusCode)
 }
 
+func callWithStateOverrides(t *testing.T) {
+	abi, err := ABI.New([]byte(abiJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, _ := abi.MethodByName("add")
+	input, err := m.EncodeInput(uint8(1), uint8(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := workshare.BytesToAddress([]byte("override"))
+	code := hexutil.Encode(runtimeBytecode)
+	reqBody := &accounts.BatchCallData{
+		Clauses: accounts.Clauses{
+			accounts.Clause{
+				To:   &target,
+				Data: hexutil.Encode(input),
+			}},
+		StateOverrides: accounts.StateOverrides{
+			target.String(): &accounts.AccountOverride{
+				Code:    &code,
+				Storage: map[string]string{workshare.Bytes32{}.String(): workshare.BytesToBytes32([]byte{1}).String()},
+			},
+		},
+	}
+	res, statusCode := httpPost(t, ts.URL+"/accounts/*", reqBody)
+	assert.Equal(t, http.StatusOK, statusCode)
+	var results accounts.BatchCallResults
+	if err := json.Unmarshal(res, &results); err != nil {
+		t.Fatal(err)
+	}
+	data, err := hexutil.Decode(results[0].Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ret uint8
+	if err := m.DecodeOutput(data, &ret); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint8(3), ret, "should execute the overridden code")
+
+	reqBody.StateOverrides[target.String()].StorageDiff = map[string]string{}
+	_, statusCode = httpPost(t, ts.URL+"/accounts/*", reqBody)
+	assert.Equal(t, http.StatusBadRequest, statusCode, "storage and storageDiff are mutually exclusive")
+}
+
+func estimateGas(t *testing.T) {
+	abi, err := ABI.New([]byte(abiJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, _ := abi.MethodByName("add")
+	input, err := m.EncodeInput(uint8(1), uint8(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reqBody := &accounts.EstimateGasData{
+		Clauses: accounts.Clauses{
+			accounts.Clause{
+				To:   &contractAddr,
+				Data: hexutil.Encode(input),
+			},
+			accounts.Clause{
+				To:   &contractAddr,
+				Data: hexutil.Encode(input),
+			}},
+	}
+	res, statusCode := httpPost(t, ts.URL+"/accounts/estimate-gas", reqBody)
+	assert.Equal(t, http.StatusOK, statusCode)
+	var result accounts.EstimateGasResult
+	if err := json.Unmarshal(res, &result); err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, result.Reverted)
+	assert.True(t, result.Gas > result.IntrinsicGas)
+
+	// unknown method
+	reqBody.Clauses[1].Data = "0x12345678"
+	res, statusCode = httpPost(t, ts.URL+"/accounts/estimate-gas", reqBody)
+	assert.Equal(t, http.StatusOK, statusCode)
+	if err := json.Unmarshal(res, &result); err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, result.Reverted)
+	assert.Equal(t, uint32(1), *result.ClauseIndex)
+}
+
 func httpPost(t *testing.T, url string, body interface{}) ([]byte, int) {
 	data, err := json.Marshal(body)
 	if err != nil {
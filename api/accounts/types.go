@@ -6,11 +6,20 @@
 package accounts
 
 import (
+	"bytes"
+	"fmt"
+	"math/big"
+
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/miniBamboo/workshare/api/abis"
 	"github.com/miniBamboo/workshare/api/transactions"
+	"github.com/miniBamboo/workshare/block"
 	"github.com/miniBamboo/workshare/runtime"
+	"github.com/miniBamboo/workshare/state"
+	"github.com/miniBamboo/workshare/tx"
 	"github.com/miniBamboo/workshare/workshare"
+	"github.com/pkg/errors"
 )
 
 //Account for marshal account
@@ -22,11 +31,12 @@ type Account struct {
 
 //CallData represents contract-call body
 type CallData struct {
-	Value    *math.HexOrDecimal256 `json:"value"`
-	Data     string                `json:"data"`
-	Gas      uint64                `json:"gas"`
-	GasPrice *math.HexOrDecimal256 `json:"gasPrice"`
-	Caller   *workshare.Address    `json:"caller"`
+	Value          *math.HexOrDecimal256 `json:"value"`
+	Data           string                `json:"data"`
+	Gas            uint64                `json:"gas"`
+	GasPrice       *math.HexOrDecimal256 `json:"gasPrice"`
+	Caller         *workshare.Address    `json:"caller"`
+	StateOverrides StateOverrides        `json:"stateOverrides"`
 }
 
 type CallResult struct {
@@ -36,6 +46,7 @@ type CallResult struct {
 	GasUsed   uint64                   `json:"gasUsed"`
 	Reverted  bool                     `json:"reverted"`
 	VMError   string                   `json:"vmError"`
+	Decoded   *abis.DecodedCall        `json:"decoded,omitempty"`
 }
 
 func convertCallResultWithInputGas(vo *runtime.Output, inputGas uint64) *CallResult {
@@ -83,6 +94,19 @@ func convertCallResultWithInputGas(vo *runtime.Output, inputGas uint64) *CallRes
 	}
 }
 
+// decodeCallResult sets the decoded output and events of the converted call result.
+func decodeCallResult(decoder *abis.Decoder, clause *tx.Clause, vo *runtime.Output, result *CallResult) (err error) {
+	if result.Decoded, err = decoder.DecodeCall(clause.To(), clause.Data(), vo.Data, vo.VMErr != nil); err != nil {
+		return err
+	}
+	for i, ev := range vo.Events {
+		if result.Events[i].Decoded, err = decoder.DecodeEvent(ev.Address, ev.Topics, ev.Data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type Clause struct {
 	To    *workshare.Address    `json:"to"`
 	Value *math.HexOrDecimal256 `json:"value"`
@@ -94,14 +118,171 @@ type Clauses []Clause
 
 //BatchCallData executes a batch of codes
 type BatchCallData struct {
+	Clauses        Clauses               `json:"clauses"`
+	Gas            uint64                `json:"gas"`
+	GasPrice       *math.HexOrDecimal256 `json:"gasPrice"`
+	ProvedWork     *math.HexOrDecimal256 `json:"provedWork"`
+	Caller         *workshare.Address    `json:"caller"`
+	GasPayer       *workshare.Address    `json:"gasPayer"`
+	Expiration     uint32                `json:"expiration"`
+	BlockRef       string                `json:"blockRef"`
+	StateOverrides StateOverrides        `json:"stateOverrides"`
+}
+
+// StateOverrides maps account address to the overrides applied before executing clauses.
+type StateOverrides map[string]*AccountOverride
+
+// AccountOverride overrides fields of an account.
+// Storage replaces the whole storage of the account, while StorageDiff only overrides given slots.
+// They are mutually exclusive.
+type AccountOverride struct {
+	Balance     *math.HexOrDecimal256 `json:"balance"`
+	Energy      *math.HexOrDecimal256 `json:"energy"`
+	Code        *string               `json:"code"`
+	Storage     map[string]string     `json:"storage"`
+	StorageDiff map[string]string     `json:"storageDiff"`
+}
+
+type BatchCallResults []*CallResult
+
+// EstimateGasData is the input of gas estimation, which describes a tx to be sent.
+type EstimateGasData struct {
 	Clauses    Clauses               `json:"clauses"`
-	Gas        uint64                `json:"gas"`
 	GasPrice   *math.HexOrDecimal256 `json:"gasPrice"`
 	ProvedWork *math.HexOrDecimal256 `json:"provedWork"`
 	Caller     *workshare.Address    `json:"caller"`
-	GasPayer   *workshare.Address    `json:"gasPayer"`
+	Delegator  *workshare.Address    `json:"delegator"`
 	Expiration uint32                `json:"expiration"`
 	BlockRef   string                `json:"blockRef"`
 }
 
-type BatchCallResults []*CallResult
+// EstimateGasResult is the result of gas estimation.
+type EstimateGasResult struct {
+	// Gas is the estimated tx gas, including the intrinsic gas.
+	// It's the gas used if the execution never succeeds.
+	Gas          uint64 `json:"gas"`
+	IntrinsicGas uint64 `json:"intrinsicGas"`
+	Reverted     bool   `json:"reverted"`
+	VMError      string `json:"vmError"`
+	RevertReason string `json:"revertReason"`
+	// ClauseIndex is the index of the failed clause if reverted.
+	ClauseIndex *uint32 `json:"clauseIndex,omitempty"`
+}
+
+// StorageProof is the merkle proof of a storage value.
+type StorageProof struct {
+	Key   workshare.Bytes32 `json:"key"`
+	Value workshare.Bytes32 `json:"value"`
+	Proof []string          `json:"proof"`
+}
+
+// ProofMeta describes the block the proof is against.
+type ProofMeta struct {
+	BlockID     workshare.Bytes32 `json:"blockID"`
+	BlockNumber uint32            `json:"blockNumber"`
+	StateRoot   workshare.Bytes32 `json:"stateRoot"`
+}
+
+// Proof is the merkle proof of an account and its storage values.
+// Account fields are in the raw form stored in the account trie, e.g. energy is the value at blockTime.
+type Proof struct {
+	Address      workshare.Address     `json:"address"`
+	Balance      *math.HexOrDecimal256 `json:"balance"`
+	Energy       *math.HexOrDecimal256 `json:"energy"`
+	BlockTime    uint64                `json:"blockTime"`
+	Master       hexutil.Bytes         `json:"master"`
+	CodeHash     hexutil.Bytes         `json:"codeHash"`
+	StorageRoot  hexutil.Bytes         `json:"storageRoot"`
+	AccountProof []string              `json:"accountProof"`
+	StorageProof []*StorageProof       `json:"storageProof"`
+	Meta         ProofMeta             `json:"meta"`
+}
+
+func convertProof(addr workshare.Address, proof *state.AccountProof, header *block.Header) *Proof {
+	acc := proof.Account
+	p := &Proof{
+		Address:      addr,
+		Balance:      (*math.HexOrDecimal256)(acc.Balance),
+		Energy:       (*math.HexOrDecimal256)(acc.Energy),
+		BlockTime:    acc.BlockTime,
+		Master:       acc.Master,
+		CodeHash:     acc.CodeHash,
+		StorageRoot:  acc.StorageRoot,
+		AccountProof: encodeProofList(proof.Proof),
+		StorageProof: make([]*StorageProof, 0, len(proof.StorageProofs)),
+		Meta: ProofMeta{
+			header.ID(),
+			header.Number(),
+			header.StateRoot(),
+		},
+	}
+	for _, sp := range proof.StorageProofs {
+		p.StorageProof = append(p.StorageProof, &StorageProof{
+			Key:   sp.Key,
+			Value: sp.Value,
+			Proof: encodeProofList(sp.Proof),
+		})
+	}
+	return p
+}
+
+func encodeProofList(proof state.ProofList) []string {
+	nodes := make([]string, 0, len(proof))
+	for _, node := range proof {
+		nodes = append(nodes, hexutil.Encode(node))
+	}
+	return nodes
+}
+
+func decodeProofList(nodes []string) (state.ProofList, error) {
+	proof := make(state.ProofList, 0, len(nodes))
+	for _, node := range nodes {
+		data, err := hexutil.Decode(node)
+		if err != nil {
+			return nil, err
+		}
+		proof = append(proof, data)
+	}
+	return proof, nil
+}
+
+// Verify verifies the proof against the given state root offline.
+// It returns error if any proof is invalid, or any value mismatches the proven one.
+func (p *Proof) Verify(stateRoot workshare.Bytes32) error {
+	accountProof, err := decodeProofList(p.AccountProof)
+	if err != nil {
+		return errors.WithMessage(err, "accountProof")
+	}
+	acc, err := state.VerifyAccountProof(stateRoot, p.Address, accountProof)
+	if err != nil {
+		return errors.WithMessage(err, "accountProof")
+	}
+	switch {
+	case p.Balance == nil || acc.Balance.Cmp((*big.Int)(p.Balance)) != 0:
+		return errors.New("balance mismatch")
+	case p.Energy == nil || acc.Energy.Cmp((*big.Int)(p.Energy)) != 0:
+		return errors.New("energy mismatch")
+	case acc.BlockTime != p.BlockTime:
+		return errors.New("blockTime mismatch")
+	case !bytes.Equal(acc.Master, p.Master):
+		return errors.New("master mismatch")
+	case !bytes.Equal(acc.CodeHash, p.CodeHash):
+		return errors.New("codeHash mismatch")
+	case !bytes.Equal(acc.StorageRoot, p.StorageRoot):
+		return errors.New("storageRoot mismatch")
+	}
+	for i, sp := range p.StorageProof {
+		proof, err := decodeProofList(sp.Proof)
+		if err != nil {
+			return errors.WithMessage(err, fmt.Sprintf("storageProof[%d]", i))
+		}
+		value, err := state.VerifyStorageProof(acc.StorageRoot, sp.Key, proof)
+		if err != nil {
+			return errors.WithMessage(err, fmt.Sprintf("storageProof[%d]", i))
+		}
+		if value != sp.Value {
+			return fmt.Errorf("storageProof[%d]: value mismatch", i)
+		}
+	}
+	return nil
+}
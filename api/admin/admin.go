@@ -0,0 +1,172 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package admin
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/gorilla/mux"
+	"github.com/miniBamboo/workshare/api/utils"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultBanDuration = time.Hour
+	maxBanDuration     = 30 * 24 * time.Hour
+)
+
+type Admin struct {
+	pm PeerManager
+}
+
+func New(pm PeerManager) *Admin {
+	return &Admin{
+		pm,
+	}
+}
+
+func (a *Admin) handleGetPeers(w http.ResponseWriter, req *http.Request) error {
+	return utils.WriteJSON(w, a.pm.PeersInfo())
+}
+
+func parseEnode(req *http.Request) (*discover.Node, error) {
+	var body EnodeBody
+	if err := utils.ParseJSON(req.Body, &body); err != nil {
+		return nil, utils.BadRequest(errors.WithMessage(err, "body"))
+	}
+	node, err := discover.ParseNode(body.Enode)
+	if err != nil {
+		return nil, utils.BadRequest(errors.WithMessage(err, "enode"))
+	}
+	return node, nil
+}
+
+func parseNodeID(req *http.Request) (discover.NodeID, error) {
+	id, err := discover.HexID(mux.Vars(req)["id"])
+	if err != nil {
+		return discover.NodeID{}, utils.BadRequest(errors.WithMessage(err, "id"))
+	}
+	return id, nil
+}
+
+func (a *Admin) handleAddStatic(w http.ResponseWriter, req *http.Request) error {
+	node, err := parseEnode(req)
+	if err != nil {
+		return err
+	}
+	a.pm.AddStatic(node)
+	return utils.WriteJSON(w, &Result{true})
+}
+
+func (a *Admin) handleRemoveStatic(w http.ResponseWriter, req *http.Request) error {
+	node, err := parseEnode(req)
+	if err != nil {
+		return err
+	}
+	a.pm.RemoveStatic(node)
+	return utils.WriteJSON(w, &Result{true})
+}
+
+func (a *Admin) handleAddTrusted(w http.ResponseWriter, req *http.Request) error {
+	node, err := parseEnode(req)
+	if err != nil {
+		return err
+	}
+	a.pm.AddTrusted(node)
+	return utils.WriteJSON(w, &Result{true})
+}
+
+func (a *Admin) handleRemoveTrusted(w http.ResponseWriter, req *http.Request) error {
+	node, err := parseEnode(req)
+	if err != nil {
+		return err
+	}
+	a.pm.RemoveTrusted(node)
+	return utils.WriteJSON(w, &Result{true})
+}
+
+func (a *Admin) handleDisconnect(w http.ResponseWriter, req *http.Request) error {
+	id, err := parseNodeID(req)
+	if err != nil {
+		return err
+	}
+	return utils.WriteJSON(w, &Result{a.pm.Disconnect(id)})
+}
+
+func (a *Admin) handleBan(w http.ResponseWriter, req *http.Request) error {
+	id, err := parseNodeID(req)
+	if err != nil {
+		return err
+	}
+	// body is optional
+	var body BanBody
+	if err := utils.ParseJSON(req.Body, &body); err != nil && err != io.EOF {
+		return utils.BadRequest(errors.WithMessage(err, "body"))
+	}
+	duration := defaultBanDuration
+	if body.Duration != nil {
+		if *body.Duration == 0 || *body.Duration > uint64(maxBanDuration/time.Second) {
+			return utils.BadRequest(errors.Errorf("duration: should be in range (0, %d]", uint64(maxBanDuration/time.Second)))
+		}
+		duration = time.Duration(*body.Duration) * time.Second
+	}
+	a.pm.Ban(id, duration)
+	return utils.WriteJSON(w, &Result{true})
+}
+
+func (a *Admin) handleUnban(w http.ResponseWriter, req *http.Request) error {
+	id, err := parseNodeID(req)
+	if err != nil {
+		return err
+	}
+	return utils.WriteJSON(w, &Result{a.pm.Unban(id)})
+}
+
+func (a *Admin) handleGetBanned(w http.ResponseWriter, req *http.Request) error {
+	banned := a.pm.BannedNodes()
+	nodes := make([]*BannedNode, 0, len(banned))
+	for id, until := range banned {
+		nodes = append(nodes, &BannedNode{
+			ID:    id.String(),
+			Until: uint64(until.Unix()),
+		})
+	}
+	return utils.WriteJSON(w, nodes)
+}
+
+func (a *Admin) handleGetDiscovery(w http.ResponseWriter, req *http.Request) error {
+	discovered := a.pm.DiscoveredNodes()
+	table := a.pm.TableNodes()
+	disc := &Discovery{
+		Discovered: make([]string, 0, len(discovered)),
+		Table:      make([]string, 0, len(table)),
+	}
+	for _, node := range discovered {
+		disc.Discovered = append(disc.Discovered, node.String())
+	}
+	for _, node := range table {
+		disc.Table = append(disc.Table, node.String())
+	}
+	return utils.WriteJSON(w, disc)
+}
+
+func (a *Admin) Mount(root *mux.Router, pathPrefix string) {
+	sub := root.PathPrefix(pathPrefix).Subrouter()
+
+	sub.Path("/peers").Methods(http.MethodGet).HandlerFunc(utils.WrapHandlerFunc(a.handleGetPeers))
+	sub.Path("/peers/static").Methods(http.MethodPost).HandlerFunc(utils.WrapHandlerFunc(a.handleAddStatic))
+	sub.Path("/peers/static").Methods(http.MethodDelete).HandlerFunc(utils.WrapHandlerFunc(a.handleRemoveStatic))
+	sub.Path("/peers/trusted").Methods(http.MethodPost).HandlerFunc(utils.WrapHandlerFunc(a.handleAddTrusted))
+	sub.Path("/peers/trusted").Methods(http.MethodDelete).HandlerFunc(utils.WrapHandlerFunc(a.handleRemoveTrusted))
+	sub.Path("/peers/banned").Methods(http.MethodGet).HandlerFunc(utils.WrapHandlerFunc(a.handleGetBanned))
+	sub.Path("/peers/{id}/disconnect").Methods(http.MethodPost).HandlerFunc(utils.WrapHandlerFunc(a.handleDisconnect))
+	sub.Path("/peers/{id}/ban").Methods(http.MethodPost).HandlerFunc(utils.WrapHandlerFunc(a.handleBan))
+	sub.Path("/peers/{id}/ban").Methods(http.MethodDelete).HandlerFunc(utils.WrapHandlerFunc(a.handleUnban))
+	sub.Path("/discovery").Methods(http.MethodGet).HandlerFunc(utils.WrapHandlerFunc(a.handleGetDiscovery))
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package admin_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/gorilla/mux"
+	"github.com/miniBamboo/workshare/api/admin"
+	"github.com/miniBamboo/workshare/p2psrv"
+	"github.com/miniBamboo/workshare/p2psrv/discv5"
+	"github.com/stretchr/testify/assert"
+)
+
+const enode = "enode://ba85011c70bcc5c04d8607d3a0ed29aa6179c092cbdda10d5d32684fb33ed01bd94f588ca8f91ac48318087dcb02eaf36773a7a453f0eedd6742af668097b29c@10.0.1.16:30303"
+
+type fakePeerManager struct {
+	static, trusted map[discover.NodeID]bool
+	banned          map[discover.NodeID]time.Time
+}
+
+func (pm *fakePeerManager) PeersInfo() []*p2p.PeerInfo                 { return nil }
+func (pm *fakePeerManager) AddStatic(node *discover.Node)              { pm.static[node.ID] = true }
+func (pm *fakePeerManager) RemoveStatic(node *discover.Node)           { delete(pm.static, node.ID) }
+func (pm *fakePeerManager) AddTrusted(node *discover.Node)             { pm.trusted[node.ID] = true }
+func (pm *fakePeerManager) RemoveTrusted(node *discover.Node)          { delete(pm.trusted, node.ID) }
+func (pm *fakePeerManager) Disconnect(id discover.NodeID) bool         { return false }
+func (pm *fakePeerManager) DiscoveredNodes() p2psrv.Nodes              { return nil }
+func (pm *fakePeerManager) TableNodes() []*discv5.Node                 { return nil }
+func (pm *fakePeerManager) BannedNodes() map[discover.NodeID]time.Time { return pm.banned }
+func (pm *fakePeerManager) Ban(id discover.NodeID, duration time.Duration) {
+	pm.banned[id] = time.Now().Add(duration)
+}
+func (pm *fakePeerManager) Unban(id discover.NodeID) bool {
+	_, ok := pm.banned[id]
+	delete(pm.banned, id)
+	return ok
+}
+
+func TestAdmin(t *testing.T) {
+	pm := &fakePeerManager{
+		static:  make(map[discover.NodeID]bool),
+		trusted: make(map[discover.NodeID]bool),
+		banned:  make(map[discover.NodeID]time.Time),
+	}
+	router := mux.NewRouter()
+	admin.New(pm).Mount(router, "/admin")
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	node := discover.MustParseNode(enode)
+
+	_, code := httpDo(t, "POST", ts.URL+"/admin/peers/static", &admin.EnodeBody{Enode: enode})
+	assert.Equal(t, http.StatusOK, code)
+	assert.True(t, pm.static[node.ID])
+
+	_, code = httpDo(t, "DELETE", ts.URL+"/admin/peers/static", &admin.EnodeBody{Enode: enode})
+	assert.Equal(t, http.StatusOK, code)
+	assert.False(t, pm.static[node.ID])
+
+	_, code = httpDo(t, "POST", ts.URL+"/admin/peers/trusted", &admin.EnodeBody{Enode: "invalid"})
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	_, code = httpDo(t, "POST", ts.URL+"/admin/peers/"+node.ID.String()+"/ban", nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, pm.banned, node.ID)
+
+	_, code = httpDo(t, "POST", ts.URL+"/admin/peers/"+node.ID.String()+"/ban", map[string]interface{}{"duration": 0})
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	res, _ := httpDo(t, "GET", ts.URL+"/admin/peers/banned", nil)
+	var banned []*admin.BannedNode
+	if err := json.Unmarshal(res, &banned); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, len(banned))
+	assert.Equal(t, node.ID.String(), banned[0].ID)
+
+	res, _ = httpDo(t, "DELETE", ts.URL+"/admin/peers/"+node.ID.String()+"/ban", nil)
+	var result admin.Result
+	if err := json.Unmarshal(res, &result); err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, result.Success)
+	assert.Empty(t, pm.banned)
+
+	_, code = httpDo(t, "POST", ts.URL+"/admin/peers/0x01/disconnect", nil)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func httpDo(t *testing.T, method, url string, body interface{}) ([]byte, int) {
+	var data []byte
+	if body != nil {
+		data, _ = json.Marshal(body)
+	}
+	req, err := http.NewRequest(method, url, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r, res.StatusCode
+}
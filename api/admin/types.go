@@ -0,0 +1,57 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package admin
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/miniBamboo/workshare/p2psrv"
+	"github.com/miniBamboo/workshare/p2psrv/discv5"
+)
+
+// PeerManager manages p2p peers at runtime.
+type PeerManager interface {
+	PeersInfo() []*p2p.PeerInfo
+	AddStatic(node *discover.Node)
+	RemoveStatic(node *discover.Node)
+	AddTrusted(node *discover.Node)
+	RemoveTrusted(node *discover.Node)
+	Disconnect(id discover.NodeID) bool
+	Ban(id discover.NodeID, duration time.Duration)
+	Unban(id discover.NodeID) bool
+	BannedNodes() map[discover.NodeID]time.Time
+	DiscoveredNodes() p2psrv.Nodes
+	TableNodes() []*discv5.Node
+}
+
+type EnodeBody struct {
+	Enode string `json:"enode"`
+}
+
+type BanBody struct {
+	// Duration is the ban duration in seconds. Defaults to 1 hour, and 30 days at most.
+	Duration *uint64 `json:"duration"`
+}
+
+// Result is the result of an admin operation.
+// Success is false if the operation has no effect, e.g. to disconnect a peer not connected.
+type Result struct {
+	Success bool `json:"success"`
+}
+
+type BannedNode struct {
+	ID    string `json:"id"`
+	Until uint64 `json:"until"` // unix timestamp the ban expires
+}
+
+type Discovery struct {
+	// Discovered nodes are candidates to dial.
+	Discovered []string `json:"discovered"`
+	// Table nodes are in the discovery table.
+	Table []string `json:"table"`
+}
@@ -9,26 +9,51 @@ import (
 	"net/http"
 	"net/http/pprof"
 	"strings"
+	"time"
 
 	assetfs "github.com/elazarl/go-bindata-assetfs"
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/miniBamboo/workshare/api/abis"
 	"github.com/miniBamboo/workshare/api/accounts"
+	"github.com/miniBamboo/workshare/api/auth"
 	"github.com/miniBamboo/workshare/api/blocks"
 	"github.com/miniBamboo/workshare/api/debug"
 	"github.com/miniBamboo/workshare/api/doc"
+	"github.com/miniBamboo/workshare/api/eth"
 	"github.com/miniBamboo/workshare/api/events"
+	"github.com/miniBamboo/workshare/api/graphql"
 	"github.com/miniBamboo/workshare/api/node"
 	"github.com/miniBamboo/workshare/api/subscriptions"
+	"github.com/miniBamboo/workshare/api/tokentransfers"
 	"github.com/miniBamboo/workshare/api/transactions"
 	"github.com/miniBamboo/workshare/api/transfers"
+	pool "github.com/miniBamboo/workshare/api/txpool"
 	"github.com/miniBamboo/workshare/chain"
 	"github.com/miniBamboo/workshare/logdb"
+	"github.com/miniBamboo/workshare/metric"
 	"github.com/miniBamboo/workshare/state"
 	"github.com/miniBamboo/workshare/txpool"
 	"github.com/miniBamboo/workshare/workshare"
 )
 
+var (
+	metricRequestDuration = metric.NewHistogramVec("api_request_duration_seconds", "Latency of API requests by method and route.", nil, "method", "route")
+	metricWebsocketCount  = metric.NewCounterVec("api_websocket_count", "Count of websocket connections by route.", "route")
+
+	// meteredMethods are methods labeled as is, others are labeled "other" to bound the metric cardinality.
+	meteredMethods = map[string]bool{
+		http.MethodGet:     true,
+		http.MethodHead:    true,
+		http.MethodPost:    true,
+		http.MethodPut:     true,
+		http.MethodPatch:   true,
+		http.MethodDelete:  true,
+		http.MethodOptions: true,
+	}
+)
+
 //New return api router
 func New(
 	repo *chain.Repository,
@@ -39,7 +64,14 @@ func New(
 	allowedOrigins string,
 	backtraceLimit uint32,
 	callGasLimit uint64,
+	maxBlockRange uint32,
+	graphQLCostLimit uint64,
+	abiRegistry *abis.Registry,
+	apiAuth *auth.Auth,
+	healthConfig node.HealthConfig,
+	nodeInfo node.Info,
 	pprofOn bool,
+	metricsOn bool,
 	skipLogs bool,
 	forkConfig workshare.ForkConfig,
 ) (http.HandlerFunc, func()) {
@@ -65,26 +97,42 @@ func New(
 			http.Redirect(w, req, "doc/swagger-ui/", http.StatusTemporaryRedirect)
 		})
 
-	accounts.New(repo, stater, callGasLimit, forkConfig).
+	accounts.New(repo, stater, callGasLimit, forkConfig, abiRegistry).
 		Mount(router, "/accounts")
 
 	if !skipLogs {
-		events.New(repo, logDB).
+		events.New(repo, logDB, stater, abiRegistry).
 			Mount(router, "/logs/event")
 		transfers.New(repo, logDB).
 			Mount(router, "/logs/transfer")
+		tokentransfers.New(repo, logDB).
+			Mount(router, "/logs/token-transfer")
 	}
-	blocks.New(repo).
+	blocks.New(repo, maxBlockRange).
 		Mount(router, "/blocks")
-	transactions.New(repo, txPool).
+	transactions.New(repo, stater, txPool, forkConfig, abiRegistry).
 		Mount(router, "/transactions")
-	debug.New(repo, stater, forkConfig).
+	pool.New(txPool).
+		Mount(router, "/txpool")
+	debug.New(repo, stater, callGasLimit, forkConfig).
 		Mount(router, "/debug")
-	node.New(nw).
+	nodeInfo.CallGasLimit = callGasLimit
+	nodeInfo.BacktraceLimit = backtraceLimit
+	nodeInfo.MaxBlockRange = maxBlockRange
+	node.New(nw, repo, healthConfig, nodeInfo).
 		Mount(router, "/node")
-	subs := subscriptions.New(repo, origins, backtraceLimit)
+	subs := subscriptions.New(repo, origins, backtraceLimit, txPool)
 	subs.Mount(router, "/subscriptions")
 
+	ethLogDB := logDB
+	if skipLogs {
+		ethLogDB = nil
+	}
+	ethAPI := eth.New(repo, stater, ethLogDB, callGasLimit, forkConfig, origins)
+	ethAPI.Mount(router, "/eth")
+	graphql.New(repo, stater, ethLogDB, graphQLCostLimit).
+		Mount(router, "/graphql")
+
 	if pprofOn {
 		router.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
 		router.HandleFunc("/debug/pprof/profile", pprof.Profile)
@@ -93,12 +141,50 @@ func New(
 		router.PathPrefix("/debug/pprof/").HandlerFunc(pprof.Index)
 	}
 
-	handler := handlers.CompressHandler(router)
+	if metricsOn {
+		router.Path("/metrics").Methods("GET").Handler(metric.Handler())
+	}
+
+	handler := handlers.CompressHandler(meterRoutes(router))
+	if apiAuth != nil {
+		handler = apiAuth.Handle(handler)
+	}
 	handler = handlers.CORS(
 		handlers.AllowedOrigins(origins),
-		handlers.AllowedHeaders([]string{"content-type", "x-genesis-id"}),
-		handlers.ExposedHeaders([]string{"x-genesis-id", "x-workshareest-ver"}),
+		handlers.AllowedHeaders([]string{"content-type", "x-genesis-id", "authorization", auth.APIKeyHeader}),
+		handlers.ExposedHeaders([]string{"x-genesis-id", "x-workshareest-ver", events.NextCursorHeader}),
 	)(handler)
 	return handler.ServeHTTP,
-		subs.Close // subscriptions handles hijacked conns, which need to be closed
+		func() {
+			// subscriptions and eth handle hijacked conns, which need to be closed
+			subs.Close()
+			ethAPI.Close()
+		}
+}
+
+// meterRoutes records request latency of each route, and counts websocket connections apart.
+func meterRoutes(router *mux.Router) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		route := "unmatched"
+		var match mux.RouteMatch
+		if router.Match(req, &match) && match.Route != nil {
+			if tpl, err := match.Route.GetPathTemplate(); err == nil {
+				route = tpl
+			}
+		}
+		if websocket.IsWebSocketUpgrade(req) {
+			// the conn is hijacked and lives as long as the subscription, which is not a request latency
+			metricWebsocketCount.With(route).Inc()
+			router.ServeHTTP(w, req)
+			return
+		}
+		method := req.Method
+		if !meteredMethods[method] {
+			method = "other"
+		}
+		defer func(start time.Time) {
+			metricRequestDuration.With(method, route).ObserveDuration(time.Since(start))
+		}(time.Now())
+		router.ServeHTTP(w, req)
+	})
 }
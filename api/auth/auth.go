@@ -0,0 +1,249 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+// Package auth implements API authentication by keys, with per-key rate limits and route allow-lists.
+//
+// Keys are loaded from a JSON file, which is reloaded on change:
+//
+//	{
+//	    "keys": [
+//	        {"name": "indexer", "key": "secret", "rate": 100, "burst": 200, "allow": ["/blocks", "/logs"]}
+//	    ],
+//	    "anonymous": {"rate": 5, "burst": 10, "allow": ["/doc", "/blocks", "/accounts"]}
+//	}
+//
+// A key is presented by either 'Authorization: Bearer <key>' or 'X-Api-Key: <key>' header.
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/miniBamboo/workshare/co"
+	"github.com/pkg/errors"
+)
+
+var log = log15.New("pkg", "auth")
+
+// interval to check changes of the key file.
+const reloadInterval = 5 * time.Second
+
+// APIKeyHeader is the header to present the API key.
+const APIKeyHeader = "x-api-key"
+
+// Policy restricts requests.
+type Policy struct {
+	// Rate is requests allowed per second. Zero means unlimited.
+	Rate float64 `json:"rate"`
+	// Burst is the max requests allowed at once.
+	Burst int `json:"burst"`
+	// Allow is the list of allowed route groups, which are path prefixes e.g. '/blocks'. Empty means all.
+	Allow []string `json:"allow"`
+}
+
+// KeyConfig is the config of an API key.
+type KeyConfig struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+	Policy
+}
+
+// Config is the content of the key file.
+type Config struct {
+	Keys []*KeyConfig `json:"keys"`
+	// Anonymous is the policy for requests without key. Nil means anonymous requests are rejected.
+	// All anonymous requests share the same rate limit.
+	Anonymous *Policy `json:"anonymous"`
+}
+
+type entry struct {
+	name   string
+	policy Policy
+	bucket *bucket // nil if unlimited
+}
+
+func newEntry(name string, policy Policy) *entry {
+	e := &entry{name: name, policy: policy}
+	if policy.Rate > 0 {
+		e.bucket = newBucket(policy.Rate, policy.Burst)
+	}
+	return e
+}
+
+func (e *entry) allowed(path string) bool {
+	if len(e.policy.Allow) == 0 {
+		return true
+	}
+	for _, prefix := range e.policy.Allow {
+		prefix = strings.TrimSuffix(prefix, "/")
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Auth authenticates API requests.
+type Auth struct {
+	path string
+
+	lock      sync.RWMutex
+	keys      map[[32]byte]*entry // keyed by hash of key, to avoid timing attack
+	anonymous *entry
+	modTime   time.Time
+
+	done chan struct{}
+	goes co.Goes
+}
+
+// New creates an Auth with keys loaded from the given file, and watches changes of the file.
+func New(path string) (*Auth, error) {
+	a := &Auth{
+		path: path,
+		done: make(chan struct{}),
+	}
+	if err := a.load(); err != nil {
+		return nil, err
+	}
+	a.goes.Go(a.watch)
+	return a, nil
+}
+
+// load loads the key file if it's changed.
+func (a *Auth) load() error {
+	info, err := os.Stat(a.path)
+	if err != nil {
+		return errors.WithMessage(err, "stat key file")
+	}
+	a.lock.RLock()
+	unchanged := info.ModTime().Equal(a.modTime)
+	a.lock.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(a.path)
+	if err != nil {
+		return errors.WithMessage(err, "read key file")
+	}
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return errors.WithMessage(err, "decode key file")
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	keys := make(map[[32]byte]*entry, len(config.Keys))
+	for i, k := range config.Keys {
+		if k.Key == "" {
+			return errors.Errorf("key file: keys[%d]: empty key", i)
+		}
+		hash := sha256.Sum256([]byte(k.Key))
+		if _, ok := keys[hash]; ok {
+			return errors.Errorf("key file: keys[%d]: duplicated key", i)
+		}
+		// keep the rate limit state if the policy is unchanged
+		if old, ok := a.keys[hash]; ok && samePolicy(&old.policy, &k.Policy) {
+			keys[hash] = &entry{k.Name, k.Policy, old.bucket}
+		} else {
+			keys[hash] = newEntry(k.Name, k.Policy)
+		}
+	}
+	a.keys = keys
+	switch {
+	case config.Anonymous == nil:
+		a.anonymous = nil
+	case a.anonymous != nil && samePolicy(&a.anonymous.policy, config.Anonymous):
+		a.anonymous = &entry{"", *config.Anonymous, a.anonymous.bucket}
+	default:
+		a.anonymous = newEntry("", *config.Anonymous)
+	}
+	a.modTime = info.ModTime()
+	return nil
+}
+
+func samePolicy(a, b *Policy) bool {
+	return a.Rate == b.Rate && a.Burst == b.Burst
+}
+
+func (a *Auth) watch() {
+	ticker := time.NewTicker(reloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.done:
+			return
+		case <-ticker.C:
+			if err := a.load(); err != nil {
+				log.Warn("failed to reload API keys, keep the old ones", "err", err)
+			}
+		}
+	}
+}
+
+// lookup returns the entry of the key. The anonymous entry is returned if key is empty.
+func (a *Auth) lookup(key string) *entry {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	if key == "" {
+		return a.anonymous
+	}
+	return a.keys[sha256.Sum256([]byte(key))]
+}
+
+func extractKey(req *http.Request) string {
+	if key := req.Header.Get(APIKeyHeader); key != "" {
+		return key
+	}
+	if v := req.Header.Get("Authorization"); len(v) > 7 && strings.EqualFold(v[:7], "bearer ") {
+		return strings.TrimSpace(v[7:])
+	}
+	return ""
+}
+
+// Handle wraps the handler to authenticate requests.
+func (a *Auth) Handle(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		// CORS preflight requests carry no credentials
+		if req.Method == http.MethodOptions {
+			h.ServeHTTP(w, req)
+			return
+		}
+		e := a.lookup(extractKey(req))
+		if e == nil {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "invalid or missing API key", http.StatusUnauthorized)
+			return
+		}
+		if !e.allowed(req.URL.Path) {
+			http.Error(w, "route not allowed", http.StatusForbidden)
+			return
+		}
+		if e.bucket != nil {
+			if ok, wait := e.bucket.take(time.Now()); !ok {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+				return
+			}
+		}
+		h.ServeHTTP(w, req)
+	})
+}
+
+// Close stops watching the key file.
+func (a *Auth) Close() {
+	close(a.done)
+	a.goes.Wait()
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package auth
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeKeyFile(t *testing.T, path, content string, modTime time.Time) {
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAuth(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "keys.json")
+
+	now := time.Now()
+	writeKeyFile(t, path, `{
+		"keys": [
+			{"name": "public", "key": "k1", "rate": 1, "burst": 2, "allow": ["/blocks", "/accounts/"]},
+			{"name": "admin", "key": "k2"}
+		]
+	}`, now)
+
+	a, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	h := a.Handle(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	do := func(path, header, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, do("/blocks/best", "", "").Code, "anonymous rejected")
+	assert.Equal(t, http.StatusUnauthorized, do("/blocks/best", "Authorization", "Bearer x").Code, "invalid key")
+	assert.Equal(t, http.StatusForbidden, do("/debug/tracers", "Authorization", "Bearer k1").Code)
+	assert.Equal(t, http.StatusForbidden, do("/blocksx", "Authorization", "Bearer k1").Code)
+	assert.Equal(t, http.StatusOK, do("/accounts/0x00", "X-Api-Key", "k1").Code)
+	assert.Equal(t, http.StatusOK, do("/blocks/best", "Authorization", "bearer k1").Code)
+
+	w := do("/blocks/best", "Authorization", "Bearer k1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code, "burst exhausted")
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, http.StatusOK, do("/debug/tracers", "Authorization", "Bearer k2").Code, "unlimited")
+	}
+
+	// reload
+	writeKeyFile(t, path, `{"anonymous": {"allow": ["/doc"]}}`, now.Add(time.Second))
+	assert.Nil(t, a.load())
+	assert.Equal(t, http.StatusUnauthorized, do("/blocks/best", "Authorization", "Bearer k1").Code, "key removed")
+	assert.Equal(t, http.StatusOK, do("/doc/swagger-ui/", "", "").Code)
+	assert.Equal(t, http.StatusForbidden, do("/blocks/best", "", "").Code)
+
+	// bad file keeps the old keys
+	writeKeyFile(t, path, `{"keys": [{"key": ""}]}`, now.Add(2*time.Second))
+	assert.NotNil(t, a.load())
+	assert.Equal(t, http.StatusOK, do("/doc/swagger-ui/", "", "").Code)
+}
+
+func TestBucket(t *testing.T) {
+	now := time.Now()
+	b := newBucket(2, 2)
+
+	ok, _ := b.take(now)
+	assert.True(t, ok)
+	ok, _ = b.take(now)
+	assert.True(t, ok)
+	ok, wait := b.take(now)
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, wait)
+
+	ok, _ = b.take(now.Add(500 * time.Millisecond))
+	assert.True(t, ok)
+	ok, _ = b.take(now.Add(time.Hour))
+	assert.True(t, ok)
+	ok, _ = b.take(now.Add(time.Hour))
+	assert.True(t, ok, "should be capped by burst")
+	ok, _ = b.take(now.Add(time.Hour))
+	assert.False(t, ok)
+}
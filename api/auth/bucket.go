@@ -0,0 +1,53 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// bucket is the token bucket rate limiter.
+type bucket struct {
+	rate  float64 // tokens filled per second
+	burst float64 // capacity of the bucket
+
+	lock   sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(rate float64, burst int) *bucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &bucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+	}
+}
+
+// take takes a token from the bucket. If no token available, it returns false
+// and the duration to wait for the next token.
+func (b *bucket) take(now time.Time) (bool, time.Duration) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !b.last.IsZero() && now.After(b.last) {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
@@ -6,24 +6,28 @@
 package blocks
 
 import (
+	"encoding/json"
 	"net/http"
 	"strconv"
 
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/gorilla/mux"
 	"github.com/miniBamboo/workshare/api/utils"
+	"github.com/miniBamboo/workshare/block"
 	"github.com/miniBamboo/workshare/chain"
 	"github.com/miniBamboo/workshare/workshare"
 	"github.com/pkg/errors"
 )
 
 type Blocks struct {
-	repo *chain.Repository
+	repo          *chain.Repository
+	maxBlockRange uint32
 }
 
-func New(repo *chain.Repository) *Blocks {
+func New(repo *chain.Repository, maxBlockRange uint32) *Blocks {
 	return &Blocks{
 		repo,
+		maxBlockRange,
 	}
 }
 
@@ -72,6 +76,103 @@ func (b *Blocks) handleGetBlock(w http.ResponseWriter, req *http.Request) error
 	})
 }
 
+// handleGetBlockRange streams expanded blocks of the range [from, to] on the best chain, one JSON object per line.
+// Blocks after the best block are omitted.
+func (b *Blocks) handleGetBlockRange(w http.ResponseWriter, req *http.Request) error {
+	from, err := parseBlockNumber(req.URL.Query().Get("from"))
+	if err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "from"))
+	}
+	to, err := parseBlockNumber(req.URL.Query().Get("to"))
+	if err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "to"))
+	}
+	if from > to {
+		return utils.BadRequest(errors.New("from: greater than to"))
+	}
+	if to-from >= b.maxBlockRange {
+		return utils.Forbidden(errors.New("range: exceeds limit"))
+	}
+
+	bestChain := b.repo.NewBestChain()
+	if best := block.Number(bestChain.HeadID()); best < to {
+		to = best
+	}
+
+	ctx := req.Context()
+	return streamBlocks(w, from, to, func(num uint32) (*JSONExpandedBlock, error) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+		blk, err := bestChain.GetBlock(num)
+		if err != nil {
+			return nil, err
+		}
+		receipts, err := b.repo.GetBlockReceipts(blk.Header().ID())
+		if err != nil {
+			return nil, err
+		}
+		summary := &chain.BlockSummary{Header: blk.Header(), Size: uint64(blk.Size())}
+		return &JSONExpandedBlock{
+			buildJSONBlockSummary(summary, true),
+			buildJSONEmbeddedTxs(blk.Transactions(), receipts),
+		}, nil
+	})
+}
+
+// streamError is the last line of a block stream which fails halfway.
+type streamError struct {
+	Error string `json:"error"`
+}
+
+// streamBlocks writes blocks in range [from, to] as NDJSON, one line per block.
+// The status can't be changed once the first block is written, so a later error ends the stream
+// with a line of streamError, to tell a failed stream from a complete one.
+func streamBlocks(w http.ResponseWriter, from, to uint32, load func(num uint32) (*JSONExpandedBlock, error)) error {
+	w.Header().Set("Content-Type", utils.NDJSONContentType)
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+	for num := from; num <= to; num++ {
+		blk, err := load(num)
+		if err != nil {
+			if num == from {
+				return err
+			}
+			return enc.Encode(&streamError{err.Error()})
+		}
+		if err := enc.Encode(blk); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		if num == to {
+			break
+		}
+	}
+	if from > to {
+		// no block
+		w.WriteHeader(http.StatusOK)
+	}
+	return nil
+}
+
+func parseBlockNumber(s string) (uint32, error) {
+	if s == "" {
+		return 0, errors.New("required")
+	}
+	n, err := strconv.ParseUint(s, 0, 0)
+	if err != nil {
+		return 0, err
+	}
+	if n > math.MaxUint32 {
+		return 0, errors.New("block number out of max uint32")
+	}
+	return uint32(n), nil
+}
+
 func (b *Blocks) parseRevision(revision string) (interface{}, error) {
 	if revision == "" || revision == "best" {
 		return nil, nil
@@ -119,6 +220,7 @@ func (b *Blocks) isTrunk(blkID workshare.Bytes32, blkNum uint32) (bool, error) {
 
 func (b *Blocks) Mount(root *mux.Router, pathPrefix string) {
 	sub := root.PathPrefix(pathPrefix).Subrouter()
+	sub.Path("/range").Methods("GET").HandlerFunc(utils.WrapHandlerFunc(b.handleGetBlockRange))
 	sub.Path("/{revision}").Methods("GET").HandlerFunc(utils.WrapHandlerFunc(b.handleGetBlock))
 
 }
@@ -6,16 +6,20 @@
 package blocks
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"math/big"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/gorilla/mux"
+	"github.com/miniBamboo/workshare/api/utils"
 	"github.com/miniBamboo/workshare/block"
 	"github.com/miniBamboo/workshare/chain"
 	"github.com/miniBamboo/workshare/genesis"
@@ -70,6 +74,84 @@ func TestBlock(t *testing.T) {
 	checkBlock(t, blk, rb)
 	assert.Equal(t, http.StatusOK, statusCode)
 
+	getBlockRange(t)
+}
+
+func getBlockRange(t *testing.T) {
+	_, statusCode := httpGet(t, ts.URL+"/blocks/range?from=1&to=0")
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+	_, statusCode = httpGet(t, ts.URL+"/blocks/range?from=0&to=10")
+	assert.Equal(t, http.StatusForbidden, statusCode, "exceeds limit")
+
+	// blocks after the best are omitted
+	res, statusCode := httpGet(t, ts.URL+"/blocks/range?from=0&to=5")
+	assert.Equal(t, http.StatusOK, statusCode)
+	dec := json.NewDecoder(bytes.NewReader(res))
+	var blocks []*JSONExpandedBlock
+	for dec.More() {
+		var b JSONExpandedBlock
+		if err := dec.Decode(&b); err != nil {
+			t.Fatal(err)
+		}
+		blocks = append(blocks, &b)
+	}
+	assert.Equal(t, 2, len(blocks))
+	assert.Equal(t, blk.Header().ID(), blocks[1].ID)
+	assert.Equal(t, 1, len(blocks[1].Transactions))
+	assert.Equal(t, blk.Transactions()[0].ID(), blocks[1].Transactions[0].ID)
+	assert.Equal(t, uint64(21000), blocks[1].Transactions[0].GasUsed)
+}
+
+func TestStreamBlocks(t *testing.T) {
+	load := func(failAt uint32) func(uint32) (*JSONExpandedBlock, error) {
+		return func(num uint32) (*JSONExpandedBlock, error) {
+			if num == failAt {
+				return nil, errors.New("boom")
+			}
+			return &JSONExpandedBlock{JSONBlockSummary: &JSONBlockSummary{Number: num}}, nil
+		}
+	}
+	lines := func(body string) []map[string]interface{} {
+		var lines []map[string]interface{}
+		dec := json.NewDecoder(strings.NewReader(body))
+		for dec.More() {
+			var line map[string]interface{}
+			if err := dec.Decode(&line); err != nil {
+				t.Fatal(err)
+			}
+			lines = append(lines, line)
+		}
+		return lines
+	}
+
+	// complete
+	w := httptest.NewRecorder()
+	assert.Nil(t, streamBlocks(w, 1, 3, load(100)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, utils.NDJSONContentType, w.Header().Get("Content-Type"))
+	if l := lines(w.Body.String()); assert.Len(t, l, 3) {
+		assert.Equal(t, float64(3), l[2]["number"])
+	}
+
+	// failed before any block written
+	w = httptest.NewRecorder()
+	assert.EqualError(t, streamBlocks(w, 1, 3, load(1)), "boom")
+	assert.Empty(t, w.Body.String())
+
+	// failed halfway
+	w = httptest.NewRecorder()
+	assert.Nil(t, streamBlocks(w, 1, 3, load(3)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	if l := lines(w.Body.String()); assert.Len(t, l, 3) {
+		assert.Equal(t, float64(2), l[1]["number"])
+		assert.Equal(t, map[string]interface{}{"error": "boom"}, l[2])
+	}
+
+	// empty
+	w = httptest.NewRecorder()
+	assert.Nil(t, streamBlocks(w, 2, 1, load(100)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Body.String())
 }
 
 func initBlockServer(t *testing.T) {
@@ -123,7 +205,7 @@ func initBlockServer(t *testing.T) {
 		t.Fatal(err)
 	}
 	router := mux.NewRouter()
-	New(repo).Mount(router, "/blocks")
+	New(repo, 10).Mount(router, "/blocks")
 	ts = httptest.NewServer(router)
 	blk = block
 }
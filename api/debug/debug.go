@@ -7,6 +7,9 @@ package debug
 
 import (
 	"context"
+	"encoding/json"
+	"math"
+	"math/big"
 	"net/http"
 	"strconv"
 	"strings"
@@ -24,23 +27,27 @@ import (
 	"github.com/miniBamboo/workshare/tracers"
 	"github.com/miniBamboo/workshare/tracers/logger"
 	"github.com/miniBamboo/workshare/trie"
+	"github.com/miniBamboo/workshare/tx"
 	"github.com/miniBamboo/workshare/vm"
 	"github.com/miniBamboo/workshare/workshare"
+	"github.com/miniBamboo/workshare/xenv"
 	"github.com/pkg/errors"
 )
 
 var devNetGenesisID = genesis.NewDevnet().ID()
 
 type Debug struct {
-	repo       *chain.Repository
-	stater     *state.Stater
-	forkConfig workshare.ForkConfig
+	repo         *chain.Repository
+	stater       *state.Stater
+	callGasLimit uint64
+	forkConfig   workshare.ForkConfig
 }
 
-func New(repo *chain.Repository, stater *state.Stater, forkConfig workshare.ForkConfig) *Debug {
+func New(repo *chain.Repository, stater *state.Stater, callGasLimit uint64, forkConfig workshare.ForkConfig) *Debug {
 	return &Debug{
 		repo,
 		stater,
+		callGasLimit,
 		forkConfig,
 	}
 }
@@ -113,6 +120,17 @@ func (d *Debug) traceTransaction(ctx context.Context, tracer tracers.Tracer, blo
 	return tracer.GetResult()
 }
 
+// newTracer creates the tracer of the given name. The struct logger is created if name is empty.
+func newTracer(name string, config json.RawMessage) (tracers.Tracer, error) {
+	if name == "" {
+		return logger.NewStructLogger(config)
+	}
+	if !strings.HasSuffix(name, "Tracer") {
+		name += "Tracer"
+	}
+	return tracers.New(name, nil, config)
+}
+
 func (d *Debug) handleTraceTransaction(w http.ResponseWriter, req *http.Request) error {
 	var opt *TracerOption
 	if err := utils.ParseJSON(req.Body, &opt); err != nil {
@@ -121,35 +139,214 @@ func (d *Debug) handleTraceTransaction(w http.ResponseWriter, req *http.Request)
 	if opt == nil {
 		return utils.BadRequest(errors.New("body: empty body"))
 	}
-	var tracer tracers.Tracer
-	if opt.Name == "" {
-		tr, err := logger.NewStructLogger(opt.Config)
+	tracer, err := newTracer(opt.Name, opt.Config)
+	if err != nil {
+		return err
+	}
+	blockID, txIndex, clauseIndex, err := d.parseTarget(opt.Target)
+	if err != nil {
+		return err
+	}
+	res, err := d.traceTransaction(req.Context(), tracer, blockID, txIndex, clauseIndex)
+	if err != nil {
+		return err
+	}
+	return utils.WriteJSON(w, res)
+}
+
+// traceBlock replays the block once, and traces every clause of every tx in order.
+func (d *Debug) traceBlock(ctx context.Context, name string, config json.RawMessage, blockID workshare.Bytes32) ([]*TxTraceResult, error) {
+	block, err := d.repo.GetBlock(blockID)
+	if err != nil {
+		if d.repo.IsNotFound(err) {
+			return nil, utils.Forbidden(errors.New("block not found"))
+		}
+		return nil, err
+	}
+	skipPoA := d.repo.GenesisBlock().Header().ID() == devNetGenesisID
+	rt, err := consensus.New(
+		d.repo,
+		d.stater,
+		d.forkConfig,
+	).NewRuntimeForReplay(block.Header(), skipPoA)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*TxTraceResult, 0, len(block.Transactions()))
+	for i, tx := range block.Transactions() {
+		txExec, err := rt.PrepareTransaction(tx)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		tracer = tr
-	} else {
-		name := opt.Name
-		if !strings.HasSuffix(name, "Tracer") {
-			name += "Tracer"
+		result := &TxTraceResult{
+			TxID:    tx.ID(),
+			TxIndex: uint64(i),
+			Clauses: make([]json.RawMessage, 0, len(tx.Clauses())),
+		}
+		for txExec.HasNextClause() {
+			// tracers are not reusable
+			tracer, err := newTracer(name, config)
+			if err != nil {
+				return nil, err
+			}
+			rt.SetVMConfig(vm.Config{Debug: true, Tracer: tracer})
+			if _, _, err := txExec.NextClause(); err != nil {
+				return nil, err
+			}
+			res, err := tracer.GetResult()
+			if err != nil {
+				return nil, err
+			}
+			result.Clauses = append(result.Clauses, res)
 		}
-		tr, err := tracers.New(name, nil, opt.Config)
+		rt.SetVMConfig(vm.Config{})
+		receipt, err := txExec.Finalize()
 		if err != nil {
-			return err
+			return nil, err
+		}
+		result.Reverted = receipt.Reverted
+		results = append(results, result)
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
 		}
-		tracer = tr
 	}
-	blockID, txIndex, clauseIndex, err := d.parseTarget(opt.Target)
+	return results, nil
+}
+
+func (d *Debug) handleTraceBlock(w http.ResponseWriter, req *http.Request) error {
+	var opt *TracerOption
+	if err := utils.ParseJSON(req.Body, &opt); err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "body"))
+	}
+	if opt == nil {
+		return utils.BadRequest(errors.New("body: empty body"))
+	}
+	blockID, err := d.parseBlockTarget(opt.Target)
 	if err != nil {
 		return err
 	}
-	res, err := d.traceTransaction(req.Context(), tracer, blockID, txIndex, clauseIndex)
+	// fail fast on bad tracer name or config
+	if _, err := newTracer(opt.Name, opt.Config); err != nil {
+		return err
+	}
+	res, err := d.traceBlock(req.Context(), opt.Name, opt.Config, blockID)
 	if err != nil {
 		return err
 	}
 	return utils.WriteJSON(w, res)
 }
 
+// traceCall executes the clause on top of the state of the given block, in the same way as contract calls of accounts API.
+func (d *Debug) traceCall(ctx context.Context, tracer tracers.Tracer, summary *chain.BlockSummary, txCtx *xenv.TransactionContext, gas uint64, clause *tx.Clause) (interface{}, error) {
+	rt := utils.NewRuntime(d.repo, d.stater, summary, d.forkConfig)
+	rt.SetVMConfig(vm.Config{Debug: true, Tracer: tracer})
+
+	exec, interrupt := rt.PrepareClause(clause, 0, gas, txCtx)
+	errCh := make(chan error, 1)
+	go func() {
+		_, _, err := exec()
+		errCh <- err
+	}()
+	select {
+	case <-ctx.Done():
+		interrupt()
+		return nil, ctx.Err()
+	case err := <-errCh:
+		if err != nil {
+			return nil, err
+		}
+	}
+	return tracer.GetResult()
+}
+
+func (d *Debug) handleTraceCall(w http.ResponseWriter, req *http.Request) error {
+	var opt *TraceCallOption
+	if err := utils.ParseJSON(req.Body, &opt); err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "body"))
+	}
+	if opt == nil {
+		return utils.BadRequest(errors.New("body: empty body"))
+	}
+	summary, err := utils.ParseRevision(d.repo, req.URL.Query().Get("revision"))
+	if err != nil {
+		return err
+	}
+	tracer, err := newTracer(opt.Name, opt.Config)
+	if err != nil {
+		return err
+	}
+	txCtx, gas, clause, err := d.handleTraceCallOption(opt)
+	if err != nil {
+		return err
+	}
+	res, err := d.traceCall(req.Context(), tracer, summary, txCtx, gas, clause)
+	if err != nil {
+		return err
+	}
+	return utils.WriteJSON(w, res)
+}
+
+func (d *Debug) handleTraceCallOption(opt *TraceCallOption) (txCtx *xenv.TransactionContext, gas uint64, clause *tx.Clause, err error) {
+	if opt.Gas > d.callGasLimit {
+		return nil, 0, nil, utils.Forbidden(errors.New("gas: exceeds limit"))
+	} else if opt.Gas == 0 {
+		gas = d.callGasLimit
+	} else {
+		gas = opt.Gas
+	}
+
+	txCtx = &xenv.TransactionContext{}
+
+	if opt.GasPrice == nil {
+		txCtx.GasPrice = new(big.Int)
+	} else {
+		txCtx.GasPrice = (*big.Int)(opt.GasPrice)
+	}
+	if opt.Caller != nil {
+		txCtx.Origin = *opt.Caller
+	}
+	if opt.GasPayer != nil {
+		txCtx.GasPayer = *opt.GasPayer
+	}
+	if opt.ProvedWork == nil {
+		txCtx.ProvedWork = new(big.Int)
+	} else {
+		txCtx.ProvedWork = (*big.Int)(opt.ProvedWork)
+	}
+	txCtx.Expiration = opt.Expiration
+
+	if len(opt.BlockRef) > 0 {
+		blockRef, err := hexutil.Decode(opt.BlockRef)
+		if err != nil {
+			return nil, 0, nil, utils.BadRequest(errors.WithMessage(err, "blockRef"))
+		}
+		if len(blockRef) != 8 {
+			return nil, 0, nil, utils.BadRequest(errors.New("blockRef: invalid length"))
+		}
+		copy(txCtx.BlockRef[:], blockRef)
+	}
+
+	var value *big.Int
+	if opt.Value == nil {
+		value = new(big.Int)
+	} else {
+		value = (*big.Int)(opt.Value)
+	}
+	var data []byte
+	if opt.Data != "" {
+		data, err = hexutil.Decode(opt.Data)
+		if err != nil {
+			return nil, 0, nil, utils.BadRequest(errors.WithMessage(err, "data"))
+		}
+	}
+	clause = tx.NewClause(opt.To).WithData(data).WithValue(value)
+	return
+}
+
 func (d *Debug) debugStorage(ctx context.Context, contractAddress workshare.Address, blockID workshare.Bytes32, txIndex uint64, clauseIndex uint64, keyStart []byte, maxResult int) (*StorageRangeResult, error) {
 	rt, _, err := d.handleTxEnv(ctx, blockID, txIndex, clauseIndex)
 	if err != nil {
@@ -247,10 +444,41 @@ func (d *Debug) parseTarget(target string) (blockID workshare.Bytes32, txIndex u
 	return
 }
 
+// parseBlockTarget parses the target of block tracing, which is block ID, number on the best chain, or "best".
+func (d *Debug) parseBlockTarget(target string) (workshare.Bytes32, error) {
+	if target == "best" {
+		return d.repo.BestBlockSummary().Header.ID(), nil
+	}
+	if len(target) == 64 || len(target) == 66 {
+		blockID, err := workshare.ParseBytes32(target)
+		if err != nil {
+			return workshare.Bytes32{}, utils.BadRequest(errors.WithMessage(err, "target"))
+		}
+		return blockID, nil
+	}
+	n, err := strconv.ParseUint(target, 0, 0)
+	if err != nil {
+		return workshare.Bytes32{}, utils.BadRequest(errors.WithMessage(err, "target"))
+	}
+	if n > math.MaxUint32 {
+		return workshare.Bytes32{}, utils.BadRequest(errors.WithMessage(errors.New("block number out of max uint32"), "target"))
+	}
+	blockID, err := d.repo.NewBestChain().GetBlockID(uint32(n))
+	if err != nil {
+		if d.repo.IsNotFound(err) {
+			return workshare.Bytes32{}, utils.Forbidden(errors.New("block not found"))
+		}
+		return workshare.Bytes32{}, err
+	}
+	return blockID, nil
+}
+
 func (d *Debug) Mount(root *mux.Router, pathPrefix string) {
 	sub := root.PathPrefix(pathPrefix).Subrouter()
 
 	sub.Path("/tracers").Methods(http.MethodPost).HandlerFunc(utils.WrapHandlerFunc(d.handleTraceTransaction))
+	sub.Path("/tracers/call").Methods(http.MethodPost).HandlerFunc(utils.WrapHandlerFunc(d.handleTraceCall))
+	sub.Path("/tracers/block").Methods(http.MethodPost).HandlerFunc(utils.WrapHandlerFunc(d.handleTraceBlock))
 	sub.Path("/storage-range").Methods(http.MethodPost).HandlerFunc(utils.WrapHandlerFunc(d.handleDebugStorage))
 
 }
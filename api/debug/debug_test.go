@@ -0,0 +1,299 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package debug
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gorilla/mux"
+	"github.com/miniBamboo/workshare/chain"
+	"github.com/miniBamboo/workshare/genesis"
+	"github.com/miniBamboo/workshare/muxdb"
+	"github.com/miniBamboo/workshare/packer"
+	"github.com/miniBamboo/workshare/state"
+	"github.com/miniBamboo/workshare/tx"
+	"github.com/miniBamboo/workshare/workshare"
+	"github.com/stretchr/testify/assert"
+
+	_ "github.com/miniBamboo/workshare/tracers/native"
+)
+
+// contract Test { uint8 value; function add(uint8 a,uint8 b) public pure returns(uint8); function set(uint8 v) public; }
+var (
+	testBytecode = common.Hex2Bytes("608060405234801561001057600080fd5b50610125806100206000396000f3006080604052600436106049576000357c0100000000000000000000000000000000000000000000000000000000900463ffffffff16806324b8ba5f14604e578063bb4e3f4d14607b575b600080fd5b348015605957600080fd5b506079600480360381019080803560ff16906020019092919050505060cf565b005b348015608657600080fd5b5060b3600480360381019080803560ff169060200190929190803560ff16906020019092919050505060ec565b604051808260ff1660ff16815260200191505060405180910390f35b806000806101000a81548160ff021916908360ff16021790555050565b60008183019050929150505600a165627a7a723058201584add23e31d36c569b468097fe01033525686b59bbb263fb3ab82e9553dae50029")
+	// set(7)
+	testSetInput = hexutil.MustDecode("0x24b8ba5f0000000000000000000000000000000000000000000000000000000000000007")
+)
+
+const testCallGasLimit = 10000000
+
+type testChain struct {
+	ts       *httptest.Server
+	repo     *chain.Repository
+	contract workshare.Address
+	// the tx of the best block, which calls set(7) and then transfers 1 wei to DevAccounts()[1]
+	tx *tx.Transaction
+}
+
+// newTestChain creates a chain of two blocks, the first deploying the test contract.
+func newTestChain(t *testing.T) *testChain {
+	db := muxdb.NewMem()
+	stater := state.NewStater(db)
+	b, _, _, err := genesis.NewDevnet().Build(stater)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := chain.NewRepository(db, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deploy := newTx(t, repo, 1, tx.NewClause(nil).WithData(testBytecode))
+	contract := workshare.CreateContractAddress(deploy.ID(), 0, 0)
+	packTx(t, repo, stater, deploy)
+
+	to := genesis.DevAccounts()[1].Address
+	trx := newTx(t, repo, 2,
+		tx.NewClause(&contract).WithData(testSetInput),
+		tx.NewClause(&to).WithValue(big.NewInt(1)))
+	packTx(t, repo, stater, trx)
+
+	router := mux.NewRouter()
+	New(repo, stater, testCallGasLimit, workshare.NoFork).Mount(router, "/debug")
+	ts := httptest.NewServer(router)
+	t.Cleanup(ts.Close)
+
+	return &testChain{ts, repo, contract, trx}
+}
+
+func newTx(t *testing.T, repo *chain.Repository, nonce uint64, clauses ...*tx.Clause) *tx.Transaction {
+	builder := new(tx.Builder).
+		ChainTag(repo.ChainTag()).
+		Expiration(10).
+		Gas(1000000).
+		Nonce(nonce)
+	for _, c := range clauses {
+		builder.Clause(c)
+	}
+	trx := builder.Build()
+	sig, err := crypto.Sign(trx.SigningHash().Bytes(), genesis.DevAccounts()[0].PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return trx.WithSignature(sig)
+}
+
+func packTx(t *testing.T, repo *chain.Repository, stater *state.Stater, trx *tx.Transaction) {
+	flow, err := packer.New(repo, stater, genesis.DevAccounts()[0].Address, &genesis.DevAccounts()[0].Address, workshare.NoFork).
+		Schedule(repo.BestBlockSummary(), uint64(time.Now().Unix()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := flow.Adopt(trx); err != nil {
+		t.Fatal(err)
+	}
+	blk, stage, receipts, err := flow.Pack(genesis.DevAccounts()[0].PrivateKey, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stage.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.AddBlock(blk, receipts, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.SetBestBlockID(blk.Header().ID()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func httpPost(t *testing.T, url string, body interface{}) ([]byte, int) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r, res.StatusCode
+}
+
+// callFrame is the result of callTracer, with fields checked by tests.
+type callFrame struct {
+	Type  string             `json:"type"`
+	To    *workshare.Address `json:"to"`
+	Value string             `json:"value"`
+	Input string             `json:"input"`
+	Calls []json.RawMessage  `json:"calls"`
+}
+
+func TestTraceBlock(t *testing.T) {
+	c := newTestChain(t)
+	best := c.repo.BestBlockSummary().Header
+
+	for _, target := range []string{"2", "0x2", best.ID().String(), "best"} {
+		t.Run(target, func(t *testing.T) {
+			res, code := httpPost(t, c.ts.URL+"/debug/tracers/block", &TracerOption{Name: "call", Target: target})
+			assert.Equal(t, http.StatusOK, code, string(res))
+
+			var results []*TxTraceResult
+			if err := json.Unmarshal(res, &results); err != nil {
+				t.Fatal(err)
+			}
+			if assert.Len(t, results, 1) {
+				assert.Equal(t, c.tx.ID(), results[0].TxID)
+				assert.Equal(t, uint64(0), results[0].TxIndex)
+				assert.False(t, results[0].Reverted)
+			}
+
+			var frames []*callFrame
+			for _, raw := range results[0].Clauses {
+				var frame callFrame
+				if err := json.Unmarshal(raw, &frame); err != nil {
+					t.Fatal(err)
+				}
+				frames = append(frames, &frame)
+			}
+			if assert.Len(t, frames, 2) {
+				assert.Equal(t, "CALL", frames[0].Type)
+				assert.Equal(t, c.contract, *frames[0].To)
+				assert.Equal(t, hexutil.Encode(testSetInput), frames[0].Input)
+				assert.Empty(t, frames[0].Calls)
+
+				assert.Equal(t, "CALL", frames[1].Type)
+				assert.Equal(t, genesis.DevAccounts()[1].Address, *frames[1].To)
+				assert.Equal(t, "0x1", frames[1].Value)
+				assert.Equal(t, "0x", frames[1].Input)
+			}
+		})
+	}
+
+	// each clause has its own tracer, so the transfer has no opcode logged after the contract call
+	res, code := httpPost(t, c.ts.URL+"/debug/tracers/block", &TracerOption{Target: "best"})
+	assert.Equal(t, http.StatusOK, code, string(res))
+	var logs []*struct {
+		Clauses []*struct {
+			StructLogs []json.RawMessage `json:"structLogs"`
+		} `json:"clauses"`
+	}
+	if err := json.Unmarshal(res, &logs); err != nil {
+		t.Fatal(err)
+	}
+	if assert.Len(t, logs, 1) && assert.Len(t, logs[0].Clauses, 2) {
+		assert.NotEmpty(t, logs[0].Clauses[0].StructLogs)
+		assert.Empty(t, logs[0].Clauses[1].StructLogs)
+	}
+
+	// block 1 deploys the contract
+	res, code = httpPost(t, c.ts.URL+"/debug/tracers/block", &TracerOption{Name: "callTracer", Target: "1"})
+	assert.Equal(t, http.StatusOK, code, string(res))
+	var results []*TxTraceResult
+	if err := json.Unmarshal(res, &results); err != nil {
+		t.Fatal(err)
+	}
+	if assert.Len(t, results, 1) && assert.Len(t, results[0].Clauses, 1) {
+		var frame callFrame
+		if err := json.Unmarshal(results[0].Clauses[0], &frame); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "CREATE", frame.Type)
+		assert.Equal(t, c.contract, *frame.To)
+	}
+
+	tests := []struct {
+		name string
+		opt  interface{}
+		code int
+	}{
+		{"empty body", nil, http.StatusBadRequest},
+		{"malformed target", &TracerOption{Target: "abc"}, http.StatusBadRequest},
+		{"malformed ID", &TracerOption{Target: "0x" + string(bytes.Repeat([]byte("z"), 64))}, http.StatusBadRequest},
+		{"number out of range", &TracerOption{Target: "4294967296"}, http.StatusBadRequest},
+		{"unknown number", &TracerOption{Target: "3"}, http.StatusForbidden},
+		{"unknown ID", &TracerOption{Target: workshare.Bytes32{1}.String()}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, code := httpPost(t, c.ts.URL+"/debug/tracers/block", tt.opt)
+			assert.Equal(t, tt.code, code, string(res))
+		})
+	}
+}
+
+func TestTraceCall(t *testing.T) {
+	c := newTestChain(t)
+
+	res, code := httpPost(t, c.ts.URL+"/debug/tracers/call", &TraceCallOption{
+		Name: "callTracer",
+		To:   &c.contract,
+		Data: hexutil.Encode(testSetInput),
+	})
+	assert.Equal(t, http.StatusOK, code, string(res))
+	var frame callFrame
+	if err := json.Unmarshal(res, &frame); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "CALL", frame.Type)
+	assert.Equal(t, c.contract, *frame.To)
+	assert.Equal(t, hexutil.Encode(testSetInput), frame.Input)
+
+	// the contract is not deployed at block 0, so no opcode is executed there
+	for revision, executed := range map[string]bool{"0": false, "best": true} {
+		res, code = httpPost(t, c.ts.URL+"/debug/tracers/call?revision="+revision, &TraceCallOption{
+			To:   &c.contract,
+			Data: hexutil.Encode(testSetInput),
+		})
+		assert.Equal(t, http.StatusOK, code, string(res))
+		var result struct {
+			StructLogs []json.RawMessage `json:"structLogs"`
+		}
+		if err := json.Unmarshal(res, &result); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, executed, len(result.StructLogs) > 0, revision)
+	}
+
+	tests := []struct {
+		name     string
+		revision string
+		opt      interface{}
+		code     int
+	}{
+		{"empty body", "", nil, http.StatusBadRequest},
+		{"malformed revision", "abc", &TraceCallOption{To: &c.contract}, http.StatusBadRequest},
+		{"unknown revision", "3", &TraceCallOption{To: &c.contract}, http.StatusBadRequest},
+		{"gas exceeds limit", "", &TraceCallOption{To: &c.contract, Gas: testCallGasLimit + 1}, http.StatusForbidden},
+		{"malformed blockRef", "", &TraceCallOption{To: &c.contract, BlockRef: "0xzz"}, http.StatusBadRequest},
+		{"short blockRef", "", &TraceCallOption{To: &c.contract, BlockRef: "0x01"}, http.StatusBadRequest},
+		{"malformed data", "", &TraceCallOption{To: &c.contract, Data: "0xzz"}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url := c.ts.URL + "/debug/tracers/call"
+			if tt.revision != "" {
+				url += "?revision=" + tt.revision
+			}
+			res, code := httpPost(t, url, tt.opt)
+			assert.Equal(t, tt.code, code, string(res))
+		})
+	}
+}
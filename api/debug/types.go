@@ -3,6 +3,7 @@ package debug
 import (
 	"encoding/json"
 
+	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/miniBamboo/workshare/workshare"
 )
 
@@ -13,6 +14,32 @@ type TracerOption struct {
 	Config json.RawMessage `json:"config"`
 }
 
+// TraceCallOption describes an ad-hoc call to be traced, in the manner of accounts.BatchCallData.
+type TraceCallOption struct {
+	Name string `json:"name"`
+	// Config specific to given tracer.
+	Config     json.RawMessage       `json:"config"`
+	To         *workshare.Address    `json:"to"`
+	Value      *math.HexOrDecimal256 `json:"value"`
+	Data       string                `json:"data"`
+	Gas        uint64                `json:"gas"`
+	GasPrice   *math.HexOrDecimal256 `json:"gasPrice"`
+	ProvedWork *math.HexOrDecimal256 `json:"provedWork"`
+	Caller     *workshare.Address    `json:"caller"`
+	GasPayer   *workshare.Address    `json:"gasPayer"`
+	Expiration uint32                `json:"expiration"`
+	BlockRef   string                `json:"blockRef"`
+}
+
+// TxTraceResult contains trace results of clauses of a tx in a block.
+type TxTraceResult struct {
+	TxID     workshare.Bytes32 `json:"txID"`
+	TxIndex  uint64            `json:"txIndex"`
+	Reverted bool              `json:"reverted"`
+	// Clauses are results of executed clauses in order. Clauses after the reverted one are not executed.
+	Clauses []json.RawMessage `json:"clauses"`
+}
+
 type StorageRangeOption struct {
 	Address   workshare.Address
 	KeyStart  string
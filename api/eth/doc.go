@@ -0,0 +1,27 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+// Package eth serves a subset of the Ethereum JSON-RPC 2.0 API (eth_*, net_* and web3_*),
+// so that tools built for Ethereum can read chain data and call contracts.
+//
+// Requests are accepted as HTTP POST (single or batch), and over websocket, which also
+// supports eth_subscribe for 'newHeads' and 'logs'.
+//
+// Chain objects are mapped as below:
+//
+//	block hash           -> block ID
+//	transaction hash     -> transaction ID
+//	eth_chainId          -> chain tag (the last byte of the genesis ID)
+//	net_version          -> chain tag in decimal
+//	eth_getBalance       -> VET balance (energy is not exposed)
+//	block tag 'pending'  -> best block
+//	block tag 'safe' and 'finalized' -> best block
+//
+// Multi-clause transactions are exposed clause by clause. The standard 'to', 'value' and 'input'
+// fields of a transaction describe its first clause, and the non-standard 'clauses' field lists
+// all clauses in order. The logs of a receipt are the events of all clauses flattened in order,
+// each tagged with the non-standard 'clauseIndex' field. The 'contractAddress' of a receipt is set
+// only if the first clause creates a contract. Native VET transfers are not reported as logs.
+package eth
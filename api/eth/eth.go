@@ -0,0 +1,753 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package eth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/inconshreveable/log15"
+	"github.com/miniBamboo/workshare/abi"
+	"github.com/miniBamboo/workshare/api/utils"
+	"github.com/miniBamboo/workshare/chain"
+	"github.com/miniBamboo/workshare/logdb"
+	"github.com/miniBamboo/workshare/runtime"
+	"github.com/miniBamboo/workshare/state"
+	"github.com/miniBamboo/workshare/tx"
+	"github.com/miniBamboo/workshare/vm"
+	"github.com/miniBamboo/workshare/workshare"
+	"github.com/miniBamboo/workshare/xenv"
+	"github.com/pkg/errors"
+)
+
+var log = log15.New("pkg", "eth")
+
+const (
+	maxBatchSize    = 100
+	maxLogs         = 10000
+	maxFilterValues = 1000
+)
+
+type methodFunc func(ctx context.Context, params []json.RawMessage) (interface{}, error)
+
+type ETH struct {
+	repo         *chain.Repository
+	stater       *state.Stater
+	logDB        *logdb.LogDB
+	callGasLimit uint64
+	forkConfig   workshare.ForkConfig
+	methods      map[string]methodFunc
+	upgrader     *websocket.Upgrader
+	done         chan struct{}
+	wg           sync.WaitGroup
+}
+
+// New creates the JSON-RPC service. logDB can be nil if logs are not recorded.
+func New(
+	repo *chain.Repository,
+	stater *state.Stater,
+	logDB *logdb.LogDB,
+	callGasLimit uint64,
+	forkConfig workshare.ForkConfig,
+	allowedOrigins []string,
+) *ETH {
+	e := &ETH{
+		repo:         repo,
+		stater:       stater,
+		logDB:        logDB,
+		callGasLimit: callGasLimit,
+		forkConfig:   forkConfig,
+		upgrader: &websocket.Upgrader{
+			EnableCompression: true,
+			CheckOrigin: func(r *http.Request) bool {
+				origin := r.Header.Get("Origin")
+				if origin == "" {
+					return true
+				}
+				for _, allowedOrigin := range allowedOrigins {
+					if allowedOrigin == origin || allowedOrigin == "*" {
+						return true
+					}
+				}
+				return false
+			},
+		},
+		done: make(chan struct{}),
+	}
+	e.methods = map[string]methodFunc{
+		"web3_sha3":                 e.sha3,
+		"net_version":               e.netVersion,
+		"eth_chainId":               e.chainID,
+		"eth_blockNumber":           e.blockNumber,
+		"eth_getBlockByNumber":      e.getBlockByNumber,
+		"eth_getBlockByHash":        e.getBlockByHash,
+		"eth_getTransactionByHash":  e.getTransactionByHash,
+		"eth_getTransactionReceipt": e.getTransactionReceipt,
+		"eth_getBalance":            e.getBalance,
+		"eth_getCode":               e.getCode,
+		"eth_getStorageAt":          e.getStorageAt,
+		"eth_call":                  e.call,
+		"eth_estimateGas":           e.estimateGas,
+		"eth_getLogs":               e.getLogs,
+	}
+	return e
+}
+
+// parseParams decodes positional params into args. Params beyond the given ones are optional.
+func parseParams(params []json.RawMessage, required int, args ...interface{}) error {
+	if len(params) < required {
+		return invalidParams(fmt.Errorf("missing value for required argument %d", len(params)))
+	}
+	if len(params) > len(args) {
+		return invalidParams(fmt.Errorf("too many arguments, want at most %d", len(args)))
+	}
+	for i, param := range params {
+		if err := json.Unmarshal(param, args[i]); err != nil {
+			return invalidParams(errors.WithMessage(err, fmt.Sprintf("invalid argument %d", i)))
+		}
+	}
+	return nil
+}
+
+// resolveSummary returns the block summary specified by b. A nil b means the best block.
+func (e *ETH) resolveSummary(b *BlockNumberOrHash) (*chain.BlockSummary, error) {
+	if b == nil {
+		return e.repo.BestBlockSummary(), nil
+	}
+	if b.Hash != nil {
+		return e.repo.GetBlockSummary(*b.Hash)
+	}
+	if b.Number != nil {
+		return e.repo.NewBestChain().GetBlockSummary(*b.Number)
+	}
+	if b.Tag == "earliest" {
+		return e.repo.NewBestChain().GetBlockSummary(0)
+	}
+	return e.repo.BestBlockSummary(), nil
+}
+
+// resolveState is like resolveSummary, but responds 'header not found' if the block is unknown.
+func (e *ETH) resolveState(b *BlockNumberOrHash) (*chain.BlockSummary, *state.State, error) {
+	summary, err := e.resolveSummary(b)
+	if err != nil {
+		if e.repo.IsNotFound(err) {
+			return nil, nil, serverError(errors.New("header not found"))
+		}
+		return nil, nil, err
+	}
+	header := summary.Header
+	return summary, e.stater.NewState(header.StateRoot(), header.Number(), summary.Conflicts, summary.SteadyNum), nil
+}
+
+func (e *ETH) sha3(ctx context.Context, params []json.RawMessage) (interface{}, error) {
+	var data hexutil.Bytes
+	if err := parseParams(params, 1, &data); err != nil {
+		return nil, err
+	}
+	return hexutil.Bytes(crypto.Keccak256(data)), nil
+}
+
+func (e *ETH) netVersion(ctx context.Context, params []json.RawMessage) (interface{}, error) {
+	return strconv.Itoa(int(e.repo.ChainTag())), nil
+}
+
+func (e *ETH) chainID(ctx context.Context, params []json.RawMessage) (interface{}, error) {
+	return hexutil.Uint64(e.repo.ChainTag()), nil
+}
+
+func (e *ETH) blockNumber(ctx context.Context, params []json.RawMessage) (interface{}, error) {
+	return hexutil.Uint64(e.repo.BestBlockSummary().Header.Number()), nil
+}
+
+func (e *ETH) getBlock(summary *chain.BlockSummary, fullTx bool) (interface{}, error) {
+	b, err := e.repo.GetBlock(summary.Header.ID())
+	if err != nil {
+		return nil, err
+	}
+	var receipts tx.Receipts
+	if fullTx {
+		if receipts, err = e.repo.GetBlockReceipts(summary.Header.ID()); err != nil {
+			return nil, err
+		}
+	}
+	return convertBlock(b, receipts, e.repo.ChainTag(), fullTx), nil
+}
+
+func (e *ETH) getBlockByNumber(ctx context.Context, params []json.RawMessage) (interface{}, error) {
+	var (
+		number BlockNumberOrHash
+		fullTx bool
+	)
+	if err := parseParams(params, 1, &number, &fullTx); err != nil {
+		return nil, err
+	}
+	summary, err := e.resolveSummary(&number)
+	if err != nil {
+		if e.repo.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return e.getBlock(summary, fullTx)
+}
+
+func (e *ETH) getBlockByHash(ctx context.Context, params []json.RawMessage) (interface{}, error) {
+	var (
+		hash   workshare.Bytes32
+		fullTx bool
+	)
+	if err := parseParams(params, 1, &hash, &fullTx); err != nil {
+		return nil, err
+	}
+	summary, err := e.repo.GetBlockSummary(hash)
+	if err != nil {
+		if e.repo.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return e.getBlock(summary, fullTx)
+}
+
+func (e *ETH) getTransactionByHash(ctx context.Context, params []json.RawMessage) (interface{}, error) {
+	var hash workshare.Bytes32
+	if err := parseParams(params, 1, &hash); err != nil {
+		return nil, err
+	}
+	chain := e.repo.NewBestChain()
+	t, meta, err := chain.GetTransaction(hash)
+	if err != nil {
+		if e.repo.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	summary, err := e.repo.GetBlockSummary(meta.BlockID)
+	if err != nil {
+		return nil, err
+	}
+	receipt, err := chain.GetTransactionReceipt(hash)
+	if err != nil {
+		return nil, err
+	}
+	return convertTransaction(t, summary.Header, meta.Index, receipt, e.repo.ChainTag()), nil
+}
+
+func (e *ETH) getTransactionReceipt(ctx context.Context, params []json.RawMessage) (interface{}, error) {
+	var hash workshare.Bytes32
+	if err := parseParams(params, 1, &hash); err != nil {
+		return nil, err
+	}
+	meta, err := e.repo.NewBestChain().GetTransactionMeta(hash)
+	if err != nil {
+		if e.repo.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	b, err := e.repo.GetBlock(meta.BlockID)
+	if err != nil {
+		return nil, err
+	}
+	receipts, err := e.repo.GetBlockReceipts(meta.BlockID)
+	if err != nil {
+		return nil, err
+	}
+	return convertReceipt(b, receipts, meta.Index), nil
+}
+
+func (e *ETH) getBalance(ctx context.Context, params []json.RawMessage) (interface{}, error) {
+	var (
+		addr     workshare.Address
+		revision *BlockNumberOrHash
+	)
+	if err := parseParams(params, 1, &addr, &revision); err != nil {
+		return nil, err
+	}
+	_, st, err := e.resolveState(revision)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := st.GetBalance(addr)
+	if err != nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(balance), nil
+}
+
+func (e *ETH) getCode(ctx context.Context, params []json.RawMessage) (interface{}, error) {
+	var (
+		addr     workshare.Address
+		revision *BlockNumberOrHash
+	)
+	if err := parseParams(params, 1, &addr, &revision); err != nil {
+		return nil, err
+	}
+	_, st, err := e.resolveState(revision)
+	if err != nil {
+		return nil, err
+	}
+	code, err := st.GetCode(addr)
+	if err != nil {
+		return nil, err
+	}
+	return hexutil.Bytes(code), nil
+}
+
+func (e *ETH) getStorageAt(ctx context.Context, params []json.RawMessage) (interface{}, error) {
+	var (
+		addr     workshare.Address
+		slot     string
+		revision *BlockNumberOrHash
+	)
+	if err := parseParams(params, 2, &addr, &slot, &revision); err != nil {
+		return nil, err
+	}
+	// the slot can be a full 32 bytes key, or a quantity
+	var key workshare.Bytes32
+	if len(slot) == 66 {
+		k, err := workshare.ParseBytes32(slot)
+		if err != nil {
+			return nil, invalidParams(errors.WithMessage(err, "slot"))
+		}
+		key = k
+	} else {
+		n, err := hexutil.DecodeBig(slot)
+		if err != nil {
+			return nil, invalidParams(errors.WithMessage(err, "slot"))
+		}
+		if n.BitLen() > 256 {
+			return nil, invalidParams(errors.New("slot: too large"))
+		}
+		key = workshare.BytesToBytes32(n.Bytes())
+	}
+	_, st, err := e.resolveState(revision)
+	if err != nil {
+		return nil, err
+	}
+	value, err := st.GetStorage(addr, key)
+	if err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
+// execute runs the clause on a throwaway state with the given execution gas.
+func (e *ETH) execute(ctx context.Context, summary *chain.BlockSummary, clause *tx.Clause, args *CallArgs, gas uint64) (*runtime.Output, error) {
+	txCtx := &xenv.TransactionContext{
+		GasPrice:   new(big.Int),
+		ProvedWork: new(big.Int),
+	}
+	if args.GasPrice != nil {
+		txCtx.GasPrice = (*big.Int)(args.GasPrice)
+	}
+	if args.From != nil {
+		txCtx.Origin = *args.From
+	}
+
+	exec, interrupt := utils.NewRuntime(e.repo, e.stater, summary, e.forkConfig).PrepareClause(clause, 0, gas, txCtx)
+
+	type result struct {
+		output *runtime.Output
+		err    error
+	}
+	resultCh := make(chan result, 1)
+	go func() {
+		output, _, err := exec()
+		resultCh <- result{output, err}
+	}()
+	select {
+	case <-ctx.Done():
+		interrupt()
+		return nil, ctx.Err()
+	case r := <-resultCh:
+		return r.output, r.err
+	}
+}
+
+// executionGas returns the gas available for execution, i.e. the requested gas minus the intrinsic gas.
+func (e *ETH) executionGas(clause *tx.Clause, args *CallArgs) (intrinsicGas, gas uint64, err error) {
+	if intrinsicGas, err = tx.IntrinsicGas(clause); err != nil {
+		return 0, 0, err
+	}
+	if args.Gas == nil {
+		return intrinsicGas, e.callGasLimit, nil
+	}
+	if uint64(*args.Gas) > e.callGasLimit+intrinsicGas {
+		return 0, 0, invalidParams(errors.New("gas: exceeds limit"))
+	}
+	if uint64(*args.Gas) < intrinsicGas {
+		return 0, 0, invalidParams(errors.New("gas: intrinsic gas too low"))
+	}
+	return intrinsicGas, uint64(*args.Gas) - intrinsicGas, nil
+}
+
+// vmError converts the VM error of the output into the JSON-RPC error.
+func vmError(output *runtime.Output) error {
+	if output.VMErr == nil {
+		return nil
+	}
+	if output.VMErr == vm.ErrExecutionReverted {
+		msg := output.VMErr.Error()
+		if reason, err := abi.UnpackRevert(output.Data); err == nil {
+			msg += ": " + reason
+		}
+		return &rpcError{Code: codeReverted, Message: msg, Data: hexutil.Encode(output.Data)}
+	}
+	return serverError(output.VMErr)
+}
+
+func (e *ETH) call(ctx context.Context, params []json.RawMessage) (interface{}, error) {
+	var (
+		args     CallArgs
+		revision *BlockNumberOrHash
+	)
+	if err := parseParams(params, 1, &args, &revision); err != nil {
+		return nil, err
+	}
+	summary, _, err := e.resolveState(revision)
+	if err != nil {
+		return nil, err
+	}
+	clause := args.clause()
+	_, gas, err := e.executionGas(clause, &args)
+	if err != nil {
+		return nil, err
+	}
+	output, err := e.execute(ctx, summary, clause, &args, gas)
+	if err != nil {
+		return nil, err
+	}
+	if err := vmError(output); err != nil {
+		return nil, err
+	}
+	return hexutil.Bytes(output.Data), nil
+}
+
+// estimateGas implements eth_estimateGas, which responds an error if the call never succeeds.
+func (e *ETH) estimateGas(ctx context.Context, params []json.RawMessage) (interface{}, error) {
+	var (
+		args     CallArgs
+		revision *BlockNumberOrHash
+	)
+	if err := parseParams(params, 1, &args, &revision); err != nil {
+		return nil, err
+	}
+	summary, _, err := e.resolveState(revision)
+	if err != nil {
+		return nil, err
+	}
+	clause := args.clause()
+	intrinsicGas, hi, err := e.executionGas(clause, &args)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := e.execute(ctx, summary, clause, &args, hi)
+	if err != nil {
+		return nil, err
+	}
+	if err := vmError(output); err != nil {
+		return nil, err
+	}
+
+	gas, err := utils.SearchGas(hi, hi-output.LeftOverGas, func(gas uint64) (bool, error) {
+		output, err := e.execute(ctx, summary, clause, &args, gas)
+		if err != nil {
+			return false, err
+		}
+		return output.VMErr == nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hexutil.Uint64(intrinsicGas + gas), nil
+}
+
+// buildCriteria converts the query into the criteria set of logdb.
+func buildCriteria(q *FilterQuery) ([]*logdb.EventCriteria, error) {
+	if len(q.Topics) > 5 {
+		return nil, invalidParams(errors.New("topics: too many"))
+	}
+	n := len(q.Address)
+	criteria := &logdb.EventCriteria{Address: q.Address}
+	for i, alternatives := range q.Topics {
+		n += len(alternatives)
+		criteria.Topics[i] = alternatives
+	}
+	if n > maxFilterValues {
+		return nil, invalidParams(errors.New("too many addresses and topics"))
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	return []*logdb.EventCriteria{criteria}, nil
+}
+
+func (e *ETH) getLogs(ctx context.Context, params []json.RawMessage) (interface{}, error) {
+	var query FilterQuery
+	if err := parseParams(params, 1, &query); err != nil {
+		return nil, err
+	}
+	if e.logDB == nil {
+		return nil, serverError(errors.New("logs disabled"))
+	}
+
+	var rng logdb.Range
+	if query.BlockHash != nil {
+		if query.FromBlock != nil || query.ToBlock != nil {
+			return nil, invalidParams(errors.New("cannot specify both blockHash and fromBlock/toBlock"))
+		}
+		summary, err := e.repo.GetBlockSummary(*query.BlockHash)
+		if err != nil {
+			if e.repo.IsNotFound(err) {
+				return nil, serverError(errors.New("unknown block"))
+			}
+			return nil, err
+		}
+		rng.From = summary.Header.Number()
+		rng.To = rng.From
+	} else {
+		from, err := e.resolveSummary(query.FromBlock)
+		if err != nil {
+			if e.repo.IsNotFound(err) {
+				return []*Log{}, nil
+			}
+			return nil, err
+		}
+		to, err := e.resolveSummary(query.ToBlock)
+		if err != nil {
+			if !e.repo.IsNotFound(err) {
+				return nil, err
+			}
+			to = e.repo.BestBlockSummary()
+		}
+		rng.From = from.Header.Number()
+		rng.To = to.Header.Number()
+		if rng.From > rng.To {
+			return []*Log{}, nil
+		}
+	}
+
+	criteriaSet, err := buildCriteria(&query)
+	if err != nil {
+		return nil, err
+	}
+	events, err := e.logDB.FilterEvents(ctx, &logdb.EventFilter{
+		CriteriaSet: criteriaSet,
+		Range:       &rng,
+		Options:     &logdb.Options{Limit: maxLogs + 1},
+		Order:       logdb.ASC,
+	})
+	if err != nil {
+		if _, ok := err.(*logdb.PrunedError); ok {
+			return nil, invalidParams(err)
+		}
+		return nil, err
+	}
+	if len(events) > maxLogs {
+		return nil, serverError(fmt.Errorf("query returned more than %d results", maxLogs))
+	}
+
+	logs := make([]*Log, 0, len(events))
+	txIndices := make(map[workshare.Bytes32]uint64)
+	for _, ev := range events {
+		// the log db keeps logs of the trunk only
+		if query.BlockHash != nil && ev.BlockID != *query.BlockHash {
+			continue
+		}
+		txIndex, ok := txIndices[ev.TxID]
+		if !ok {
+			meta, err := e.repo.NewChain(ev.BlockID).GetTransactionMeta(ev.TxID)
+			if err != nil {
+				// logs of genesis block have no tx
+				if !e.repo.IsNotFound(err) {
+					return nil, err
+				}
+			} else {
+				txIndex = meta.Index
+			}
+			txIndices[ev.TxID] = txIndex
+		}
+		var topics []workshare.Bytes32
+		for _, topic := range ev.Topics {
+			if topic != nil {
+				topics = append(topics, *topic)
+			}
+		}
+		data := ev.Data
+		if data == nil {
+			data = []byte{}
+		}
+		if topics == nil {
+			topics = []workshare.Bytes32{}
+		}
+		logs = append(logs, &Log{
+			Address:          ev.Address,
+			Topics:           topics,
+			Data:             data,
+			BlockNumber:      hexutil.Uint64(ev.BlockNumber),
+			BlockHash:        ev.BlockID,
+			TransactionHash:  ev.TxID,
+			TransactionIndex: hexutil.Uint64(txIndex),
+			LogIndex:         hexutil.Uint64(ev.Index),
+			ClauseIndex:      hexutil.Uint64(ev.ClauseIndex),
+		})
+	}
+	return logs, nil
+}
+
+// handleMessage handles a single or batch request, and returns the encoded response.
+// nil returned if no response is required.
+func (e *ETH) handleMessage(ctx context.Context, msg []byte, sess *session) []byte {
+	msg = bytes.TrimSpace(msg)
+	if len(msg) > 0 && msg[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(msg, &batch); err != nil {
+			return mustEncode(errorResponse(nil, &rpcError{Code: codeParseError, Message: err.Error()}))
+		}
+		if len(batch) == 0 {
+			return mustEncode(errorResponse(nil, &rpcError{Code: codeInvalidRequest, Message: "empty batch"}))
+		}
+		if len(batch) > maxBatchSize {
+			return mustEncode(errorResponse(nil, &rpcError{Code: codeInvalidRequest, Message: "batch too large"}))
+		}
+		responses := make([]*rpcResponse, 0, len(batch))
+		for _, raw := range batch {
+			if resp := e.handleRequest(ctx, raw, sess); resp != nil {
+				responses = append(responses, resp)
+			}
+		}
+		if len(responses) == 0 {
+			return nil
+		}
+		return mustEncode(responses)
+	}
+	if resp := e.handleRequest(ctx, msg, sess); resp != nil {
+		return mustEncode(resp)
+	}
+	return nil
+}
+
+func (e *ETH) handleRequest(ctx context.Context, raw json.RawMessage, sess *session) *rpcResponse {
+	var req rpcRequest
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return errorResponse(nil, &rpcError{Code: codeParseError, Message: err.Error()})
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return errorResponse(req.ID, &rpcError{Code: codeInvalidRequest, Message: "invalid request"})
+	}
+
+	var params []json.RawMessage
+	if len(req.Params) > 0 && string(req.Params) != "null" {
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return errorResponse(req.ID, invalidParams(errors.New("params must be an array")))
+		}
+	}
+
+	var (
+		result interface{}
+		err    error
+	)
+	switch req.Method {
+	case "eth_subscribe", "eth_unsubscribe":
+		if sess == nil {
+			err = &rpcError{Code: codeMethodNotFound, Message: "notifications not supported"}
+		} else if req.Method == "eth_subscribe" {
+			result, err = sess.subscribe(params)
+		} else {
+			result, err = sess.unsubscribe(params)
+		}
+	default:
+		if method, ok := e.methods[req.Method]; ok {
+			result, err = method(ctx, params)
+		} else {
+			err = &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("the method %s does not exist/is not available", req.Method)}
+		}
+	}
+
+	if req.isNotification() {
+		return nil
+	}
+	if err != nil {
+		return errorResponse(req.ID, err)
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return errorResponse(req.ID, err)
+	}
+	return &rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: data}
+}
+
+func errorResponse(id json.RawMessage, err error) *rpcResponse {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	rpcErr, ok := err.(*rpcError)
+	if !ok {
+		rpcErr = &rpcError{Code: codeInternalError, Message: err.Error()}
+	}
+	return &rpcResponse{JSONRPC: "2.0", ID: id, Error: rpcErr}
+}
+
+func mustEncode(v interface{}) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+func (e *ETH) handleHTTP(w http.ResponseWriter, req *http.Request) error {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "body"))
+	}
+	w.Header().Set("Content-Type", utils.JSONContentType)
+	if resp := e.handleMessage(req.Context(), body, nil); resp != nil {
+		_, err = w.Write(resp)
+		return err
+	}
+	return nil
+}
+
+func (e *ETH) handleWebsocket(w http.ResponseWriter, req *http.Request) error {
+	e.wg.Add(1)
+	defer e.wg.Done()
+
+	conn, err := e.upgrader.Upgrade(w, req, nil)
+	// since the conn is hijacked here, no error should be returned in lines below
+	if err != nil {
+		log.Debug("upgrade to websocket", "err", err)
+		return nil
+	}
+	newSession(e, conn).run()
+	return nil
+}
+
+// Close closes all websocket sessions.
+func (e *ETH) Close() {
+	close(e.done)
+	e.wg.Wait()
+}
+
+func (e *ETH) Mount(root *mux.Router, pathPrefix string) {
+	sub := root.PathPrefix(pathPrefix).Subrouter()
+
+	sub.Path("").Methods(http.MethodPost).HandlerFunc(utils.WrapHandlerFunc(e.handleHTTP))
+	sub.Path("").Methods(http.MethodGet).HandlerFunc(utils.WrapHandlerFunc(e.handleWebsocket))
+}
@@ -0,0 +1,239 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/miniBamboo/workshare/chain"
+	"github.com/miniBamboo/workshare/genesis"
+	"github.com/miniBamboo/workshare/logdb"
+	"github.com/miniBamboo/workshare/muxdb"
+	"github.com/miniBamboo/workshare/packer"
+	"github.com/miniBamboo/workshare/state"
+	"github.com/miniBamboo/workshare/tx"
+	"github.com/miniBamboo/workshare/workshare"
+	"github.com/stretchr/testify/assert"
+)
+
+// contract Test { uint8 value; function add(uint8 a,uint8 b) public pure returns(uint8); function set(uint8 v) public; }
+var (
+	testBytecode = common.Hex2Bytes("608060405234801561001057600080fd5b50610125806100206000396000f3006080604052600436106049576000357c0100000000000000000000000000000000000000000000000000000000900463ffffffff16806324b8ba5f14604e578063bb4e3f4d14607b575b600080fd5b348015605957600080fd5b506079600480360381019080803560ff16906020019092919050505060cf565b005b348015608657600080fd5b5060b3600480360381019080803560ff169060200190929190803560ff16906020019092919050505060ec565b604051808260ff1660ff16815260200191505060405180910390f35b806000806101000a81548160ff021916908360ff16021790555050565b60008183019050929150505600a165627a7a723058201584add23e31d36c569b468097fe01033525686b59bbb263fb3ab82e9553dae50029")
+	// set(7)
+	testSetInput = hexutil.MustDecode("0x24b8ba5f0000000000000000000000000000000000000000000000000000000000000007")
+)
+
+type testChain struct {
+	eth      *ETH
+	repo     *chain.Repository
+	logDB    *logdb.LogDB
+	contract workshare.Address
+}
+
+func newTestChain(t *testing.T) *testChain {
+	db := muxdb.NewMem()
+	stater := state.NewStater(db)
+	b, _, _, err := genesis.NewDevnet().Build(stater)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := chain.NewRepository(db, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logDB, err := logdb.NewMem()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	trx := new(tx.Builder).
+		ChainTag(repo.ChainTag()).
+		Expiration(10).
+		Gas(1000000).
+		Clause(tx.NewClause(nil).WithData(testBytecode)).
+		Build()
+	sig, err := crypto.Sign(trx.SigningHash().Bytes(), genesis.DevAccounts()[0].PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trx = trx.WithSignature(sig)
+
+	flow, err := packer.New(repo, stater, genesis.DevAccounts()[0].Address, &genesis.DevAccounts()[0].Address, workshare.NoFork).
+		Schedule(repo.BestBlockSummary(), uint64(time.Now().Unix()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := flow.Adopt(trx); err != nil {
+		t.Fatal(err)
+	}
+	blk, stage, receipts, err := flow.Pack(genesis.DevAccounts()[0].PrivateKey, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stage.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.AddBlock(blk, receipts, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.SetBestBlockID(blk.Header().ID()); err != nil {
+		t.Fatal(err)
+	}
+	w := logDB.NewWriter()
+	if err := w.Write(blk, receipts); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	return &testChain{
+		eth:      New(repo, stater, logDB, 10000000, workshare.NoFork, nil),
+		repo:     repo,
+		logDB:    logDB,
+		contract: workshare.CreateContractAddress(trx.ID(), 0, 0),
+	}
+}
+
+// call sends a single request and decodes the response.
+func (c *testChain) call(t *testing.T, method string, params ...interface{}) (json.RawMessage, *rpcError) {
+	req := map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": method, "params": params}
+	data, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp rpcResponse
+	if err := json.Unmarshal(c.eth.handleMessage(context.Background(), data, nil), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp.Result, resp.Error
+}
+
+func TestHandleMessage(t *testing.T) {
+	e := New(nil, nil, nil, 0, workshare.NoFork, nil)
+	handle := func(msg string) string {
+		return string(e.handleMessage(context.Background(), []byte(msg), nil))
+	}
+	sha3 := hexutil.Encode(crypto.Keccak256([]byte{1}))
+
+	tests := []struct {
+		name string
+		msg  string
+		want string
+	}{
+		{"single", `{"jsonrpc":"2.0","id":1,"method":"web3_sha3","params":["0x01"]}`,
+			`{"jsonrpc":"2.0","id":1,"result":"` + sha3 + `"}`},
+		{"string id", `{"jsonrpc":"2.0","id":"a","method":"web3_sha3","params":["0x01"]}`,
+			`{"jsonrpc":"2.0","id":"a","result":"` + sha3 + `"}`},
+		{"notification", `{"jsonrpc":"2.0","method":"web3_sha3","params":["0x01"]}`, ``},
+		{"failed notification", `{"jsonrpc":"2.0","method":"no_such_method"}`, ``},
+		{"batch", `[{"jsonrpc":"2.0","id":1,"method":"web3_sha3","params":["0x01"]},{"jsonrpc":"2.0","method":"web3_sha3","params":["0x01"]},{"jsonrpc":"2.0","id":2,"method":"no_such_method"}]`,
+			`[{"jsonrpc":"2.0","id":1,"result":"` + sha3 + `"},{"jsonrpc":"2.0","id":2,"error":{"code":-32601,"message":"the method no_such_method does not exist/is not available"}}]`},
+		{"batch of notifications", `[{"jsonrpc":"2.0","method":"web3_sha3","params":["0x01"]}]`, ``},
+		{"empty batch", `[]`, `{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"empty batch"}}`},
+		{"batch too large", `[` + strings.Repeat(`{},`, maxBatchSize) + `{}]`, `{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"batch too large"}}`},
+		{"invalid batch element", `[1]`, `[{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"json: cannot unmarshal number into Go value of type eth.rpcRequest"}}]`},
+		{"parse error", `{"jsonrpc":`, `{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"unexpected end of JSON input"}}`},
+		{"no version", `{"id":1,"method":"web3_sha3"}`, `{"jsonrpc":"2.0","id":1,"error":{"code":-32600,"message":"invalid request"}}`},
+		{"no method", `{"jsonrpc":"2.0","id":1}`, `{"jsonrpc":"2.0","id":1,"error":{"code":-32600,"message":"invalid request"}}`},
+		{"params not array", `{"jsonrpc":"2.0","id":1,"method":"web3_sha3","params":{}}`, `{"jsonrpc":"2.0","id":1,"error":{"code":-32602,"message":"params must be an array"}}`},
+		{"missing param", `{"jsonrpc":"2.0","id":1,"method":"web3_sha3","params":[]}`, `{"jsonrpc":"2.0","id":1,"error":{"code":-32602,"message":"missing value for required argument 0"}}`},
+		{"too many params", `{"jsonrpc":"2.0","id":1,"method":"web3_sha3","params":["0x01","0x02"]}`, `{"jsonrpc":"2.0","id":1,"error":{"code":-32602,"message":"too many arguments, want at most 1"}}`},
+		{"subscribe over http", `{"jsonrpc":"2.0","id":1,"method":"eth_subscribe","params":["newHeads"]}`, `{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"notifications not supported"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, handle(tt.msg))
+		})
+	}
+
+	// invalid param is reported with its position
+	resp := handle(`{"jsonrpc":"2.0","id":1,"method":"web3_sha3","params":["xyz"]}`)
+	assert.True(t, strings.HasPrefix(resp, `{"jsonrpc":"2.0","id":1,"error":{"code":-32602,"message":"invalid argument 0: `), resp)
+}
+
+func TestEstimateGas(t *testing.T) {
+	c := newTestChain(t)
+	args := map[string]interface{}{"to": c.contract.String(), "data": hexutil.Encode(testSetInput)}
+
+	result, rpcErr := c.call(t, "eth_estimateGas", args)
+	if rpcErr != nil {
+		t.Fatal(rpcErr)
+	}
+	var gas hexutil.Uint64
+	if err := json.Unmarshal(result, &gas); err != nil {
+		t.Fatal(err)
+	}
+	intrinsicGas, err := tx.IntrinsicGas(tx.NewClause(&c.contract).WithData(testSetInput))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, uint64(gas) > intrinsicGas)
+
+	// the estimated gas is the minimal gas
+	args["gas"] = gas
+	_, rpcErr = c.call(t, "eth_call", args)
+	assert.Nil(t, rpcErr)
+	args["gas"] = gas - 1
+	_, rpcErr = c.call(t, "eth_call", args)
+	if assert.NotNil(t, rpcErr) {
+		assert.Equal(t, codeServerError, rpcErr.Code)
+	}
+
+	// plain transfer needs no execution gas
+	result, rpcErr = c.call(t, "eth_estimateGas", map[string]interface{}{"to": workshare.BytesToAddress([]byte("to")).String()})
+	assert.Nil(t, rpcErr)
+	assert.Equal(t, `"0x5208"`, string(result))
+
+	// reverted
+	_, rpcErr = c.call(t, "eth_estimateGas", map[string]interface{}{"to": c.contract.String(), "data": "0x12345678"})
+	if assert.NotNil(t, rpcErr) {
+		assert.Equal(t, codeReverted, rpcErr.Code)
+		assert.Equal(t, "0x", rpcErr.Data)
+	}
+
+	// gas out of limit
+	_, rpcErr = c.call(t, "eth_estimateGas", map[string]interface{}{"to": c.contract.String(), "gas": hexutil.Uint64(100)})
+	if assert.NotNil(t, rpcErr) {
+		assert.Equal(t, codeInvalidParams, rpcErr.Code)
+	}
+}
+
+func TestGetLogs(t *testing.T) {
+	c := newTestChain(t)
+
+	// logs of all blocks
+	result, rpcErr := c.call(t, "eth_getLogs", map[string]interface{}{"fromBlock": "earliest"})
+	if rpcErr != nil {
+		t.Fatal(rpcErr)
+	}
+	var logs []*Log
+	if err := json.Unmarshal(result, &logs); err != nil {
+		t.Fatal(err)
+	}
+	events, err := c.logDB.FilterEvents(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(events), len(logs))
+
+	_, rpcErr = c.call(t, "eth_getLogs", map[string]interface{}{"blockHash": c.repo.BestBlockSummary().Header.ID(), "fromBlock": "0x0"})
+	if assert.NotNil(t, rpcErr) {
+		assert.Equal(t, codeInvalidParams, rpcErr.Code)
+	}
+
+	_, rpcErr = c.call(t, "eth_getLogs", map[string]interface{}{"topics": make([]interface{}, 6)})
+	if assert.NotNil(t, rpcErr) {
+		assert.Equal(t, codeInvalidParams, rpcErr.Code)
+	}
+}
@@ -0,0 +1,260 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package eth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gorilla/websocket"
+	"github.com/miniBamboo/workshare/chain"
+	"github.com/pkg/errors"
+)
+
+const (
+	// Time allowed to read the next pong message from the peer.
+	pongWait = 60 * time.Second
+	// Send pings to peer with this period. Must be less than pongWait.
+	pingPeriod = (pongWait * 7) / 10
+	// Max subscriptions per websocket connection.
+	maxSubscriptions = 32
+)
+
+// session serves JSON-RPC over a websocket connection.
+type session struct {
+	eth    *ETH
+	conn   *websocket.Conn
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	writeLock sync.Mutex
+	lock      sync.Mutex
+	subs      map[string]context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+func newSession(eth *ETH, conn *websocket.Conn) *session {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &session{
+		eth:    eth,
+		conn:   conn,
+		ctx:    ctx,
+		cancel: cancel,
+		subs:   make(map[string]context.CancelFunc),
+	}
+}
+
+func (s *session) write(data []byte) error {
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+	return s.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// run serves the connection until it's closed by either side.
+func (s *session) run() {
+	defer s.conn.Close()
+	defer s.wg.Wait()
+	defer s.cancel()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer s.cancel()
+
+		s.conn.SetReadDeadline(time.Now().Add(pongWait))
+		s.conn.SetPongHandler(func(string) error {
+			s.conn.SetReadDeadline(time.Now().Add(pongWait))
+			return nil
+		})
+		for {
+			_, msg, err := s.conn.ReadMessage()
+			if err != nil {
+				log.Debug("websocket read err", "err", err)
+				return
+			}
+			if resp := s.eth.handleMessage(s.ctx, msg, s); resp != nil {
+				if err := s.write(resp); err != nil {
+					log.Debug("websocket write err", "err", err)
+					return
+				}
+			}
+		}
+	}()
+
+	pingTicker := time.NewTicker(pingPeriod)
+	defer pingTicker.Stop()
+	for {
+		select {
+		case <-s.eth.done:
+			s.writeLock.Lock()
+			s.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""))
+			s.writeLock.Unlock()
+			return
+		case <-s.ctx.Done():
+			return
+		case <-pingTicker.C:
+			s.writeLock.Lock()
+			err := s.conn.WriteMessage(websocket.PingMessage, nil)
+			s.writeLock.Unlock()
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *session) subscribe(params []json.RawMessage) (interface{}, error) {
+	if len(params) == 0 {
+		return nil, invalidParams(errors.New("missing subscription name"))
+	}
+	var name string
+	if err := json.Unmarshal(params[0], &name); err != nil {
+		return nil, invalidParams(errors.WithMessage(err, "subscription name"))
+	}
+
+	var convert func(block *chain.ExtendedBlock) ([]interface{}, error)
+	switch name {
+	case "newHeads":
+		if len(params) > 1 {
+			return nil, invalidParams(errors.New("too many arguments"))
+		}
+		convert = func(block *chain.ExtendedBlock) ([]interface{}, error) {
+			if block.Obsolete {
+				return nil, nil
+			}
+			return []interface{}{convertHeader(block.Header())}, nil
+		}
+	case "logs":
+		var query FilterQuery
+		if err := parseParams(params[1:], 0, &query); err != nil {
+			return nil, err
+		}
+		convert = func(block *chain.ExtendedBlock) ([]interface{}, error) {
+			return s.eth.blockLogs(block, &query)
+		}
+	default:
+		return nil, invalidParams(errors.Errorf("unsupported subscription %s", name))
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if len(s.subs) >= maxSubscriptions {
+		return nil, serverError(errors.New("too many subscriptions"))
+	}
+
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return nil, err
+	}
+	id := hexutil.Encode(b[:])
+	ctx, cancel := context.WithCancel(s.ctx)
+	s.subs[id] = cancel
+
+	reader := s.eth.repo.NewBlockReader(s.eth.repo.BestBlockSummary().Header.ID())
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := s.notify(ctx, id, reader, convert); err != nil {
+			log.Debug("subscription", "id", id, "err", err)
+		}
+	}()
+	return id, nil
+}
+
+func (s *session) unsubscribe(params []json.RawMessage) (interface{}, error) {
+	var id string
+	if err := parseParams(params, 1, &id); err != nil {
+		return nil, err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	cancel, ok := s.subs[id]
+	if !ok {
+		return false, nil
+	}
+	cancel()
+	delete(s.subs, id)
+	return true, nil
+}
+
+// notify pushes notifications of new blocks until the subscription is cancelled.
+func (s *session) notify(
+	ctx context.Context,
+	id string,
+	reader chain.BlockReader,
+	convert func(block *chain.ExtendedBlock) ([]interface{}, error),
+) error {
+	ticker := s.eth.repo.NewTicker()
+	for {
+		blocks, err := reader.Read()
+		if err != nil {
+			return err
+		}
+		for _, block := range blocks {
+			results, err := convert(block)
+			if err != nil {
+				return err
+			}
+			for _, result := range results {
+				data, err := json.Marshal(&rpcNotification{
+					JSONRPC: "2.0",
+					Method:  "eth_subscription",
+					Params:  subscriptionResult{Subscription: id, Result: result},
+				})
+				if err != nil {
+					return err
+				}
+				if err := s.write(data); err != nil {
+					return err
+				}
+			}
+		}
+		if len(blocks) == 0 {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C():
+			}
+		} else {
+			select {
+			case <-ctx.Done():
+				return nil
+			default:
+			}
+		}
+	}
+}
+
+// blockLogs returns logs of the block matched by the query.
+func (e *ETH) blockLogs(block *chain.ExtendedBlock, query *FilterQuery) ([]interface{}, error) {
+	if query.BlockHash != nil && *query.BlockHash != block.Header().ID() {
+		return nil, nil
+	}
+	receipts, err := e.repo.GetBlockReceipts(block.Header().ID())
+	if err != nil {
+		return nil, err
+	}
+	var (
+		logs     []interface{}
+		logIndex uint64
+		txs      = block.Transactions()
+	)
+	for i, receipt := range receipts {
+		for clauseIndex, output := range receipt.Outputs {
+			for _, event := range output.Events {
+				if query.Match(event) {
+					logs = append(logs, convertLog(block.Header(), txs[i].ID(), uint64(i), uint64(clauseIndex), logIndex, event, block.Obsolete))
+				}
+				logIndex++
+			}
+		}
+	}
+	return logs, nil
+}
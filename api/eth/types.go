@@ -0,0 +1,555 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package eth
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/miniBamboo/workshare/block"
+	"github.com/miniBamboo/workshare/tx"
+	"github.com/miniBamboo/workshare/workshare"
+	"github.com/pkg/errors"
+)
+
+// standard JSON-RPC 2.0 error codes, and the one used by geth for reverted executions.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+	codeServerError    = -32000
+	codeReverted       = 3
+)
+
+var (
+	// keccak256(rlp([])), the uncle hash of a block without uncles.
+	emptyUncleHash = workshare.MustParseBytes32("0x1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347")
+	emptyLogsBloom = make(hexutil.Bytes, 256)
+	emptyNonce     = make(hexutil.Bytes, 8)
+)
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+// isNotification returns whether the request expects no response.
+func (r *rpcRequest) isNotification() bool {
+	return len(r.ID) == 0
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcNotification struct {
+	JSONRPC string             `json:"jsonrpc"`
+	Method  string             `json:"method"`
+	Params  subscriptionResult `json:"params"`
+}
+
+type subscriptionResult struct {
+	Subscription string      `json:"subscription"`
+	Result       interface{} `json:"result"`
+}
+
+type rpcError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+func invalidParams(cause error) error {
+	return &rpcError{Code: codeInvalidParams, Message: cause.Error()}
+}
+
+func serverError(cause error) error {
+	return &rpcError{Code: codeServerError, Message: cause.Error()}
+}
+
+// BlockNumberOrHash specifies a block by tag, number or hash (EIP-1898).
+type BlockNumberOrHash struct {
+	Tag    string
+	Number *uint32
+	Hash   *workshare.Bytes32
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (b *BlockNumberOrHash) UnmarshalJSON(data []byte) error {
+	var obj struct {
+		BlockNumber *string            `json:"blockNumber"`
+		BlockHash   *workshare.Bytes32 `json:"blockHash"`
+	}
+	if len(data) > 0 && data[0] == '{' {
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return err
+		}
+		if obj.BlockHash != nil {
+			if obj.BlockNumber != nil {
+				return errors.New("cannot specify both blockHash and blockNumber")
+			}
+			b.Hash = obj.BlockHash
+			return nil
+		}
+		if obj.BlockNumber == nil {
+			return errors.New("either blockHash or blockNumber required")
+		}
+		return b.parse(*obj.BlockNumber)
+	}
+
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+	return b.parse(str)
+}
+
+func (b *BlockNumberOrHash) parse(str string) error {
+	switch str {
+	case "latest", "pending", "safe", "finalized", "earliest":
+		b.Tag = str
+		return nil
+	}
+	if len(str) == 66 {
+		hash, err := workshare.ParseBytes32(str)
+		if err != nil {
+			return err
+		}
+		b.Hash = &hash
+		return nil
+	}
+	n, err := hexutil.DecodeUint64(str)
+	if err != nil {
+		return err
+	}
+	if n > uint64(^uint32(0)) {
+		return errors.New("block number out of max uint32")
+	}
+	num := uint32(n)
+	b.Number = &num
+	return nil
+}
+
+// CallArgs represents the arguments of eth_call and eth_estimateGas.
+type CallArgs struct {
+	From     *workshare.Address `json:"from"`
+	To       *workshare.Address `json:"to"`
+	Gas      *hexutil.Uint64    `json:"gas"`
+	GasPrice *hexutil.Big       `json:"gasPrice"`
+	Value    *hexutil.Big       `json:"value"`
+	Data     *hexutil.Bytes     `json:"data"`
+	Input    *hexutil.Bytes     `json:"input"`
+}
+
+func (args *CallArgs) clause() *tx.Clause {
+	var data []byte
+	if args.Input != nil {
+		data = *args.Input
+	} else if args.Data != nil {
+		data = *args.Data
+	}
+	value := new(big.Int)
+	if args.Value != nil {
+		value = (*big.Int)(args.Value)
+	}
+	return tx.NewClause(args.To).WithValue(value).WithData(data)
+}
+
+// AddressList is a single address or a list of addresses.
+type AddressList []workshare.Address
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (l *AddressList) UnmarshalJSON(data []byte) error {
+	if strings.TrimSpace(string(data)) == "null" {
+		*l = nil
+		return nil
+	}
+	if len(data) > 0 && data[0] == '[' {
+		var addrs []workshare.Address
+		if err := json.Unmarshal(data, &addrs); err != nil {
+			return err
+		}
+		*l = addrs
+		return nil
+	}
+	var addr workshare.Address
+	if err := json.Unmarshal(data, &addr); err != nil {
+		return err
+	}
+	*l = AddressList{addr}
+	return nil
+}
+
+// TopicList is a wildcard (null), a single topic or a list of alternative topics.
+type TopicList []workshare.Bytes32
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (l *TopicList) UnmarshalJSON(data []byte) error {
+	if strings.TrimSpace(string(data)) == "null" {
+		*l = nil
+		return nil
+	}
+	if len(data) > 0 && data[0] == '[' {
+		var topics []*workshare.Bytes32
+		if err := json.Unmarshal(data, &topics); err != nil {
+			return err
+		}
+		list := make(TopicList, 0, len(topics))
+		for _, topic := range topics {
+			// a null in alternatives matches anything
+			if topic == nil {
+				*l = nil
+				return nil
+			}
+			list = append(list, *topic)
+		}
+		*l = list
+		return nil
+	}
+	var topic workshare.Bytes32
+	if err := json.Unmarshal(data, &topic); err != nil {
+		return err
+	}
+	*l = TopicList{topic}
+	return nil
+}
+
+// FilterQuery represents the argument of eth_getLogs and eth_subscribe('logs').
+type FilterQuery struct {
+	BlockHash *workshare.Bytes32 `json:"blockHash"`
+	FromBlock *BlockNumberOrHash `json:"fromBlock"`
+	ToBlock   *BlockNumberOrHash `json:"toBlock"`
+	Address   AddressList        `json:"address"`
+	Topics    []TopicList        `json:"topics"`
+}
+
+// Match returns whether the event matches the query, regardless of block range.
+func (q *FilterQuery) Match(event *tx.Event) bool {
+	if len(q.Address) > 0 {
+		found := false
+		for _, addr := range q.Address {
+			if addr == event.Address {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for i, alternatives := range q.Topics {
+		if len(alternatives) == 0 {
+			continue
+		}
+		if i >= len(event.Topics) {
+			return false
+		}
+		found := false
+		for _, topic := range alternatives {
+			if topic == event.Topics[i] {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Block is the Ethereum style block.
+type Block struct {
+	Number           hexutil.Uint64      `json:"number"`
+	Hash             workshare.Bytes32   `json:"hash"`
+	ParentHash       workshare.Bytes32   `json:"parentHash"`
+	Nonce            hexutil.Bytes       `json:"nonce"`
+	Sha3Uncles       workshare.Bytes32   `json:"sha3Uncles"`
+	LogsBloom        hexutil.Bytes       `json:"logsBloom"`
+	TransactionsRoot workshare.Bytes32   `json:"transactionsRoot"`
+	StateRoot        workshare.Bytes32   `json:"stateRoot"`
+	ReceiptsRoot     workshare.Bytes32   `json:"receiptsRoot"`
+	Miner            workshare.Address   `json:"miner"`
+	Difficulty       hexutil.Uint64      `json:"difficulty"`
+	TotalDifficulty  hexutil.Uint64      `json:"totalDifficulty"`
+	ExtraData        hexutil.Bytes       `json:"extraData"`
+	Size             hexutil.Uint64      `json:"size"`
+	GasLimit         hexutil.Uint64      `json:"gasLimit"`
+	GasUsed          hexutil.Uint64      `json:"gasUsed"`
+	Timestamp        hexutil.Uint64      `json:"timestamp"`
+	Transactions     []interface{}       `json:"transactions"`
+	Uncles           []workshare.Bytes32 `json:"uncles"`
+}
+
+// Header is the Ethereum style block header, pushed by eth_subscribe('newHeads').
+type Header struct {
+	Number           hexutil.Uint64    `json:"number"`
+	Hash             workshare.Bytes32 `json:"hash"`
+	ParentHash       workshare.Bytes32 `json:"parentHash"`
+	Nonce            hexutil.Bytes     `json:"nonce"`
+	Sha3Uncles       workshare.Bytes32 `json:"sha3Uncles"`
+	LogsBloom        hexutil.Bytes     `json:"logsBloom"`
+	TransactionsRoot workshare.Bytes32 `json:"transactionsRoot"`
+	StateRoot        workshare.Bytes32 `json:"stateRoot"`
+	ReceiptsRoot     workshare.Bytes32 `json:"receiptsRoot"`
+	Miner            workshare.Address `json:"miner"`
+	Difficulty       hexutil.Uint64    `json:"difficulty"`
+	ExtraData        hexutil.Bytes     `json:"extraData"`
+	GasLimit         hexutil.Uint64    `json:"gasLimit"`
+	GasUsed          hexutil.Uint64    `json:"gasUsed"`
+	Timestamp        hexutil.Uint64    `json:"timestamp"`
+}
+
+// Clause is a clause of the multi-clause transaction.
+type Clause struct {
+	To    *workshare.Address `json:"to"`
+	Value *hexutil.Big       `json:"value"`
+	Input hexutil.Bytes      `json:"input"`
+}
+
+// Transaction is the Ethereum style transaction.
+type Transaction struct {
+	Hash             workshare.Bytes32  `json:"hash"`
+	Nonce            hexutil.Uint64     `json:"nonce"`
+	BlockHash        *workshare.Bytes32 `json:"blockHash"`
+	BlockNumber      *hexutil.Uint64    `json:"blockNumber"`
+	TransactionIndex *hexutil.Uint64    `json:"transactionIndex"`
+	From             workshare.Address  `json:"from"`
+	To               *workshare.Address `json:"to"`
+	Value            *hexutil.Big       `json:"value"`
+	Gas              hexutil.Uint64     `json:"gas"`
+	GasPrice         *hexutil.Big       `json:"gasPrice"`
+	Input            hexutil.Bytes      `json:"input"`
+	ChainID          hexutil.Uint64     `json:"chainId"`
+	Type             hexutil.Uint64     `json:"type"`
+	Delegator        *workshare.Address `json:"delegator"`
+	Clauses          []*Clause          `json:"clauses"`
+}
+
+// Receipt is the Ethereum style transaction receipt.
+type Receipt struct {
+	TransactionHash   workshare.Bytes32  `json:"transactionHash"`
+	TransactionIndex  hexutil.Uint64     `json:"transactionIndex"`
+	BlockHash         workshare.Bytes32  `json:"blockHash"`
+	BlockNumber       hexutil.Uint64     `json:"blockNumber"`
+	From              workshare.Address  `json:"from"`
+	To                *workshare.Address `json:"to"`
+	CumulativeGasUsed hexutil.Uint64     `json:"cumulativeGasUsed"`
+	GasUsed           hexutil.Uint64     `json:"gasUsed"`
+	EffectiveGasPrice *hexutil.Big       `json:"effectiveGasPrice"`
+	ContractAddress   *workshare.Address `json:"contractAddress"`
+	Logs              []*Log             `json:"logs"`
+	LogsBloom         hexutil.Bytes      `json:"logsBloom"`
+	Status            hexutil.Uint64     `json:"status"`
+	Type              hexutil.Uint64     `json:"type"`
+	GasPayer          workshare.Address  `json:"gasPayer"`
+}
+
+// Log is the Ethereum style log, converted from tx.Event.
+type Log struct {
+	Address          workshare.Address   `json:"address"`
+	Topics           []workshare.Bytes32 `json:"topics"`
+	Data             hexutil.Bytes       `json:"data"`
+	BlockNumber      hexutil.Uint64      `json:"blockNumber"`
+	BlockHash        workshare.Bytes32   `json:"blockHash"`
+	TransactionHash  workshare.Bytes32   `json:"transactionHash"`
+	TransactionIndex hexutil.Uint64      `json:"transactionIndex"`
+	LogIndex         hexutil.Uint64      `json:"logIndex"`
+	ClauseIndex      hexutil.Uint64      `json:"clauseIndex"`
+	Removed          bool                `json:"removed"`
+}
+
+func convertHeader(header *block.Header) *Header {
+	return &Header{
+		Number:           hexutil.Uint64(header.Number()),
+		Hash:             header.ID(),
+		ParentHash:       header.ParentID(),
+		Nonce:            emptyNonce,
+		Sha3Uncles:       emptyUncleHash,
+		LogsBloom:        emptyLogsBloom,
+		TransactionsRoot: header.TxsRoot(),
+		StateRoot:        header.StateRoot(),
+		ReceiptsRoot:     header.ReceiptsRoot(),
+		Miner:            header.Beneficiary(),
+		ExtraData:        hexutil.Bytes{},
+		GasLimit:         hexutil.Uint64(header.GasLimit()),
+		GasUsed:          hexutil.Uint64(header.GasUsed()),
+		Timestamp:        hexutil.Uint64(header.Timestamp()),
+	}
+}
+
+func convertBlock(b *block.Block, receipts tx.Receipts, chainTag byte, fullTx bool) *Block {
+	header := b.Header()
+	eb := &Block{
+		Number:           hexutil.Uint64(header.Number()),
+		Hash:             header.ID(),
+		ParentHash:       header.ParentID(),
+		Nonce:            emptyNonce,
+		Sha3Uncles:       emptyUncleHash,
+		LogsBloom:        emptyLogsBloom,
+		TransactionsRoot: header.TxsRoot(),
+		StateRoot:        header.StateRoot(),
+		ReceiptsRoot:     header.ReceiptsRoot(),
+		Miner:            header.Beneficiary(),
+		TotalDifficulty:  hexutil.Uint64(header.TotalScore()),
+		ExtraData:        hexutil.Bytes{},
+		Size:             hexutil.Uint64(b.Size()),
+		GasLimit:         hexutil.Uint64(header.GasLimit()),
+		GasUsed:          hexutil.Uint64(header.GasUsed()),
+		Timestamp:        hexutil.Uint64(header.Timestamp()),
+		Transactions:     make([]interface{}, 0, len(b.Transactions())),
+		Uncles:           []workshare.Bytes32{},
+	}
+	for i, t := range b.Transactions() {
+		if fullTx {
+			eb.Transactions = append(eb.Transactions, convertTransaction(t, header, uint64(i), receipts[i], chainTag))
+		} else {
+			id := t.ID()
+			eb.Transactions = append(eb.Transactions, &id)
+		}
+	}
+	return eb
+}
+
+func convertTransaction(t *tx.Transaction, header *block.Header, index uint64, receipt *tx.Receipt, chainTag byte) *Transaction {
+	origin, _ := t.Origin()
+	delegator, _ := t.Delegator()
+
+	clauses := make([]*Clause, len(t.Clauses()))
+	for i, c := range t.Clauses() {
+		clauses[i] = &Clause{
+			To:    c.To(),
+			Value: (*hexutil.Big)(c.Value()),
+			Input: c.Data(),
+		}
+	}
+
+	et := &Transaction{
+		Hash:      t.ID(),
+		Nonce:     hexutil.Uint64(t.Nonce()),
+		From:      origin,
+		Gas:       hexutil.Uint64(t.Gas()),
+		GasPrice:  (*hexutil.Big)(new(big.Int)),
+		Input:     hexutil.Bytes{},
+		Value:     (*hexutil.Big)(new(big.Int)),
+		ChainID:   hexutil.Uint64(chainTag),
+		Delegator: delegator,
+		Clauses:   clauses,
+	}
+	if len(clauses) > 0 {
+		et.To = clauses[0].To
+		et.Value = clauses[0].Value
+		et.Input = clauses[0].Input
+	}
+	if header != nil {
+		blockID := header.ID()
+		blockNum := hexutil.Uint64(header.Number())
+		txIndex := hexutil.Uint64(index)
+		et.BlockHash = &blockID
+		et.BlockNumber = &blockNum
+		et.TransactionIndex = &txIndex
+	}
+	if receipt != nil {
+		et.GasPrice = (*hexutil.Big)(effectiveGasPrice(receipt))
+	}
+	return et
+}
+
+// effectiveGasPrice derives the gas price actually paid from the receipt.
+func effectiveGasPrice(receipt *tx.Receipt) *big.Int {
+	if receipt.GasUsed == 0 {
+		return new(big.Int)
+	}
+	return new(big.Int).Div(receipt.Paid, new(big.Int).SetUint64(receipt.GasUsed))
+}
+
+// convertReceipt converts the receipt of the tx at txIndex of the block.
+// All receipts of the block are required to compute cumulative gas and log index.
+func convertReceipt(b *block.Block, receipts tx.Receipts, txIndex uint64) *Receipt {
+	var (
+		header            = b.Header()
+		t                 = b.Transactions()[txIndex]
+		receipt           = receipts[txIndex]
+		cumulativeGasUsed uint64
+		logIndex          uint64
+	)
+	for i := uint64(0); i <= txIndex; i++ {
+		cumulativeGasUsed += receipts[i].GasUsed
+		if i < txIndex {
+			for _, output := range receipts[i].Outputs {
+				logIndex += uint64(len(output.Events))
+			}
+		}
+	}
+
+	origin, _ := t.Origin()
+	er := &Receipt{
+		TransactionHash:   t.ID(),
+		TransactionIndex:  hexutil.Uint64(txIndex),
+		BlockHash:         header.ID(),
+		BlockNumber:       hexutil.Uint64(header.Number()),
+		From:              origin,
+		CumulativeGasUsed: hexutil.Uint64(cumulativeGasUsed),
+		GasUsed:           hexutil.Uint64(receipt.GasUsed),
+		EffectiveGasPrice: (*hexutil.Big)(effectiveGasPrice(receipt)),
+		Logs:              []*Log{},
+		LogsBloom:         emptyLogsBloom,
+		GasPayer:          receipt.GasPayer,
+	}
+	if !receipt.Reverted {
+		er.Status = 1
+	}
+	if clauses := t.Clauses(); len(clauses) > 0 {
+		er.To = clauses[0].To()
+		if clauses[0].IsCreatingContract() && !receipt.Reverted {
+			addr := workshare.CreateContractAddress(t.ID(), 0, 0)
+			er.ContractAddress = &addr
+		}
+	}
+	for clauseIndex, output := range receipt.Outputs {
+		for _, event := range output.Events {
+			er.Logs = append(er.Logs, convertLog(header, t.ID(), txIndex, uint64(clauseIndex), logIndex, event, false))
+			logIndex++
+		}
+	}
+	return er
+}
+
+func convertLog(header *block.Header, txID workshare.Bytes32, txIndex, clauseIndex, logIndex uint64, event *tx.Event, removed bool) *Log {
+	topics := event.Topics
+	if topics == nil {
+		topics = []workshare.Bytes32{}
+	}
+	data := event.Data
+	if data == nil {
+		data = []byte{}
+	}
+	return &Log{
+		Address:          event.Address,
+		Topics:           topics,
+		Data:             data,
+		BlockNumber:      hexutil.Uint64(header.Number()),
+		BlockHash:        header.ID(),
+		TransactionHash:  txID,
+		TransactionIndex: hexutil.Uint64(txIndex),
+		LogIndex:         hexutil.Uint64(logIndex),
+		ClauseIndex:      hexutil.Uint64(clauseIndex),
+		Removed:          removed,
+	}
+}
@@ -0,0 +1,182 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package eth
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/miniBamboo/workshare/tx"
+	"github.com/miniBamboo/workshare/workshare"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testHash  = workshare.MustParseBytes32("0x00000002c5fe5f5e2bf9fd4e9e3f1ab9bd3b1a1bb3d0ca1f25d3e3edf2c5d3ee")
+	testAddr1 = workshare.BytesToAddress([]byte("addr1"))
+	testAddr2 = workshare.BytesToAddress([]byte("addr2"))
+	topic1    = workshare.BytesToBytes32([]byte("topic1"))
+	topic2    = workshare.BytesToBytes32([]byte("topic2"))
+)
+
+func uint32Ptr(n uint32) *uint32 {
+	return &n
+}
+
+func TestBlockNumberOrHash(t *testing.T) {
+	tests := []struct {
+		json    string
+		want    BlockNumberOrHash
+		wantErr bool
+	}{
+		{`"latest"`, BlockNumberOrHash{Tag: "latest"}, false},
+		{`"earliest"`, BlockNumberOrHash{Tag: "earliest"}, false},
+		{`"finalized"`, BlockNumberOrHash{Tag: "finalized"}, false},
+		{`"0x0"`, BlockNumberOrHash{Number: uint32Ptr(0)}, false},
+		{`"0x10"`, BlockNumberOrHash{Number: uint32Ptr(16)}, false},
+		{`"0xffffffff"`, BlockNumberOrHash{Number: uint32Ptr(0xffffffff)}, false},
+		{`"` + testHash.String() + `"`, BlockNumberOrHash{Hash: &testHash}, false},
+		{`{"blockHash":"` + testHash.String() + `"}`, BlockNumberOrHash{Hash: &testHash}, false},
+		{`{"blockNumber":"0x1"}`, BlockNumberOrHash{Number: uint32Ptr(1)}, false},
+		{`{"blockNumber":"latest"}`, BlockNumberOrHash{Tag: "latest"}, false},
+		{`{"blockNumber":"0x1","blockHash":"` + testHash.String() + `"}`, BlockNumberOrHash{}, true},
+		{`{}`, BlockNumberOrHash{}, true},
+		{`"0x100000000"`, BlockNumberOrHash{}, true},
+		{`"0x"`, BlockNumberOrHash{}, true},
+		{`"10"`, BlockNumberOrHash{}, true},
+		{`"pending1"`, BlockNumberOrHash{}, true},
+		{`1`, BlockNumberOrHash{}, true},
+	}
+	for _, tt := range tests {
+		var got BlockNumberOrHash
+		err := json.Unmarshal([]byte(tt.json), &got)
+		if tt.wantErr {
+			assert.NotNil(t, err, tt.json)
+			continue
+		}
+		if assert.Nil(t, err, tt.json) {
+			assert.Equal(t, tt.want, got, tt.json)
+		}
+	}
+}
+
+func TestAddressList(t *testing.T) {
+	tests := []struct {
+		json    string
+		want    AddressList
+		wantErr bool
+	}{
+		{`null`, nil, false},
+		{`"` + testAddr1.String() + `"`, AddressList{testAddr1}, false},
+		{`[]`, AddressList{}, false},
+		{`["` + testAddr1.String() + `","` + testAddr2.String() + `"]`, AddressList{testAddr1, testAddr2}, false},
+		{`"0x01"`, nil, true},
+		{`[null]`, nil, true},
+		{`1`, nil, true},
+	}
+	for _, tt := range tests {
+		var got AddressList
+		err := json.Unmarshal([]byte(tt.json), &got)
+		if tt.wantErr {
+			assert.NotNil(t, err, tt.json)
+			continue
+		}
+		if assert.Nil(t, err, tt.json) {
+			assert.Equal(t, tt.want, got, tt.json)
+		}
+	}
+}
+
+func TestTopicList(t *testing.T) {
+	tests := []struct {
+		json    string
+		want    TopicList
+		wantErr bool
+	}{
+		{`null`, nil, false},
+		{`"` + topic1.String() + `"`, TopicList{topic1}, false},
+		{`["` + topic1.String() + `","` + topic2.String() + `"]`, TopicList{topic1, topic2}, false},
+		{`["` + topic1.String() + `",null]`, nil, false}, // null alternative matches anything
+		{`[]`, TopicList{}, false},
+		{`"0x01"`, nil, true},
+		{`[1]`, nil, true},
+	}
+	for _, tt := range tests {
+		var got TopicList
+		err := json.Unmarshal([]byte(tt.json), &got)
+		if tt.wantErr {
+			assert.NotNil(t, err, tt.json)
+			continue
+		}
+		if assert.Nil(t, err, tt.json) {
+			assert.Equal(t, tt.want, got, tt.json)
+		}
+	}
+
+	// positional topics in a filter query
+	var q FilterQuery
+	err := json.Unmarshal([]byte(`{"topics":[null,"`+topic1.String()+`",["`+topic1.String()+`","`+topic2.String()+`"]]}`), &q)
+	assert.Nil(t, err)
+	assert.Equal(t, []TopicList{nil, {topic1}, {topic1, topic2}}, q.Topics)
+}
+
+func TestFilterQueryMatch(t *testing.T) {
+	event := &tx.Event{Address: testAddr1, Topics: []workshare.Bytes32{topic1, topic2}}
+
+	tests := []struct {
+		name  string
+		query FilterQuery
+		want  bool
+	}{
+		{"empty", FilterQuery{}, true},
+		{"address", FilterQuery{Address: AddressList{testAddr1}}, true},
+		{"other address", FilterQuery{Address: AddressList{testAddr2}}, false},
+		{"any of addresses", FilterQuery{Address: AddressList{testAddr2, testAddr1}}, true},
+		{"topic0", FilterQuery{Topics: []TopicList{{topic1}}}, true},
+		{"wrong topic0", FilterQuery{Topics: []TopicList{{topic2}}}, false},
+		{"wildcard topic0", FilterQuery{Topics: []TopicList{nil, {topic2}}}, true},
+		{"any of topics", FilterQuery{Topics: []TopicList{{topic2, topic1}}}, true},
+		{"topic beyond event topics", FilterQuery{Topics: []TopicList{nil, nil, {topic1}}}, false},
+		{"wildcard beyond event topics", FilterQuery{Topics: []TopicList{nil, nil, nil}}, true},
+		{"address and topics", FilterQuery{Address: AddressList{testAddr1}, Topics: []TopicList{{topic1}, {topic2}}}, true},
+		{"address matches but topic not", FilterQuery{Address: AddressList{testAddr1}, Topics: []TopicList{{topic1}, {topic1}}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.query.Match(event))
+		})
+	}
+}
+
+func TestBuildCriteria(t *testing.T) {
+	// no address or topic
+	criteria, err := buildCriteria(&FilterQuery{Topics: []TopicList{nil, nil}})
+	assert.Nil(t, err)
+	assert.Nil(t, criteria)
+
+	criteria, err = buildCriteria(&FilterQuery{Address: AddressList{testAddr1}, Topics: []TopicList{nil, {topic1, topic2}}})
+	assert.Nil(t, err)
+	if assert.Equal(t, 1, len(criteria)) {
+		assert.Equal(t, []workshare.Address{testAddr1}, criteria[0].Address)
+		assert.Nil(t, criteria[0].Topics[0])
+		assert.Equal(t, []workshare.Bytes32{topic1, topic2}, criteria[0].Topics[1])
+	}
+
+	// too many topics
+	_, err = buildCriteria(&FilterQuery{Topics: make([]TopicList, 6)})
+	assert.Equal(t, codeInvalidParams, err.(*rpcError).Code)
+
+	// addresses and topics are counted together
+	addrs := make(AddressList, maxFilterValues)
+	_, err = buildCriteria(&FilterQuery{Address: addrs})
+	assert.Nil(t, err)
+	_, err = buildCriteria(&FilterQuery{Address: addrs, Topics: []TopicList{{topic1}}})
+	if assert.NotNil(t, err) {
+		assert.Equal(t, codeInvalidParams, err.(*rpcError).Code)
+		assert.True(t, strings.Contains(err.Error(), "too many"))
+	}
+}
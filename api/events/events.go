@@ -10,40 +10,71 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"github.com/miniBamboo/workshare/api/abis"
 	"github.com/miniBamboo/workshare/api/utils"
 	"github.com/miniBamboo/workshare/chain"
 	"github.com/miniBamboo/workshare/logdb"
+	"github.com/miniBamboo/workshare/state"
+	"github.com/miniBamboo/workshare/workshare"
 	"github.com/pkg/errors"
 )
 
+// NextCursorHeader is the response header carrying the cursor to fetch the next page.
+// It's set only if the page is full.
+const NextCursorHeader = "x-next-cursor"
+
 type Events struct {
-	repo *chain.Repository
-	db   *logdb.LogDB
+	repo   *chain.Repository
+	db     *logdb.LogDB
+	stater *state.Stater
+	abis   *abis.Registry
 }
 
-func New(repo *chain.Repository, db *logdb.LogDB) *Events {
+func New(repo *chain.Repository, db *logdb.LogDB, stater *state.Stater, abis *abis.Registry) *Events {
 	return &Events{
 		repo,
 		db,
+		stater,
+		abis,
 	}
 }
 
-//Filter query events with option
-func (e *Events) filter(ctx context.Context, ef *EventFilter) ([]*FilteredEvent, error) {
+//Filter query events with option, and returns the cursor of the next page if any.
+func (e *Events) filter(ctx context.Context, ef *EventFilter, decoded bool) ([]*FilteredEvent, *logdb.Cursor, error) {
 	chain := e.repo.NewBestChain()
 	filter, err := convertEventFilter(chain, ef)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	events, err := e.db.FilterEvents(ctx, filter)
 	if err != nil {
-		return nil, err
+		return nil, nil, ConvertFilterError(err)
 	}
 	fes := make([]*FilteredEvent, len(events))
 	for i, e := range events {
 		fes[i] = convertEvent(e)
 	}
-	return fes, nil
+	if decoded {
+		// contracts are resolved by code hash in the best state
+		best := e.repo.BestBlockSummary()
+		decoder := e.abis.NewDecoder(e.stater.NewState(best.Header.StateRoot(), best.Header.Number(), best.Conflicts, best.SteadyNum))
+		for i, ev := range events {
+			topics := make([]workshare.Bytes32, 0, len(ev.Topics))
+			for _, topic := range ev.Topics {
+				if topic != nil {
+					topics = append(topics, *topic)
+				}
+			}
+			if fes[i].Decoded, err = decoder.DecodeEvent(ev.Address, topics, ev.Data); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	var next *logdb.Cursor
+	if n := len(events); n > 0 && filter.Options != nil && uint64(n) == filter.Options.Limit {
+		next = events[n-1].Cursor()
+	}
+	return fes, next, nil
 }
 
 func (e *Events) handleFilter(w http.ResponseWriter, req *http.Request) error {
@@ -51,10 +82,20 @@ func (e *Events) handleFilter(w http.ResponseWriter, req *http.Request) error {
 	if err := utils.ParseJSON(req.Body, &filter); err != nil {
 		return utils.BadRequest(errors.WithMessage(err, "body"))
 	}
-	fes, err := e.filter(req.Context(), &filter)
+	if err := ValidateOptions(filter.Options); err != nil {
+		return err
+	}
+	decoded, err := utils.ParseDecoded(req)
+	if err != nil {
+		return err
+	}
+	fes, next, err := e.filter(req.Context(), &filter, decoded)
 	if err != nil {
 		return err
 	}
+	if next != nil {
+		w.Header().Set(NextCursorHeader, next.String())
+	}
 	return utils.WriteJSON(w, fes)
 }
 
@@ -63,3 +104,19 @@ func (e *Events) Mount(root *mux.Router, pathPrefix string) {
 
 	sub.Path("").Methods("POST").HandlerFunc(utils.WrapHandlerFunc(e.handleFilter))
 }
+
+// ConvertFilterError reports filtering logs in the pruned range as bad request.
+func ConvertFilterError(err error) error {
+	if _, ok := err.(*logdb.PrunedError); ok {
+		return utils.BadRequest(errors.WithMessage(err, "range"))
+	}
+	return err
+}
+
+// ValidateOptions checks the paging options. Offset is not allowed along with cursor.
+func ValidateOptions(options *logdb.Options) error {
+	if options != nil && options.Cursor != nil && options.Offset > 0 {
+		return utils.BadRequest(errors.New("options: offset and cursor are exclusive"))
+	}
+	return nil
+}
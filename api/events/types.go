@@ -6,16 +6,25 @@
 package events
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"math"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/miniBamboo/workshare/api/abis"
+	"github.com/miniBamboo/workshare/api/utils"
 	"github.com/miniBamboo/workshare/block"
 	"github.com/miniBamboo/workshare/chain"
 	"github.com/miniBamboo/workshare/logdb"
 	"github.com/miniBamboo/workshare/workshare"
+	"github.com/pkg/errors"
 )
 
+// maxFilterValues limits the number of values in all address and bytes32 sets of a filter,
+// since each one is a parameter of the SQL query.
+const maxFilterValues = 1000
+
 type LogMeta struct {
 	BlockID        workshare.Bytes32 `json:"blockID"`
 	BlockNumber    uint32            `json:"blockNumber"`
@@ -25,12 +34,54 @@ type LogMeta struct {
 	ClauseIndex    uint32            `json:"clauseIndex"`
 }
 
+// AddressSet is an OR-set of addresses, which is decoded from either a single address or an array.
+type AddressSet []workshare.Address
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *AddressSet) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		return json.Unmarshal(data, (*[]workshare.Address)(s))
+	}
+	if string(data) == "null" {
+		*s = nil
+		return nil
+	}
+	var addr workshare.Address
+	if err := json.Unmarshal(data, &addr); err != nil {
+		return err
+	}
+	*s = AddressSet{addr}
+	return nil
+}
+
+// Bytes32Set is an OR-set of bytes32 values, which is decoded from either a single value or an array.
+type Bytes32Set []workshare.Bytes32
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *Bytes32Set) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		return json.Unmarshal(data, (*[]workshare.Bytes32)(s))
+	}
+	if string(data) == "null" {
+		*s = nil
+		return nil
+	}
+	var b workshare.Bytes32
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	*s = Bytes32Set{b}
+	return nil
+}
+
 type TopicSet struct {
-	Topic0 *workshare.Bytes32 `json:"topic0"`
-	Topic1 *workshare.Bytes32 `json:"topic1"`
-	Topic2 *workshare.Bytes32 `json:"topic2"`
-	Topic3 *workshare.Bytes32 `json:"topic3"`
-	Topic4 *workshare.Bytes32 `json:"topic4"`
+	Topic0 Bytes32Set `json:"topic0"`
+	Topic1 Bytes32Set `json:"topic1"`
+	Topic2 Bytes32Set `json:"topic2"`
+	Topic3 Bytes32Set `json:"topic3"`
+	Topic4 Bytes32Set `json:"topic4"`
 }
 
 // FilteredEvent only comes from one contract
@@ -39,6 +90,7 @@ type FilteredEvent struct {
 	Topics  []*workshare.Bytes32 `json:"topics"`
 	Data    string               `json:"data"`
 	Meta    LogMeta              `json:"meta"`
+	Decoded *abis.DecodedEvent   `json:"decoded,omitempty"`
 }
 
 //convert a logdb.Event into a json format Event
@@ -90,7 +142,9 @@ func (e *FilteredEvent) String() string {
 }
 
 type EventCriteria struct {
-	Address *workshare.Address `json:"address"`
+	Address  AddressSet `json:"address"`
+	TxID     Bytes32Set `json:"txID"`
+	TxOrigin AddressSet `json:"txOrigin"`
 	TopicSet
 }
 
@@ -113,16 +167,24 @@ func convertEventFilter(chain *chain.Chain, filter *EventFilter) (*logdb.EventFi
 	}
 	if len(filter.CriteriaSet) > 0 {
 		criterias := make([]*logdb.EventCriteria, len(filter.CriteriaSet))
+		n := 0
 		for i, criteria := range filter.CriteriaSet {
-			var topics [5]*workshare.Bytes32
+			n += len(criteria.Address) + len(criteria.TxID) + len(criteria.TxOrigin) +
+				len(criteria.Topic0) + len(criteria.Topic1) + len(criteria.Topic2) + len(criteria.Topic3) + len(criteria.Topic4)
+			if n > maxFilterValues {
+				return nil, utils.BadRequest(errors.WithMessage(errors.New("too many values"), "criteriaSet"))
+			}
+			var topics [5][]workshare.Bytes32
 			topics[0] = criteria.Topic0
 			topics[1] = criteria.Topic1
 			topics[2] = criteria.Topic2
 			topics[3] = criteria.Topic3
 			topics[4] = criteria.Topic4
 			criteria := &logdb.EventCriteria{
-				Address: criteria.Address,
-				Topics:  topics,
+				Address:  criteria.Address,
+				Topics:   topics,
+				TxID:     criteria.TxID,
+				TxOrigin: criteria.TxOrigin,
 			}
 			criterias[i] = criteria
 		}
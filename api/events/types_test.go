@@ -0,0 +1,68 @@
+// Copyright (c) 2018 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package events
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/miniBamboo/workshare/api/utils"
+	"github.com/miniBamboo/workshare/workshare"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddressSet(t *testing.T) {
+	addr := workshare.BytesToAddress([]byte("addr"))
+	tests := []struct {
+		json string
+		want AddressSet
+	}{
+		{`null`, nil},
+		{`"` + addr.String() + `"`, AddressSet{addr}},
+		{`["` + addr.String() + `","` + addr.String() + `"]`, AddressSet{addr, addr}},
+		{`[]`, AddressSet{}},
+	}
+	for _, tt := range tests {
+		var s AddressSet
+		assert.Nil(t, json.Unmarshal([]byte(tt.json), &s), tt.json)
+		assert.Equal(t, tt.want, s, tt.json)
+	}
+	var s AddressSet
+	assert.Error(t, json.Unmarshal([]byte(`"0x01"`), &s))
+}
+
+func TestConvertEventFilterMaxValues(t *testing.T) {
+	newSet := func(n int) Bytes32Set {
+		return make(Bytes32Set, n)
+	}
+
+	// the limit counts values of all fields of all criteria
+	filter := &EventFilter{CriteriaSet: []*EventCriteria{
+		{Address: make(AddressSet, 200), TopicSet: TopicSet{Topic0: newSet(200), Topic4: newSet(100)}},
+		{TxID: newSet(250), TxOrigin: make(AddressSet, 250)},
+	}}
+	f, err := convertEventFilter(nil, filter)
+	assert.Nil(t, err)
+	assert.Len(t, f.CriteriaSet, 2)
+
+	for _, criteria := range []*EventCriteria{
+		{Address: make(AddressSet, 1)},
+		{TxID: newSet(1)},
+		{TxOrigin: make(AddressSet, 1)},
+		{TopicSet: TopicSet{Topic2: newSet(1)}},
+	} {
+		filter := &EventFilter{CriteriaSet: append(filter.CriteriaSet, criteria)}
+		_, err := convertEventFilter(nil, filter)
+		if assert.Error(t, err) {
+			assert.Equal(t, "criteriaSet: too many values", err.Error())
+			rec := httptest.NewRecorder()
+			utils.WrapHandlerFunc(func(http.ResponseWriter, *http.Request) error { return err })(rec, nil)
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+// Package graphql serves chain data over GraphQL, so that clients can fetch blocks, txs, receipts,
+// logs and account states in one request.
+//
+// Queries are accepted as HTTP POST with JSON body {query, operationName, variables}, or as GET with
+// the same query parameters. The schema in SDL is served at '/schema'. Only query operations are
+// supported, and introspection is not, except for '__typename'.
+//
+// Each resolved field costs 1, and fields that load data cost more (e.g. blocks, receipts, account
+// states and log queries). A query is aborted once its cost exceeds the limit, and the depth of a
+// query is limited too. The cost is reported in 'extensions' of the response.
+package graphql
@@ -0,0 +1,446 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/miniBamboo/workshare/workshare"
+	"github.com/pkg/errors"
+)
+
+const maxDepth = 12
+
+// Error is a GraphQL error, with the response path of the field where it occurred.
+type Error struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+// Response is the GraphQL response.
+type Response struct {
+	Data       interface{} `json:"data,omitempty"`
+	Errors     []*Error    `json:"errors,omitempty"`
+	Extensions *Extensions `json:"extensions,omitempty"`
+}
+
+// Extensions reports the cost of the query.
+type Extensions struct {
+	Cost      uint64 `json:"cost"`
+	CostLimit uint64 `json:"costLimit"`
+}
+
+// abortError stops the execution, e.g. the cost exceeds the limit.
+type abortError struct {
+	msg string
+}
+
+func (e *abortError) Error() string { return e.msg }
+
+// errNull tells the parent that a non-null value is null, and the error is already recorded.
+var errNull = errors.New("null")
+
+// request is the execution state of a query.
+type request struct {
+	ctx       context.Context
+	schema    *schema
+	doc       *document
+	vars      map[string]interface{}
+	cost      uint64
+	costLimit uint64
+	errors    []*Error
+	memos     map[interface{}]interface{}
+}
+
+// charge adds cost of the query and aborts if it exceeds the limit.
+func (r *request) charge(cost uint64) error {
+	if err := r.ctx.Err(); err != nil {
+		return &abortError{err.Error()}
+	}
+	r.cost += cost
+	if r.cost > r.costLimit {
+		return &abortError{fmt.Sprintf("query cost exceeds limit %d", r.costLimit)}
+	}
+	return nil
+}
+
+// memo returns the value cached with key, or loads and caches it.
+func (r *request) memo(key interface{}, load func() (interface{}, error)) (interface{}, error) {
+	if v, ok := r.memos[key]; ok {
+		return v, nil
+	}
+	v, err := load()
+	if err != nil {
+		return nil, err
+	}
+	r.memos[key] = v
+	return v, nil
+}
+
+func (r *request) addError(path []interface{}, msg string) {
+	r.errors = append(r.errors, &Error{
+		Message: msg,
+		Path:    append([]interface{}(nil), path...),
+	})
+}
+
+// execute runs the named operation of the query.
+func execute(ctx context.Context, s *schema, query, operationName string, vars map[string]interface{}, costLimit uint64) *Response {
+	doc, err := parse(query)
+	if err != nil {
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+	op, err := selectOperation(doc, operationName)
+	if err != nil {
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+	if err := validate(s, doc, op); err != nil {
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+	r := &request{
+		ctx:       ctx,
+		schema:    s,
+		doc:       doc,
+		costLimit: costLimit,
+		memos:     make(map[interface{}]interface{}),
+	}
+	if r.vars, err = r.coerceVars(op.vars, vars); err != nil {
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+
+	resp := &Response{Extensions: &Extensions{CostLimit: costLimit}}
+	data, err := r.executeSelections(s.query, nil, op.selections, nil)
+	switch err.(type) {
+	case nil:
+		resp.Data = data
+	case *abortError:
+		r.errors = append(r.errors, &Error{Message: err.Error()})
+	default:
+		resp.Data = json.RawMessage("null")
+	}
+	resp.Errors = r.errors
+	resp.Extensions.Cost = r.cost
+	return resp
+}
+
+func selectOperation(doc *document, name string) (*operation, error) {
+	if name == "" {
+		if len(doc.operations) > 1 {
+			return nil, errors.New("operation name is required for document with multiple operations")
+		}
+		return doc.operations[0], nil
+	}
+	for _, op := range doc.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown operation '%s'", name)
+}
+
+// coerceVars checks the given variables against the definitions, and fills in defaults.
+// Values are kept raw, and coerced again into argument types where used.
+func (r *request) coerceVars(defs []*varDef, input map[string]interface{}) (map[string]interface{}, error) {
+	vars := make(map[string]interface{})
+	for _, def := range defs {
+		raw, ok := input[def.name]
+		if !ok && def.def != nil {
+			raw, ok = r.rawValue(def.def)
+		}
+		if !ok && !def.typ.nonNull {
+			continue
+		}
+		if _, err := r.schema.coerce(def.typ, raw); err != nil {
+			return nil, errors.WithMessage(err, "variable $"+def.name)
+		}
+		vars[def.name] = raw
+	}
+	return vars, nil
+}
+
+// rawValue converts the literal into the raw form of decoded JSON. It returns false for unset variables.
+func (r *request) rawValue(v *value) (interface{}, bool) {
+	switch v.kind {
+	case varValue:
+		raw, ok := r.vars[v.raw]
+		return raw, ok
+	case intValue, floatValue:
+		return json.Number(v.raw), true
+	case stringValue:
+		return v.raw, true
+	case boolValue:
+		return v.raw == "true", true
+	case enumValue:
+		return enumLiteral(v.raw), true
+	case listValue:
+		list := make([]interface{}, 0, len(v.list))
+		for _, elem := range v.list {
+			raw, ok := r.rawValue(elem)
+			if !ok {
+				raw = nil
+			}
+			list = append(list, raw)
+		}
+		return list, true
+	case objectValue:
+		obj := make(map[string]interface{})
+		for _, f := range v.fields {
+			if raw, ok := r.rawValue(f.value); ok {
+				obj[f.name] = raw
+			}
+		}
+		return obj, true
+	}
+	return nil, true
+}
+
+// coerceArgs coerces the given arguments by the definitions.
+func (r *request) coerceArgs(defs []*argDef, args []*argument) (map[string]interface{}, error) {
+	out := make(map[string]interface{})
+	for _, def := range defs {
+		var (
+			raw interface{}
+			ok  bool
+		)
+		for _, arg := range args {
+			if arg.name == def.name {
+				raw, ok = r.rawValue(arg.value)
+				break
+			}
+		}
+		if !ok {
+			raw = def.def
+		}
+		v, err := r.schema.coerce(def.typ, raw)
+		if err != nil {
+			return nil, errors.WithMessage(err, "argument "+def.name)
+		}
+		if v != nil {
+			out[def.name] = v
+		}
+	}
+	return out, nil
+}
+
+// included evaluates @skip and @include.
+func (r *request) included(dirs []*directive) (bool, error) {
+	for _, dir := range dirs {
+		args, err := r.coerceArgs([]*argDef{newArg("if", "Boolean!")}, dir.args)
+		if err != nil {
+			return false, err
+		}
+		cond := args["if"].(bool)
+		if (dir.name == "skip" && cond) || (dir.name == "include" && !cond) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+type collectedField struct {
+	key    string
+	fields []*field
+}
+
+// collectFields flattens fragments of the selection set, and groups fields by response key.
+func (r *request) collectFields(t *objectType, sels []selection, collected []*collectedField, visited map[string]bool) ([]*collectedField, error) {
+	for _, sel := range sels {
+		switch sel := sel.(type) {
+		case *field:
+			if ok, err := r.included(sel.directives); err != nil || !ok {
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+			var found bool
+			for _, cf := range collected {
+				if cf.key == sel.key() {
+					if cf.fields[0].name != sel.name {
+						return nil, fmt.Errorf("fields '%s' and '%s' conflict on response key '%s'", cf.fields[0].name, sel.name, cf.key)
+					}
+					cf.fields = append(cf.fields, sel)
+					found = true
+					break
+				}
+			}
+			if !found {
+				collected = append(collected, &collectedField{sel.key(), []*field{sel}})
+			}
+		case *fragmentSpread:
+			if visited[sel.name] {
+				continue
+			}
+			visited[sel.name] = true
+			if ok, err := r.included(sel.directives); err != nil || !ok {
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+			frag := r.doc.fragments[sel.name]
+			var err error
+			if collected, err = r.collectFields(t, frag.selections, collected, visited); err != nil {
+				return nil, err
+			}
+		case *inlineFragment:
+			if ok, err := r.included(sel.directives); err != nil || !ok {
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+			var err error
+			if collected, err = r.collectFields(t, sel.selections, collected, visited); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return collected, nil
+}
+
+// executeSelections resolves the selection set on src of type t.
+func (r *request) executeSelections(t *objectType, src interface{}, sels []selection, path []interface{}) (*orderedMap, error) {
+	collected, err := r.collectFields(t, sels, nil, make(map[string]bool))
+	if err != nil {
+		return nil, &abortError{err.Error()}
+	}
+	out := &orderedMap{values: make(map[string]interface{})}
+	for _, cf := range collected {
+		fieldPath := append(path, cf.key)
+		f := cf.fields[0]
+		if f.name == "__typename" {
+			out.set(cf.key, t.name)
+			continue
+		}
+		def := t.index[f.name]
+		if err := r.charge(1 + def.cost); err != nil {
+			return nil, err
+		}
+		v, err := r.resolveField(def, src, f)
+		if err != nil {
+			if _, ok := err.(*abortError); ok {
+				return nil, err
+			}
+			r.addError(fieldPath, err.Error())
+			if def.typ.nonNull {
+				return nil, errNull
+			}
+			out.set(cf.key, nil)
+			continue
+		}
+		completed, err := r.completeValue(def.typ, cf.fields, v, fieldPath)
+		if err != nil {
+			return nil, err
+		}
+		out.set(cf.key, completed)
+	}
+	return out, nil
+}
+
+func (r *request) resolveField(def *fieldDef, src interface{}, f *field) (interface{}, error) {
+	args, err := r.coerceArgs(def.args, f.args)
+	if err != nil {
+		return nil, err
+	}
+	return def.resolve(r, src, args)
+}
+
+// completeValue converts the resolved value into the response value of type t.
+// It returns errNull if t is non-null but the value is null.
+func (r *request) completeValue(t *typeRef, fields []*field, v interface{}, path []interface{}) (interface{}, error) {
+	rv := reflect.ValueOf(v)
+	if v == nil || (rv.Kind() == reflect.Ptr && rv.IsNil()) {
+		if t.nonNull {
+			r.addError(path, "unexpected null value for "+t.String())
+			return nil, errNull
+		}
+		return nil, nil
+	}
+	var (
+		out interface{}
+		err error
+	)
+	if t.elem != nil {
+		if rv.Kind() != reflect.Slice {
+			return nil, &abortError{fmt.Sprintf("internal error: %v is not a list", t)}
+		}
+		list := make([]interface{}, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			var item interface{}
+			if item, err = r.completeValue(t.elem, fields, rv.Index(i).Interface(), append(path, i)); err != nil {
+				break
+			}
+			list = append(list, item)
+		}
+		out = list
+	} else if obj, ok := r.schema.types[t.name].(*objectType); ok {
+		var sels []selection
+		for _, f := range fields {
+			sels = append(sels, f.selections...)
+		}
+		out, err = r.executeSelections(obj, v, sels, path)
+	} else {
+		out = serializeScalar(v)
+	}
+	if err == errNull {
+		if t.nonNull {
+			return nil, errNull
+		}
+		return nil, nil
+	}
+	return out, err
+}
+
+// serializeScalar converts the scalar value into the form to be marshalled.
+// Address and Bytes32 implement json.Marshaler on pointer receivers, so they'd be marshalled as arrays by value.
+func serializeScalar(v interface{}) interface{} {
+	switch v := v.(type) {
+	case workshare.Address:
+		return v.String()
+	case workshare.Bytes32:
+		return v.String()
+	}
+	return v
+}
+
+// orderedMap is a JSON object keeping the order of keys, as GraphQL requires.
+type orderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (m *orderedMap) set(key string, value interface{}) {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+// MarshalJSON implements json.Marshaler.
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		buf.Write(k)
+		buf.WriteByte(':')
+		v, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
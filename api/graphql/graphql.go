@@ -0,0 +1,85 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package graphql
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/miniBamboo/workshare/api/utils"
+	"github.com/miniBamboo/workshare/chain"
+	"github.com/miniBamboo/workshare/logdb"
+	"github.com/miniBamboo/workshare/state"
+	"github.com/pkg/errors"
+)
+
+type GraphQL struct {
+	repo      *chain.Repository
+	stater    *state.Stater
+	logDB     *logdb.LogDB
+	costLimit uint64
+	schema    *schema
+}
+
+// New creates the GraphQL service. logDB can be nil if logs are not recorded.
+func New(repo *chain.Repository, stater *state.Stater, logDB *logdb.LogDB, costLimit uint64) *GraphQL {
+	g := &GraphQL{
+		repo:      repo,
+		stater:    stater,
+		logDB:     logDB,
+		costLimit: costLimit,
+	}
+	g.schema = g.buildSchema()
+	return g
+}
+
+// Query is the GraphQL request.
+type Query struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+func (g *GraphQL) handleQuery(w http.ResponseWriter, req *http.Request) error {
+	var query Query
+	if req.Method == "GET" {
+		values := req.URL.Query()
+		query.Query = values.Get("query")
+		query.OperationName = values.Get("operationName")
+		if vars := values.Get("variables"); vars != "" {
+			decoder := json.NewDecoder(strings.NewReader(vars))
+			decoder.UseNumber()
+			if err := decoder.Decode(&query.Variables); err != nil {
+				return utils.BadRequest(errors.WithMessage(err, "variables"))
+			}
+		}
+	} else {
+		decoder := json.NewDecoder(req.Body)
+		decoder.UseNumber()
+		if err := decoder.Decode(&query); err != nil {
+			return utils.BadRequest(errors.WithMessage(err, "body"))
+		}
+	}
+	if query.Query == "" {
+		return utils.BadRequest(errors.New("query: empty"))
+	}
+	return utils.WriteJSON(w, execute(req.Context(), g.schema, query.Query, query.OperationName, query.Variables, g.costLimit))
+}
+
+func (g *GraphQL) handleGetSchema(w http.ResponseWriter, req *http.Request) error {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, err := w.Write([]byte(g.schema.String()))
+	return err
+}
+
+func (g *GraphQL) Mount(root *mux.Router, pathPrefix string) {
+	sub := root.PathPrefix(pathPrefix).Subrouter()
+
+	sub.Path("").Methods("GET", "POST").HandlerFunc(utils.WrapHandlerFunc(g.handleQuery))
+	sub.Path("/schema").Methods("GET").HandlerFunc(utils.WrapHandlerFunc(g.handleGetSchema))
+}
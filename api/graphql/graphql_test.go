@@ -0,0 +1,258 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gorilla/mux"
+	"github.com/miniBamboo/workshare/block"
+	"github.com/miniBamboo/workshare/chain"
+	"github.com/miniBamboo/workshare/genesis"
+	"github.com/miniBamboo/workshare/logdb"
+	"github.com/miniBamboo/workshare/muxdb"
+	"github.com/miniBamboo/workshare/packer"
+	"github.com/miniBamboo/workshare/state"
+	"github.com/miniBamboo/workshare/tx"
+	"github.com/miniBamboo/workshare/workshare"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	ts  *httptest.Server
+	blk *block.Block
+	to  = workshare.BytesToAddress([]byte("to"))
+)
+
+type result struct {
+	Data       json.RawMessage `json:"data"`
+	Errors     []*Error        `json:"errors"`
+	Extensions *Extensions     `json:"extensions"`
+}
+
+func TestBlockQuery(t *testing.T) {
+	initGraphQLServer(t, 20000)
+	defer ts.Close()
+
+	res := query(t, `query ($n: String) {
+		best: block { number }
+		b: block(revision: $n) {
+			id
+			...header
+			transactions { id origin clauses { to value } receipt { reverted outputs { transfers { recipient amount meta { txID clauseIndex } } } } }
+			parent { number __typename }
+		}
+		missing: block(revision: "100") { id }
+	}
+	fragment header on Block { number isTrunk }`, map[string]interface{}{"n": "1"})
+	assert.Empty(t, res.Errors)
+
+	var data struct {
+		Best    struct{ Number uint32 }
+		B       map[string]interface{}
+		Missing *struct{}
+	}
+	assert.Nil(t, json.Unmarshal(res.Data, &data))
+	assert.Equal(t, uint32(1), data.Best.Number)
+	assert.Nil(t, data.Missing)
+	assert.Equal(t, blk.Header().ID().String(), data.B["id"])
+	assert.Equal(t, true, data.B["isTrunk"])
+	assert.Equal(t, map[string]interface{}{"number": float64(0), "__typename": "Block"}, data.B["parent"])
+
+	txs := data.B["transactions"].([]interface{})
+	assert.Equal(t, 1, len(txs))
+	trx := txs[0].(map[string]interface{})
+	assert.Equal(t, blk.Transactions()[0].ID().String(), trx["id"])
+	assert.Equal(t, genesis.DevAccounts()[0].Address.String(), trx["origin"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"to": to.String(), "value": "0x2710"}}, trx["clauses"])
+	transfers := trx["receipt"].(map[string]interface{})["outputs"].([]interface{})[0].(map[string]interface{})["transfers"].([]interface{})
+	assert.Equal(t, map[string]interface{}{
+		"recipient": to.String(),
+		"amount":    "0x2710",
+		"meta":      map[string]interface{}{"txID": trx["id"], "clauseIndex": float64(0)},
+	}, transfers[0])
+
+	// keys are in the order of the query
+	assert.True(t, strings.Index(string(res.Data), `"best"`) < strings.Index(string(res.Data), `"b"`))
+}
+
+func TestAccountAndLogs(t *testing.T) {
+	initGraphQLServer(t, 20000)
+	defer ts.Close()
+
+	res := query(t, `{
+		now: account(address: "`+to.String()+`") { balance hasCode }
+		before: account(address: "`+to.String()+`", revision: "0") { balance }
+		transfers(criteriaSet: [{recipient: "`+to.String()+`"}], limit: 10) { sender amount cursor block { number } transaction { id } }
+	}`, nil)
+	assert.Empty(t, res.Errors)
+
+	var data struct {
+		Now struct {
+			Balance string
+			HasCode bool
+		}
+		Before    struct{ Balance string }
+		Transfers []struct {
+			Sender      workshare.Address
+			Amount      string
+			Cursor      string
+			Block       struct{ Number uint32 }
+			Transaction struct{ ID workshare.Bytes32 }
+		}
+	}
+	assert.Nil(t, json.Unmarshal(res.Data, &data))
+	assert.Equal(t, "0x2710", data.Now.Balance)
+	assert.False(t, data.Now.HasCode)
+	assert.Equal(t, "0x0", data.Before.Balance)
+	assert.Equal(t, 1, len(data.Transfers))
+	assert.Equal(t, genesis.DevAccounts()[0].Address, data.Transfers[0].Sender)
+	assert.Equal(t, uint32(1), data.Transfers[0].Block.Number)
+	assert.Equal(t, blk.Transactions()[0].ID(), data.Transfers[0].Transaction.ID)
+
+	// the cursor of the filtered transfer continues to the end
+	res = query(t, `query ($c: String) { transfers(limit: 10, cursor: $c) { amount } }`, map[string]interface{}{"c": data.Transfers[0].Cursor})
+	assert.Empty(t, res.Errors)
+	assert.Equal(t, `{"transfers":[]}`, string(res.Data))
+}
+
+func TestQueryErrors(t *testing.T) {
+	initGraphQLServer(t, 100)
+	defer ts.Close()
+
+	tests := []struct {
+		query string
+		err   string
+	}{
+		{`{ block { foo } }`, "cannot query field 'foo' on type 'Block'"},
+		{`{ block }`, "field 'block' of type 'Block' must have a selection of subfields"},
+		{`{ account { balance } }`, "argument 'address' of type 'Address!' is required on field 'account'"},
+		{`{ block(rev: "1") { id } }`, "unknown argument 'rev' on field 'block'"},
+		{`{ block(revision: $r) { id } }`, "variable $r is not defined"},
+		{`{ block { ...f } } fragment f on Block { parent { ...f } }`, "fragment 'f' spreads itself"},
+		{`mutation { block { id } }`, "mutation operations are not supported"},
+		{`{ block { id }`, "syntax error at 1:15: unexpected end of document"},
+		{`{ events(limit: 1000) { address } }`, "query cost exceeds limit 100"},
+	}
+	for _, tt := range tests {
+		res := query(t, tt.query, nil)
+		if assert.Equal(t, 1, len(res.Errors), tt.query) {
+			assert.Equal(t, tt.err, res.Errors[0].Message, tt.query)
+		}
+	}
+
+	// field errors are reported with path, leaving other fields resolved
+	res := query(t, `{ a: block { number } b: block(revision: "0xzz") { number } }`, nil)
+	assert.Equal(t, `{"a":{"number":1},"b":null}`, string(res.Data))
+	assert.Equal(t, []interface{}{"b"}, res.Errors[0].Path)
+	assert.True(t, res.Extensions.Cost > 0)
+}
+
+func TestSchema(t *testing.T) {
+	initGraphQLServer(t, 20000)
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL + "/graphql/schema")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, _ := ioutil.ReadAll(res.Body)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Contains(t, string(body), "account(address: Address!, revision: String): Account\n")
+	assert.Contains(t, string(body), "events(criteriaSet: [EventCriteria!], range: Range, order: Order = ASC, limit: Int!, offset: Int, cursor: String): [Event!]!\n")
+}
+
+func query(t *testing.T, q string, vars map[string]interface{}) *result {
+	data, _ := json.Marshal(&Query{Query: q, Variables: vars})
+	res, err := http.Post(ts.URL+"/graphql", "application/json", bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	var r result
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		t.Fatal(err)
+	}
+	return &r
+}
+
+func initGraphQLServer(t *testing.T, costLimit uint64) {
+	db := muxdb.NewMem()
+	stater := state.NewStater(db)
+	gene := genesis.NewDevnet()
+
+	b, _, _, err := gene.Build(stater)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, _ := chain.NewRepository(db, b)
+	cla := tx.NewClause(&to).WithValue(big.NewInt(10000))
+	trx := new(tx.Builder).
+		ChainTag(repo.ChainTag()).
+		GasPriceCoef(1).
+		Expiration(10).
+		Gas(21000).
+		Nonce(1).
+		Clause(cla).
+		BlockRef(tx.NewBlockRef(0)).
+		Build()
+
+	sig, err := crypto.Sign(trx.SigningHash().Bytes(), genesis.DevAccounts()[0].PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trx = trx.WithSignature(sig)
+	packer := packer.New(repo, stater, genesis.DevAccounts()[0].Address, &genesis.DevAccounts()[0].Address, workshare.NoFork)
+	sum, _ := repo.GetBlockSummary(b.Header().ID())
+	flow, err := packer.Schedule(sum, uint64(time.Now().Unix()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := flow.Adopt(trx); err != nil {
+		t.Fatal(err)
+	}
+	block, stage, receipts, err := flow.Pack(genesis.DevAccounts()[0].PrivateKey, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stage.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.AddBlock(block, receipts, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.SetBestBlockID(block.Header().ID()); err != nil {
+		t.Fatal(err)
+	}
+
+	logDB, err := logdb.NewMem()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := logDB.NewWriter()
+	if err := w.Write(block, receipts); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	router := mux.NewRouter()
+	New(repo, stater, logDB, costLimit).Mount(router, "/graphql")
+	ts = httptest.NewServer(router)
+	blk = block
+}
@@ -0,0 +1,740 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const maxQuerySize = 64 * 1024
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokPunct
+	tokName
+	tokInt
+	tokFloat
+	tokString
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+// lexer splits a GraphQL document into tokens. Commas are insignificant as the spec defines.
+type lexer struct {
+	src string
+	pos int
+}
+
+func (l *lexer) errorf(pos int, format string, args ...interface{}) error {
+	line, col := 1, 1
+	for _, c := range l.src[:pos] {
+		if c == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+	}
+	return fmt.Errorf("syntax error at %d:%d: %s", line, col, fmt.Sprintf(format, args...))
+}
+
+func (l *lexer) skipIgnored() {
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; c {
+		case ' ', '\t', '\n', '\r', ',':
+			l.pos++
+		case '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' && l.src[l.pos] != '\r' {
+				l.pos++
+			}
+		default:
+			if strings.HasPrefix(l.src[l.pos:], "\uFEFF") {
+				l.pos += len("\uFEFF")
+				continue
+			}
+			return
+		}
+	}
+}
+
+func (l *lexer) next() (token, error) {
+	l.skipIgnored()
+	start := l.pos
+	if l.pos >= len(l.src) {
+		return token{tokEOF, "", start}, nil
+	}
+	c := l.src[l.pos]
+	switch {
+	case strings.IndexByte("!$()[]{}:=@|&", c) >= 0:
+		l.pos++
+		return token{tokPunct, string(c), start}, nil
+	case c == '.':
+		if strings.HasPrefix(l.src[l.pos:], "...") {
+			l.pos += 3
+			return token{tokPunct, "...", start}, nil
+		}
+		return token{}, l.errorf(start, "unexpected '.'")
+	case c == '_' || isLetter(c):
+		for l.pos < len(l.src) && (l.src[l.pos] == '_' || isLetter(l.src[l.pos]) || isDigit(l.src[l.pos])) {
+			l.pos++
+		}
+		return token{tokName, l.src[start:l.pos], start}, nil
+	case c == '-' || isDigit(c):
+		return l.number()
+	case c == '"':
+		if strings.HasPrefix(l.src[l.pos:], `"""`) {
+			return l.blockString()
+		}
+		return l.string()
+	}
+	return token{}, l.errorf(start, "unexpected character %q", c)
+}
+
+func (l *lexer) number() (token, error) {
+	start := l.pos
+	kind := tokInt
+	if l.src[l.pos] == '-' {
+		l.pos++
+	}
+	digits := func() error {
+		if l.pos >= len(l.src) || !isDigit(l.src[l.pos]) {
+			return l.errorf(l.pos, "invalid number")
+		}
+		for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+			l.pos++
+		}
+		return nil
+	}
+	if err := digits(); err != nil {
+		return token{}, err
+	}
+	if l.pos < len(l.src) && l.src[l.pos] == '.' {
+		kind = tokFloat
+		l.pos++
+		if err := digits(); err != nil {
+			return token{}, err
+		}
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+		kind = tokFloat
+		l.pos++
+		if l.pos < len(l.src) && (l.src[l.pos] == '+' || l.src[l.pos] == '-') {
+			l.pos++
+		}
+		if err := digits(); err != nil {
+			return token{}, err
+		}
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == '_' || isLetter(l.src[l.pos]) || l.src[l.pos] == '.') {
+		return token{}, l.errorf(l.pos, "invalid number")
+	}
+	return token{kind, l.src[start:l.pos], start}, nil
+}
+
+func (l *lexer) string() (token, error) {
+	start := l.pos
+	l.pos++
+	var sb strings.Builder
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '"':
+			l.pos++
+			return token{tokString, sb.String(), start}, nil
+		case c == '\n' || c == '\r':
+			return token{}, l.errorf(l.pos, "unterminated string")
+		case c == '\\':
+			if l.pos+1 >= len(l.src) {
+				return token{}, l.errorf(l.pos, "unterminated string")
+			}
+			esc := l.src[l.pos+1]
+			l.pos += 2
+			switch esc {
+			case '"', '\\', '/':
+				sb.WriteByte(esc)
+			case 'b':
+				sb.WriteByte('\b')
+			case 'f':
+				sb.WriteByte('\f')
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			case 'u':
+				if l.pos+4 > len(l.src) {
+					return token{}, l.errorf(l.pos, "invalid unicode escape")
+				}
+				r, err := strconv.ParseUint(l.src[l.pos:l.pos+4], 16, 32)
+				if err != nil {
+					return token{}, l.errorf(l.pos, "invalid unicode escape")
+				}
+				sb.WriteRune(rune(r))
+				l.pos += 4
+			default:
+				return token{}, l.errorf(l.pos-1, "invalid escape '\\%c'", esc)
+			}
+		default:
+			r, size := utf8.DecodeRuneInString(l.src[l.pos:])
+			sb.WriteRune(r)
+			l.pos += size
+		}
+	}
+	return token{}, l.errorf(start, "unterminated string")
+}
+
+// blockString reads a """ string. Common indentation is not stripped, which only matters for descriptions.
+func (l *lexer) blockString() (token, error) {
+	start := l.pos
+	l.pos += 3
+	end := strings.Index(l.src[l.pos:], `"""`)
+	if end < 0 {
+		return token{}, l.errorf(start, "unterminated string")
+	}
+	value := strings.Replace(l.src[l.pos:l.pos+end], `\"""`, `"""`, -1)
+	l.pos += end + 3
+	return token{tokString, value, start}, nil
+}
+
+func isLetter(c byte) bool { return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') }
+func isDigit(c byte) bool  { return c >= '0' && c <= '9' }
+
+// document is a parsed executable GraphQL document.
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+type operation struct {
+	kind       string // query, mutation or subscription
+	name       string
+	vars       []*varDef
+	directives []*directive
+	selections []selection
+}
+
+type varDef struct {
+	name string
+	typ  *typeRef
+	def  *value
+}
+
+// selection is one of *field, *fragmentSpread and *inlineFragment.
+type selection interface{}
+
+type field struct {
+	alias      string
+	name       string
+	args       []*argument
+	directives []*directive
+	selections []selection
+}
+
+// key returns the response key of the field.
+func (f *field) key() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+type fragmentSpread struct {
+	name       string
+	directives []*directive
+}
+
+type inlineFragment struct {
+	on         string // empty if no type condition
+	directives []*directive
+	selections []selection
+}
+
+type fragment struct {
+	name       string
+	on         string
+	directives []*directive
+	selections []selection
+}
+
+type argument struct {
+	name  string
+	value *value
+}
+
+type directive struct {
+	name string
+	args []*argument
+}
+
+type valueKind int
+
+const (
+	varValue valueKind = iota
+	intValue
+	floatValue
+	stringValue
+	boolValue
+	nullValue
+	enumValue
+	listValue
+	objectValue
+)
+
+type value struct {
+	kind   valueKind
+	raw    string // variable name, or the literal of scalars and enums
+	list   []*value
+	fields []*argument
+}
+
+// typeRef references a named type, or a list of elem.
+type typeRef struct {
+	name    string
+	elem    *typeRef
+	nonNull bool
+}
+
+func (t *typeRef) String() string {
+	s := t.name
+	if t.elem != nil {
+		s = "[" + t.elem.String() + "]"
+	}
+	if t.nonNull {
+		s += "!"
+	}
+	return s
+}
+
+// mustParseType parses a type reference like '[Event!]!', which is used to declare the schema.
+func mustParseType(s string) *typeRef {
+	p := &parser{lexer: lexer{src: s}}
+	t, err := func() (*typeRef, error) {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		t, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokEOF {
+			return nil, p.unexpected()
+		}
+		return t, nil
+	}()
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+type parser struct {
+	lexer
+	tok token
+}
+
+// parse parses an executable document. Type system definitions are not accepted.
+func parse(src string) (*document, error) {
+	if len(src) > maxQuerySize {
+		return nil, fmt.Errorf("query too large, want at most %d bytes", maxQuerySize)
+	}
+	p := &parser{lexer: lexer{src: src}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	doc := &document{fragments: make(map[string]*fragment)}
+	for p.tok.kind != tokEOF {
+		switch {
+		case p.peek(tokPunct, "{"):
+			sels, err := p.parseSelectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, &operation{kind: "query", selections: sels})
+		case p.peek(tokName, "query"), p.peek(tokName, "mutation"), p.peek(tokName, "subscription"):
+			op, err := p.parseOperation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		case p.peek(tokName, "fragment"):
+			frag, err := p.parseFragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := doc.fragments[frag.name]; ok {
+				return nil, fmt.Errorf("duplicated fragment '%s'", frag.name)
+			}
+			doc.fragments[frag.name] = frag
+		default:
+			return nil, p.unexpected()
+		}
+	}
+	if len(doc.operations) == 0 {
+		return nil, fmt.Errorf("no operation in document")
+	}
+	return doc, nil
+}
+
+func (p *parser) advance() (err error) {
+	p.tok, err = p.lexer.next()
+	return
+}
+
+func (p *parser) peek(kind tokenKind, value string) bool {
+	return p.tok.kind == kind && p.tok.value == value
+}
+
+func (p *parser) unexpected() error {
+	if p.tok.kind == tokEOF {
+		return p.errorf(p.tok.pos, "unexpected end of document")
+	}
+	return p.errorf(p.tok.pos, "unexpected %q", p.tok.value)
+}
+
+func (p *parser) expect(kind tokenKind, value string) error {
+	if !p.peek(kind, value) {
+		return p.unexpected()
+	}
+	return p.advance()
+}
+
+// skip consumes the punctuator if present.
+func (p *parser) skip(value string) (bool, error) {
+	if !p.peek(tokPunct, value) {
+		return false, nil
+	}
+	return true, p.advance()
+}
+
+func (p *parser) parseName() (string, error) {
+	if p.tok.kind != tokName {
+		return "", p.unexpected()
+	}
+	name := p.tok.value
+	return name, p.advance()
+}
+
+func (p *parser) parseOperation() (*operation, error) {
+	op := &operation{kind: p.tok.value}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	var err error
+	if p.tok.kind == tokName {
+		if op.name, err = p.parseName(); err != nil {
+			return nil, err
+		}
+	}
+	if ok, err := p.skip("("); err != nil {
+		return nil, err
+	} else if ok {
+		for !p.peek(tokPunct, ")") {
+			v, err := p.parseVarDef()
+			if err != nil {
+				return nil, err
+			}
+			op.vars = append(op.vars, v)
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+	if op.directives, err = p.parseDirectives(); err != nil {
+		return nil, err
+	}
+	if op.selections, err = p.parseSelectionSet(); err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+func (p *parser) parseVarDef() (*varDef, error) {
+	if err := p.expect(tokPunct, "$"); err != nil {
+		return nil, err
+	}
+	name, err := p.parseName()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(tokPunct, ":"); err != nil {
+		return nil, err
+	}
+	typ, err := p.parseType()
+	if err != nil {
+		return nil, err
+	}
+	v := &varDef{name: name, typ: typ}
+	if ok, err := p.skip("="); err != nil {
+		return nil, err
+	} else if ok {
+		if v.def, err = p.parseValue(true); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+func (p *parser) parseType() (*typeRef, error) {
+	var (
+		t   *typeRef
+		err error
+	)
+	if ok, err := p.skip("["); err != nil {
+		return nil, err
+	} else if ok {
+		elem, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokPunct, "]"); err != nil {
+			return nil, err
+		}
+		t = &typeRef{elem: elem}
+	} else {
+		t = &typeRef{}
+		if t.name, err = p.parseName(); err != nil {
+			return nil, err
+		}
+	}
+	if t.nonNull, err = p.skip("!"); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (p *parser) parseFragment() (*fragment, error) {
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	frag := &fragment{}
+	var err error
+	if frag.name, err = p.parseName(); err != nil {
+		return nil, err
+	}
+	if frag.name == "on" {
+		return nil, p.errorf(p.tok.pos, "invalid fragment name 'on'")
+	}
+	if err := p.expect(tokName, "on"); err != nil {
+		return nil, err
+	}
+	if frag.on, err = p.parseName(); err != nil {
+		return nil, err
+	}
+	if frag.directives, err = p.parseDirectives(); err != nil {
+		return nil, err
+	}
+	if frag.selections, err = p.parseSelectionSet(); err != nil {
+		return nil, err
+	}
+	return frag, nil
+}
+
+func (p *parser) parseSelectionSet() ([]selection, error) {
+	if err := p.expect(tokPunct, "{"); err != nil {
+		return nil, err
+	}
+	var sels []selection
+	for !p.peek(tokPunct, "}") {
+		sel, err := p.parseSelection()
+		if err != nil {
+			return nil, err
+		}
+		sels = append(sels, sel)
+	}
+	if len(sels) == 0 {
+		return nil, p.errorf(p.tok.pos, "empty selection set")
+	}
+	return sels, p.advance()
+}
+
+func (p *parser) parseSelection() (selection, error) {
+	var err error
+	if ok, err := p.skip("..."); err != nil {
+		return nil, err
+	} else if ok {
+		if p.tok.kind == tokName && p.tok.value != "on" {
+			spread := &fragmentSpread{}
+			if spread.name, err = p.parseName(); err != nil {
+				return nil, err
+			}
+			if spread.directives, err = p.parseDirectives(); err != nil {
+				return nil, err
+			}
+			return spread, nil
+		}
+		inline := &inlineFragment{}
+		if p.peek(tokName, "on") {
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			if inline.on, err = p.parseName(); err != nil {
+				return nil, err
+			}
+		}
+		if inline.directives, err = p.parseDirectives(); err != nil {
+			return nil, err
+		}
+		if inline.selections, err = p.parseSelectionSet(); err != nil {
+			return nil, err
+		}
+		return inline, nil
+	}
+
+	f := &field{}
+	if f.name, err = p.parseName(); err != nil {
+		return nil, err
+	}
+	if ok, err := p.skip(":"); err != nil {
+		return nil, err
+	} else if ok {
+		f.alias = f.name
+		if f.name, err = p.parseName(); err != nil {
+			return nil, err
+		}
+	}
+	if f.args, err = p.parseArguments(false); err != nil {
+		return nil, err
+	}
+	if f.directives, err = p.parseDirectives(); err != nil {
+		return nil, err
+	}
+	if p.peek(tokPunct, "{") {
+		if f.selections, err = p.parseSelectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+func (p *parser) parseArguments(isConst bool) ([]*argument, error) {
+	if ok, err := p.skip("("); err != nil || !ok {
+		return nil, err
+	}
+	var args []*argument
+	for !p.peek(tokPunct, ")") {
+		arg, err := p.parseArgument(isConst)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range args {
+			if a.name == arg.name {
+				return nil, fmt.Errorf("duplicated argument '%s'", arg.name)
+			}
+		}
+		args = append(args, arg)
+	}
+	if len(args) == 0 {
+		return nil, p.errorf(p.tok.pos, "empty arguments")
+	}
+	return args, p.advance()
+}
+
+func (p *parser) parseArgument(isConst bool) (*argument, error) {
+	name, err := p.parseName()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(tokPunct, ":"); err != nil {
+		return nil, err
+	}
+	v, err := p.parseValue(isConst)
+	if err != nil {
+		return nil, err
+	}
+	return &argument{name, v}, nil
+}
+
+func (p *parser) parseDirectives() ([]*directive, error) {
+	var dirs []*directive
+	for p.peek(tokPunct, "@") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.parseName()
+		if err != nil {
+			return nil, err
+		}
+		args, err := p.parseArguments(false)
+		if err != nil {
+			return nil, err
+		}
+		dirs = append(dirs, &directive{name, args})
+	}
+	return dirs, nil
+}
+
+func (p *parser) parseValue(isConst bool) (*value, error) {
+	tok := p.tok
+	switch tok.kind {
+	case tokInt:
+		return &value{kind: intValue, raw: tok.value}, p.advance()
+	case tokFloat:
+		return &value{kind: floatValue, raw: tok.value}, p.advance()
+	case tokString:
+		return &value{kind: stringValue, raw: tok.value}, p.advance()
+	case tokName:
+		v := &value{kind: enumValue, raw: tok.value}
+		switch tok.value {
+		case "true", "false":
+			v.kind = boolValue
+		case "null":
+			v.kind = nullValue
+		}
+		return v, p.advance()
+	case tokPunct:
+		switch tok.value {
+		case "$":
+			if isConst {
+				return nil, p.errorf(tok.pos, "unexpected variable")
+			}
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			name, err := p.parseName()
+			if err != nil {
+				return nil, err
+			}
+			return &value{kind: varValue, raw: name}, nil
+		case "[":
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			v := &value{kind: listValue, list: []*value{}}
+			for !p.peek(tokPunct, "]") {
+				elem, err := p.parseValue(isConst)
+				if err != nil {
+					return nil, err
+				}
+				v.list = append(v.list, elem)
+			}
+			return v, p.advance()
+		case "{":
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			v := &value{kind: objectValue, fields: []*argument{}}
+			for !p.peek(tokPunct, "}") {
+				f, err := p.parseArgument(isConst)
+				if err != nil {
+					return nil, err
+				}
+				v.fields = append(v.fields, f)
+			}
+			return v, p.advance()
+		}
+	}
+	return nil, p.unexpected()
+}
@@ -0,0 +1,694 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package graphql
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/miniBamboo/workshare/api/events"
+	"github.com/miniBamboo/workshare/block"
+	"github.com/miniBamboo/workshare/chain"
+	"github.com/miniBamboo/workshare/logdb"
+	"github.com/miniBamboo/workshare/state"
+	"github.com/miniBamboo/workshare/tx"
+	"github.com/miniBamboo/workshare/workshare"
+)
+
+// costs on top of the base cost 1 of each field
+const (
+	blockCost    = 10  // to load a block, its txs or receipts
+	stateCost    = 10  // to read the state of an account
+	logQueryCost = 100 // to query logs, plus the limit
+	maxLogLimit  = 1000
+)
+
+type txSource struct {
+	tx      *tx.Transaction
+	summary *chain.BlockSummary
+	index   int
+}
+
+type receiptSource struct {
+	tx       *txSource
+	receipts tx.Receipts
+}
+
+type outputSource struct {
+	tx          *txSource
+	output      *tx.Output
+	clauseIndex int
+	events      []*logdb.Event
+	transfers   []*logdb.Transfer
+}
+
+type accountSource struct {
+	address workshare.Address
+	summary *chain.BlockSummary
+	state   *state.State
+}
+
+// memo keys
+type (
+	txsKey      workshare.Bytes32
+	receiptsKey workshare.Bytes32
+)
+
+func (g *GraphQL) buildSchema() *schema {
+	headerField := func(name, typ string, get func(h *block.Header) interface{}) *fieldDef {
+		return newField(name, typ, func(r *request, src interface{}, args map[string]interface{}) (interface{}, error) {
+			return get(src.(*chain.BlockSummary).Header), nil
+		})
+	}
+	txField := func(name, typ string, get func(t *tx.Transaction) interface{}) *fieldDef {
+		return newField(name, typ, func(r *request, src interface{}, args map[string]interface{}) (interface{}, error) {
+			return get(src.(*txSource).tx), nil
+		})
+	}
+	receiptField := func(name, typ string, get func(receipt *tx.Receipt) interface{}) *fieldDef {
+		return newField(name, typ, func(r *request, src interface{}, args map[string]interface{}) (interface{}, error) {
+			s := src.(*receiptSource)
+			return get(s.receipts[s.tx.index]), nil
+		})
+	}
+	clauseField := func(name, typ string, get func(c *tx.Clause) interface{}) *fieldDef {
+		return newField(name, typ, func(r *request, src interface{}, args map[string]interface{}) (interface{}, error) {
+			return get(src.(*tx.Clause)), nil
+		})
+	}
+	eventField := func(name, typ string, get func(e *logdb.Event) interface{}) *fieldDef {
+		return newField(name, typ, func(r *request, src interface{}, args map[string]interface{}) (interface{}, error) {
+			return get(src.(*logdb.Event)), nil
+		})
+	}
+	transferField := func(name, typ string, get func(t *logdb.Transfer) interface{}) *fieldDef {
+		return newField(name, typ, func(r *request, src interface{}, args map[string]interface{}) (interface{}, error) {
+			return get(src.(*logdb.Transfer)), nil
+		})
+	}
+	metaField := func(name, typ string, get func(m *events.LogMeta) interface{}) *fieldDef {
+		return newField(name, typ, func(r *request, src interface{}, args map[string]interface{}) (interface{}, error) {
+			return get(src.(*events.LogMeta)), nil
+		})
+	}
+	stateField := func(name, typ string, get func(acc *accountSource, args map[string]interface{}) (interface{}, error), args ...*argDef) *fieldDef {
+		return newField(name, typ, func(r *request, src interface{}, args map[string]interface{}) (interface{}, error) {
+			return get(src.(*accountSource), args)
+		}, args...).withCost(stateCost)
+	}
+
+	blockType := newObject("Block",
+		headerField("id", "Bytes32!", func(h *block.Header) interface{} { return h.ID() }),
+		headerField("number", "Int!", func(h *block.Header) interface{} { return h.Number() }),
+		newField("size", "Int!", func(r *request, src interface{}, args map[string]interface{}) (interface{}, error) {
+			return uint32(src.(*chain.BlockSummary).Size), nil
+		}),
+		headerField("parentID", "Bytes32!", func(h *block.Header) interface{} { return h.ParentID() }),
+		newField("parent", "Block", g.resolveParent).withCost(blockCost),
+		headerField("timestamp", "Long!", func(h *block.Header) interface{} { return h.Timestamp() }),
+		headerField("gasLimit", "Long!", func(h *block.Header) interface{} { return h.GasLimit() }),
+		headerField("beneficiary", "Address!", func(h *block.Header) interface{} { return h.Beneficiary() }),
+		headerField("gasUsed", "Long!", func(h *block.Header) interface{} { return h.GasUsed() }),
+		headerField("totalScore", "Long!", func(h *block.Header) interface{} { return h.TotalScore() }),
+		headerField("txsRoot", "Bytes32!", func(h *block.Header) interface{} { return h.TxsRoot() }),
+		headerField("txsFeatures", "Int!", func(h *block.Header) interface{} { return uint32(h.TxsFeatures()) }),
+		headerField("stateRoot", "Bytes32!", func(h *block.Header) interface{} { return h.StateRoot() }),
+		headerField("receiptsRoot", "Bytes32!", func(h *block.Header) interface{} { return h.ReceiptsRoot() }),
+		headerField("signer", "Address!", func(h *block.Header) interface{} {
+			signer, _ := h.Signer()
+			return signer
+		}),
+		newField("isTrunk", "Boolean!", g.resolveIsTrunk),
+		newField("transactions", "[Transaction!]!", g.resolveBlockTransactions),
+		newField("account", "Account!", func(r *request, src interface{}, args map[string]interface{}) (interface{}, error) {
+			return g.newAccount(args["address"].(workshare.Address), src.(*chain.BlockSummary)), nil
+		}, newArg("address", "Address!")),
+	)
+
+	transactionType := newObject("Transaction",
+		txField("id", "Bytes32!", func(t *tx.Transaction) interface{} { return t.ID() }),
+		txField("chainTag", "Int!", func(t *tx.Transaction) interface{} { return t.ChainTag() }),
+		txField("blockRef", "String!", func(t *tx.Transaction) interface{} {
+			ref := t.BlockRef()
+			return hexutil.Encode(ref[:])
+		}),
+		txField("expiration", "Int!", func(t *tx.Transaction) interface{} { return t.Expiration() }),
+		txField("clauses", "[Clause!]!", func(t *tx.Transaction) interface{} { return t.Clauses() }),
+		txField("gasPriceCoef", "Int!", func(t *tx.Transaction) interface{} { return t.GasPriceCoef() }),
+		txField("gas", "Long!", func(t *tx.Transaction) interface{} { return t.Gas() }),
+		txField("origin", "Address!", func(t *tx.Transaction) interface{} {
+			origin, _ := t.Origin()
+			return origin
+		}),
+		txField("delegator", "Address", func(t *tx.Transaction) interface{} {
+			delegator, _ := t.Delegator()
+			return delegator
+		}),
+		txField("nonce", "String!", func(t *tx.Transaction) interface{} { return math.HexOrDecimal64(t.Nonce()) }),
+		txField("dependsOn", "Bytes32", func(t *tx.Transaction) interface{} { return t.DependsOn() }),
+		txField("size", "Int!", func(t *tx.Transaction) interface{} { return uint32(t.Size()) }),
+		newField("block", "Block!", func(r *request, src interface{}, args map[string]interface{}) (interface{}, error) {
+			return src.(*txSource).summary, nil
+		}),
+		newField("index", "Int!", func(r *request, src interface{}, args map[string]interface{}) (interface{}, error) {
+			return src.(*txSource).index, nil
+		}),
+		newField("receipt", "Receipt!", g.resolveReceipt),
+	)
+
+	clauseType := newObject("Clause",
+		clauseField("to", "Address", func(c *tx.Clause) interface{} { return c.To() }),
+		clauseField("value", "BigInt!", func(c *tx.Clause) interface{} { return (*math.HexOrDecimal256)(c.Value()) }),
+		clauseField("data", "Bytes!", func(c *tx.Clause) interface{} { return hexutil.Bytes(c.Data()) }),
+	)
+
+	receiptType := newObject("Receipt",
+		receiptField("gasUsed", "Long!", func(receipt *tx.Receipt) interface{} { return receipt.GasUsed }),
+		receiptField("gasPayer", "Address!", func(receipt *tx.Receipt) interface{} { return receipt.GasPayer }),
+		receiptField("paid", "BigInt!", func(receipt *tx.Receipt) interface{} { return (*math.HexOrDecimal256)(receipt.Paid) }),
+		receiptField("reward", "BigInt!", func(receipt *tx.Receipt) interface{} { return (*math.HexOrDecimal256)(receipt.Reward) }),
+		receiptField("reverted", "Boolean!", func(receipt *tx.Receipt) interface{} { return receipt.Reverted }),
+		newField("outputs", "[Output!]!", resolveOutputs),
+	)
+
+	outputType := newObject("Output",
+		newField("contractAddress", "Address", func(r *request, src interface{}, args map[string]interface{}) (interface{}, error) {
+			o := src.(*outputSource)
+			if o.tx.tx.Clauses()[o.clauseIndex].To() != nil {
+				return nil, nil
+			}
+			addr := workshare.CreateContractAddress(o.tx.tx.ID(), uint32(o.clauseIndex), 0)
+			return &addr, nil
+		}),
+		newField("events", "[Event!]!", func(r *request, src interface{}, args map[string]interface{}) (interface{}, error) {
+			return src.(*outputSource).events, nil
+		}),
+		newField("transfers", "[Transfer!]!", func(r *request, src interface{}, args map[string]interface{}) (interface{}, error) {
+			return src.(*outputSource).transfers, nil
+		}),
+	)
+
+	eventType := newObject("Event",
+		eventField("address", "Address!", func(e *logdb.Event) interface{} { return e.Address }),
+		eventField("topics", "[Bytes32!]!", func(e *logdb.Event) interface{} {
+			topics := make([]workshare.Bytes32, 0, len(e.Topics))
+			for _, topic := range e.Topics {
+				if topic != nil {
+					topics = append(topics, *topic)
+				}
+			}
+			return topics
+		}),
+		eventField("data", "Bytes!", func(e *logdb.Event) interface{} { return hexutil.Bytes(e.Data) }),
+		eventField("meta", "LogMeta!", func(e *logdb.Event) interface{} {
+			return &events.LogMeta{
+				BlockID:        e.BlockID,
+				BlockNumber:    e.BlockNumber,
+				BlockTimestamp: e.BlockTime,
+				TxID:           e.TxID,
+				TxOrigin:       e.TxOrigin,
+				ClauseIndex:    e.ClauseIndex,
+			}
+		}),
+		eventField("cursor", "String!", func(e *logdb.Event) interface{} { return e.Cursor().String() }),
+		newField("block", "Block!", func(r *request, src interface{}, args map[string]interface{}) (interface{}, error) {
+			return g.repo.GetBlockSummary(src.(*logdb.Event).BlockID)
+		}).withCost(blockCost),
+		newField("transaction", "Transaction", func(r *request, src interface{}, args map[string]interface{}) (interface{}, error) {
+			e := src.(*logdb.Event)
+			return g.getTransaction(e.BlockID, e.TxID)
+		}).withCost(blockCost),
+	)
+
+	transferType := newObject("Transfer",
+		transferField("sender", "Address!", func(t *logdb.Transfer) interface{} { return t.Sender }),
+		transferField("recipient", "Address!", func(t *logdb.Transfer) interface{} { return t.Recipient }),
+		transferField("amount", "BigInt!", func(t *logdb.Transfer) interface{} { return (*math.HexOrDecimal256)(t.Amount) }),
+		transferField("meta", "LogMeta!", func(t *logdb.Transfer) interface{} {
+			return &events.LogMeta{
+				BlockID:        t.BlockID,
+				BlockNumber:    t.BlockNumber,
+				BlockTimestamp: t.BlockTime,
+				TxID:           t.TxID,
+				TxOrigin:       t.TxOrigin,
+				ClauseIndex:    t.ClauseIndex,
+			}
+		}),
+		transferField("cursor", "String!", func(t *logdb.Transfer) interface{} { return t.Cursor().String() }),
+		newField("block", "Block!", func(r *request, src interface{}, args map[string]interface{}) (interface{}, error) {
+			return g.repo.GetBlockSummary(src.(*logdb.Transfer).BlockID)
+		}).withCost(blockCost),
+		newField("transaction", "Transaction", func(r *request, src interface{}, args map[string]interface{}) (interface{}, error) {
+			t := src.(*logdb.Transfer)
+			return g.getTransaction(t.BlockID, t.TxID)
+		}).withCost(blockCost),
+	)
+
+	logMetaType := newObject("LogMeta",
+		metaField("blockID", "Bytes32!", func(m *events.LogMeta) interface{} { return m.BlockID }),
+		metaField("blockNumber", "Int!", func(m *events.LogMeta) interface{} { return m.BlockNumber }),
+		metaField("blockTimestamp", "Long!", func(m *events.LogMeta) interface{} { return m.BlockTimestamp }),
+		metaField("txID", "Bytes32!", func(m *events.LogMeta) interface{} { return m.TxID }),
+		metaField("txOrigin", "Address!", func(m *events.LogMeta) interface{} { return m.TxOrigin }),
+		metaField("clauseIndex", "Int!", func(m *events.LogMeta) interface{} { return m.ClauseIndex }),
+	)
+
+	accountType := newObject("Account",
+		newField("address", "Address!", func(r *request, src interface{}, args map[string]interface{}) (interface{}, error) {
+			return src.(*accountSource).address, nil
+		}),
+		newField("block", "Block!", func(r *request, src interface{}, args map[string]interface{}) (interface{}, error) {
+			return src.(*accountSource).summary, nil
+		}),
+		stateField("balance", "BigInt!", func(acc *accountSource, args map[string]interface{}) (interface{}, error) {
+			balance, err := acc.state.GetBalance(acc.address)
+			return (*math.HexOrDecimal256)(balance), err
+		}),
+		stateField("energy", "BigInt!", func(acc *accountSource, args map[string]interface{}) (interface{}, error) {
+			energy, err := acc.state.GetEnergy(acc.address, acc.summary.Header.Timestamp())
+			return (*math.HexOrDecimal256)(energy), err
+		}),
+		stateField("hasCode", "Boolean!", func(acc *accountSource, args map[string]interface{}) (interface{}, error) {
+			code, err := acc.state.GetCode(acc.address)
+			return len(code) > 0, err
+		}),
+		stateField("code", "Bytes!", func(acc *accountSource, args map[string]interface{}) (interface{}, error) {
+			code, err := acc.state.GetCode(acc.address)
+			return hexutil.Bytes(code), err
+		}),
+		stateField("storage", "Bytes32!", func(acc *accountSource, args map[string]interface{}) (interface{}, error) {
+			return acc.state.GetStorage(acc.address, args["key"].(workshare.Bytes32))
+		}, newArg("key", "Bytes32!")),
+	)
+
+	logArgs := func(criteria string) []*argDef {
+		return []*argDef{
+			newArg("criteriaSet", "["+criteria+"!]"),
+			newArg("range", "Range"),
+			newArg("order", "Order").withDefault(enumLiteral("ASC")),
+			newArg("limit", "Int!"),
+			newArg("offset", "Int"),
+			newArg("cursor", "String"),
+		}
+	}
+
+	queryType := newObject("Query",
+		newField("block", "Block", g.resolveBlock, newArg("revision", "String")).withCost(blockCost),
+		newField("blocks", "[Block!]!", g.resolveBlocks, newArg("from", "Int!"), newArg("to", "Int!")),
+		newField("transaction", "Transaction", g.resolveTransaction, newArg("id", "Bytes32!")).withCost(blockCost),
+		newField("account", "Account", g.resolveAccount, newArg("address", "Address!"), newArg("revision", "String")).withCost(blockCost),
+		newField("events", "[Event!]!", g.resolveEvents, logArgs("EventCriteria")...),
+		newField("transfers", "[Transfer!]!", g.resolveTransfers, logArgs("TransferCriteria")...),
+	)
+
+	return newSchema(queryType,
+		blockType,
+		transactionType,
+		clauseType,
+		receiptType,
+		outputType,
+		eventType,
+		transferType,
+		logMetaType,
+		accountType,
+		&inputType{"EventCriteria", []*argDef{
+			newArg("address", "[Address!]"),
+			newArg("topic0", "[Bytes32!]"),
+			newArg("topic1", "[Bytes32!]"),
+			newArg("topic2", "[Bytes32!]"),
+			newArg("topic3", "[Bytes32!]"),
+			newArg("topic4", "[Bytes32!]"),
+			newArg("txID", "[Bytes32!]"),
+			newArg("txOrigin", "[Address!]"),
+		}},
+		&inputType{"TransferCriteria", []*argDef{
+			newArg("txID", "Bytes32"),
+			newArg("txOrigin", "Address"),
+			newArg("sender", "Address"),
+			newArg("recipient", "Address"),
+		}},
+		&inputType{"Range", []*argDef{
+			newArg("unit", "RangeUnit").withDefault(enumLiteral("BLOCK")),
+			newArg("from", "Long!"),
+			newArg("to", "Long!"),
+		}},
+		&enumType{"RangeUnit", []string{"BLOCK", "TIME"}},
+		&enumType{"Order", []string{"ASC", "DESC"}},
+		longScalar,
+		bigIntScalar,
+		addressScalar,
+		bytes32Scalar,
+		bytesScalar,
+	)
+}
+
+// summaryByRevision returns the block summary of the revision, which is 'best', a block number or ID.
+// It returns nil if the block is not found.
+func (g *GraphQL) summaryByRevision(revision string) (*chain.BlockSummary, error) {
+	if revision == "" || revision == "best" {
+		return g.repo.BestBlockSummary(), nil
+	}
+	var (
+		summary *chain.BlockSummary
+		err     error
+	)
+	if len(revision) == 66 || len(revision) == 64 {
+		blockID, perr := workshare.ParseBytes32(revision)
+		if perr != nil {
+			return nil, fmt.Errorf("revision: %v", perr)
+		}
+		summary, err = g.repo.GetBlockSummary(blockID)
+	} else {
+		n, perr := strconv.ParseUint(revision, 0, 32)
+		if perr != nil {
+			return nil, fmt.Errorf("revision: %v", perr)
+		}
+		summary, err = g.repo.NewBestChain().GetBlockSummary(uint32(n))
+	}
+	if err != nil {
+		if g.repo.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return summary, nil
+}
+
+func (g *GraphQL) newAccount(addr workshare.Address, summary *chain.BlockSummary) *accountSource {
+	header := summary.Header
+	return &accountSource{
+		address: addr,
+		summary: summary,
+		state:   g.stater.NewState(header.StateRoot(), header.Number(), summary.Conflicts, summary.SteadyNum),
+	}
+}
+
+// getTransaction returns the tx in the given block, or nil for the zero tx id of genesis logs.
+func (g *GraphQL) getTransaction(blockID, txID workshare.Bytes32) (*txSource, error) {
+	if txID.IsZero() {
+		return nil, nil
+	}
+	t, meta, err := g.repo.NewChain(blockID).GetTransaction(txID)
+	if err != nil {
+		return nil, err
+	}
+	summary, err := g.repo.GetBlockSummary(meta.BlockID)
+	if err != nil {
+		return nil, err
+	}
+	return &txSource{t, summary, int(meta.Index)}, nil
+}
+
+func (g *GraphQL) resolveBlock(r *request, src interface{}, args map[string]interface{}) (interface{}, error) {
+	return g.summaryByRevision(argString(args, "revision", ""))
+}
+
+// resolveBlocks returns trunk blocks in range [from, to]. The cost is charged for each block.
+func (g *GraphQL) resolveBlocks(r *request, src interface{}, args map[string]interface{}) (interface{}, error) {
+	from, err := argUint32(args, "from")
+	if err != nil {
+		return nil, err
+	}
+	to, err := argUint32(args, "to")
+	if err != nil {
+		return nil, err
+	}
+	if to < from {
+		return nil, errors.New("to: less than from")
+	}
+	best := g.repo.BestBlockSummary().Header.Number()
+	if from > best {
+		return []*chain.BlockSummary{}, nil
+	}
+	if to > best {
+		to = best
+	}
+	if err := r.charge(uint64(to-from+1) * blockCost); err != nil {
+		return nil, err
+	}
+	c := g.repo.NewBestChain()
+	summaries := make([]*chain.BlockSummary, 0, to-from+1)
+	for n := from; n <= to; n++ {
+		summary, err := c.GetBlockSummary(n)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+func (g *GraphQL) resolveParent(r *request, src interface{}, args map[string]interface{}) (interface{}, error) {
+	header := src.(*chain.BlockSummary).Header
+	if header.Number() == 0 {
+		return nil, nil
+	}
+	return g.repo.GetBlockSummary(header.ParentID())
+}
+
+func (g *GraphQL) resolveIsTrunk(r *request, src interface{}, args map[string]interface{}) (interface{}, error) {
+	header := src.(*chain.BlockSummary).Header
+	id, err := g.repo.NewBestChain().GetBlockID(header.Number())
+	if err != nil {
+		if g.repo.IsNotFound(err) {
+			return false, nil
+		}
+		return nil, err
+	}
+	return id == header.ID(), nil
+}
+
+func (g *GraphQL) resolveBlockTransactions(r *request, src interface{}, args map[string]interface{}) (interface{}, error) {
+	summary := src.(*chain.BlockSummary)
+	txs, err := r.memo(txsKey(summary.Header.ID()), func() (interface{}, error) {
+		if err := r.charge(blockCost + uint64(len(summary.Txs))); err != nil {
+			return nil, err
+		}
+		return g.repo.GetBlockTransactions(summary.Header.ID())
+	})
+	if err != nil {
+		return nil, err
+	}
+	sources := make([]*txSource, 0, len(summary.Txs))
+	for i, t := range txs.(tx.Transactions) {
+		sources = append(sources, &txSource{t, summary, i})
+	}
+	return sources, nil
+}
+
+func (g *GraphQL) resolveReceipt(r *request, src interface{}, args map[string]interface{}) (interface{}, error) {
+	s := src.(*txSource)
+	receipts, err := r.memo(receiptsKey(s.summary.Header.ID()), func() (interface{}, error) {
+		if err := r.charge(blockCost + uint64(len(s.summary.Txs))); err != nil {
+			return nil, err
+		}
+		return g.repo.GetBlockReceipts(s.summary.Header.ID())
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &receiptSource{s, receipts.(tx.Receipts)}, nil
+}
+
+// resolveOutputs builds outputs with logs indexed as they are in the log db, so that cursors are consistent.
+func resolveOutputs(r *request, src interface{}, args map[string]interface{}) (interface{}, error) {
+	s := src.(*receiptSource)
+	var eventIndex, transferIndex uint32
+	for _, receipt := range s.receipts[:s.tx.index] {
+		for _, o := range receipt.Outputs {
+			eventIndex += uint32(len(o.Events))
+			transferIndex += uint32(len(o.Transfers))
+		}
+	}
+
+	var (
+		header    = s.tx.summary.Header
+		txID      = s.tx.tx.ID()
+		origin, _ = s.tx.tx.Origin()
+		outputs   = s.receipts[s.tx.index].Outputs
+		sources   = make([]*outputSource, 0, len(outputs))
+	)
+	for i, o := range outputs {
+		os := &outputSource{
+			tx:          s.tx,
+			output:      o,
+			clauseIndex: i,
+			events:      make([]*logdb.Event, 0, len(o.Events)),
+			transfers:   make([]*logdb.Transfer, 0, len(o.Transfers)),
+		}
+		for _, ev := range o.Events {
+			e := &logdb.Event{
+				BlockNumber: header.Number(),
+				Index:       eventIndex,
+				BlockID:     header.ID(),
+				BlockTime:   header.Timestamp(),
+				TxID:        txID,
+				TxOrigin:    origin,
+				ClauseIndex: uint32(i),
+				Address:     ev.Address,
+				Data:        ev.Data,
+			}
+			for j := 0; j < len(ev.Topics) && j < len(e.Topics); j++ {
+				topic := ev.Topics[j]
+				e.Topics[j] = &topic
+			}
+			os.events = append(os.events, e)
+			eventIndex++
+		}
+		for _, tr := range o.Transfers {
+			os.transfers = append(os.transfers, &logdb.Transfer{
+				BlockNumber: header.Number(),
+				Index:       transferIndex,
+				BlockID:     header.ID(),
+				BlockTime:   header.Timestamp(),
+				TxID:        txID,
+				TxOrigin:    origin,
+				ClauseIndex: uint32(i),
+				Sender:      tr.Sender,
+				Recipient:   tr.Recipient,
+				Amount:      tr.Amount,
+			})
+			transferIndex++
+		}
+		sources = append(sources, os)
+	}
+	return sources, nil
+}
+
+func (g *GraphQL) resolveTransaction(r *request, src interface{}, args map[string]interface{}) (interface{}, error) {
+	t, meta, err := g.repo.NewBestChain().GetTransaction(args["id"].(workshare.Bytes32))
+	if err != nil {
+		if g.repo.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	summary, err := g.repo.GetBlockSummary(meta.BlockID)
+	if err != nil {
+		return nil, err
+	}
+	return &txSource{t, summary, int(meta.Index)}, nil
+}
+
+func (g *GraphQL) resolveAccount(r *request, src interface{}, args map[string]interface{}) (interface{}, error) {
+	summary, err := g.summaryByRevision(argString(args, "revision", ""))
+	if err != nil || summary == nil {
+		return nil, err
+	}
+	return g.newAccount(args["address"].(workshare.Address), summary), nil
+}
+
+// logOptions converts the common arguments of log queries, and charges the cost of the query.
+func (g *GraphQL) logOptions(r *request, args map[string]interface{}) (*logdb.Range, *logdb.Options, logdb.Order, error) {
+	if g.logDB == nil {
+		return nil, nil, "", errors.New("logs are not recorded")
+	}
+	limit := argInt(args, "limit", 0)
+	if limit < 0 || limit > maxLogLimit {
+		return nil, nil, "", fmt.Errorf("limit: out of range [0, %d]", maxLogLimit)
+	}
+	offset := argInt(args, "offset", 0)
+	if offset < 0 {
+		return nil, nil, "", errors.New("offset: negative")
+	}
+	options := &logdb.Options{Offset: uint64(offset), Limit: uint64(limit)}
+	if cursor, ok := args["cursor"].(string); ok {
+		options.Cursor = &logdb.Cursor{}
+		if err := options.Cursor.UnmarshalText([]byte(cursor)); err != nil {
+			return nil, nil, "", fmt.Errorf("cursor: %v", err)
+		}
+	}
+	if err := events.ValidateOptions(options); err != nil {
+		return nil, nil, "", err
+	}
+	if err := r.charge(logQueryCost + uint64(limit)); err != nil {
+		return nil, nil, "", err
+	}
+
+	var rng *logdb.Range
+	if obj, ok := args["range"].(map[string]interface{}); ok {
+		unit := events.BlockRangeType
+		if obj["unit"] == "TIME" {
+			unit = events.TimeRangeType
+		}
+		var err error
+		if rng, err = events.ConvertRange(g.repo.NewBestChain(), &events.Range{
+			Unit: unit,
+			From: obj["from"].(uint64),
+			To:   obj["to"].(uint64),
+		}); err != nil {
+			return nil, nil, "", err
+		}
+	}
+	order := logdb.ASC
+	if args["order"] == "DESC" {
+		order = logdb.DESC
+	}
+	return rng, options, order, nil
+}
+
+func (g *GraphQL) resolveEvents(r *request, src interface{}, args map[string]interface{}) (interface{}, error) {
+	rng, options, order, err := g.logOptions(r, args)
+	if err != nil {
+		return nil, err
+	}
+	filter := &logdb.EventFilter{Range: rng, Options: options, Order: order}
+	set, _ := args["criteriaSet"].([]interface{})
+	for _, item := range set {
+		obj := item.(map[string]interface{})
+		criteria := &logdb.EventCriteria{}
+		addrs, _ := obj["address"].([]interface{})
+		for _, addr := range addrs {
+			criteria.Address = append(criteria.Address, addr.(workshare.Address))
+		}
+		for i := range criteria.Topics {
+			topics, _ := obj["topic"+strconv.Itoa(i)].([]interface{})
+			for _, topic := range topics {
+				criteria.Topics[i] = append(criteria.Topics[i], topic.(workshare.Bytes32))
+			}
+		}
+		ids, _ := obj["txID"].([]interface{})
+		for _, id := range ids {
+			criteria.TxID = append(criteria.TxID, id.(workshare.Bytes32))
+		}
+		origins, _ := obj["txOrigin"].([]interface{})
+		for _, addr := range origins {
+			criteria.TxOrigin = append(criteria.TxOrigin, addr.(workshare.Address))
+		}
+		filter.CriteriaSet = append(filter.CriteriaSet, criteria)
+	}
+	return g.logDB.FilterEvents(r.ctx, filter)
+}
+
+func (g *GraphQL) resolveTransfers(r *request, src interface{}, args map[string]interface{}) (interface{}, error) {
+	rng, options, order, err := g.logOptions(r, args)
+	if err != nil {
+		return nil, err
+	}
+	filter := &logdb.TransferFilter{Range: rng, Options: options, Order: order}
+	set, _ := args["criteriaSet"].([]interface{})
+	for _, item := range set {
+		obj := item.(map[string]interface{})
+		criteria := &logdb.TransferCriteria{}
+		if id, ok := obj["txID"].(workshare.Bytes32); ok {
+			criteria.TxID = &id
+		}
+		if addr, ok := obj["txOrigin"].(workshare.Address); ok {
+			criteria.TxOrigin = &addr
+		}
+		if addr, ok := obj["sender"].(workshare.Address); ok {
+			criteria.Sender = &addr
+		}
+		if addr, ok := obj["recipient"].(workshare.Address); ok {
+			criteria.Recipient = &addr
+		}
+		filter.CriteriaSet = append(filter.CriteriaSet, criteria)
+	}
+	return g.logDB.FilterTransfers(r.ctx, filter)
+}
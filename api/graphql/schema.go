@@ -0,0 +1,377 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/miniBamboo/workshare/workshare"
+	"github.com/pkg/errors"
+)
+
+// resolveFunc resolves a field of src, with coerced arguments. Absent arguments are not in args.
+type resolveFunc func(r *request, src interface{}, args map[string]interface{}) (interface{}, error)
+
+type fieldDef struct {
+	name    string
+	typ     *typeRef
+	args    []*argDef
+	cost    uint64 // charged on top of the base cost of each field
+	resolve resolveFunc
+}
+
+type argDef struct {
+	name string
+	typ  *typeRef
+	def  interface{} // raw default value, nil if none
+}
+
+type objectType struct {
+	name   string
+	fields []*fieldDef
+	index  map[string]*fieldDef
+}
+
+type scalarType struct {
+	name  string
+	parse func(raw interface{}) (interface{}, error)
+}
+
+type enumType struct {
+	name   string
+	values []string
+}
+
+type inputType struct {
+	name   string
+	fields []*argDef
+}
+
+// enumLiteral is an enum value in a query literal, which is distinguished from strings.
+type enumLiteral string
+
+type schema struct {
+	query *objectType
+	types map[string]interface{} // *objectType, *scalarType, *enumType or *inputType
+	order []string
+}
+
+func newField(name, typ string, resolve resolveFunc, args ...*argDef) *fieldDef {
+	return &fieldDef{name: name, typ: mustParseType(typ), args: args, resolve: resolve}
+}
+
+// withCost sets the extra cost of the field.
+func (f *fieldDef) withCost(cost uint64) *fieldDef {
+	f.cost = cost
+	return f
+}
+
+func newArg(name, typ string) *argDef {
+	return &argDef{name: name, typ: mustParseType(typ)}
+}
+
+// withDefault sets the default value, in the form of decoded JSON.
+func (a *argDef) withDefault(def interface{}) *argDef {
+	a.def = def
+	return a
+}
+
+func newObject(name string, fields ...*fieldDef) *objectType {
+	t := &objectType{name: name, fields: fields, index: make(map[string]*fieldDef)}
+	for _, f := range fields {
+		t.index[f.name] = f
+	}
+	return t
+}
+
+func newSchema(query *objectType, types ...interface{}) *schema {
+	s := &schema{query: query, types: make(map[string]interface{})}
+	for _, t := range append([]interface{}{query}, types...) {
+		var name string
+		switch t := t.(type) {
+		case *objectType:
+			name = t.name
+		case *scalarType:
+			name = t.name
+		case *enumType:
+			name = t.name
+		case *inputType:
+			name = t.name
+		}
+		s.types[name] = t
+		s.order = append(s.order, name)
+	}
+	for _, scalar := range builtinScalars {
+		s.types[scalar.name] = scalar
+	}
+	return s
+}
+
+// isInput tells if the named type can be used as input.
+func (s *schema) isInput(t *typeRef) bool {
+	for t.elem != nil {
+		t = t.elem
+	}
+	switch s.types[t.name].(type) {
+	case *scalarType, *enumType, *inputType:
+		return true
+	}
+	return false
+}
+
+// String returns the schema in SDL.
+func (s *schema) String() string {
+	var sb strings.Builder
+	writeArgs := func(args []*argDef) {
+		if len(args) == 0 {
+			return
+		}
+		sb.WriteString("(")
+		for i, a := range args {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(a.name + ": " + a.typ.String())
+			if a.def != nil {
+				sb.WriteString(" = " + formatDefault(a.def))
+			}
+		}
+		sb.WriteString(")")
+	}
+	for i, name := range s.order {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		switch t := s.types[name].(type) {
+		case *objectType:
+			sb.WriteString("type " + t.name + " {\n")
+			for _, f := range t.fields {
+				sb.WriteString("  " + f.name)
+				writeArgs(f.args)
+				sb.WriteString(": " + f.typ.String() + "\n")
+			}
+			sb.WriteString("}\n")
+		case *scalarType:
+			sb.WriteString("scalar " + t.name + "\n")
+		case *enumType:
+			sb.WriteString("enum " + t.name + " {\n")
+			for _, v := range t.values {
+				sb.WriteString("  " + v + "\n")
+			}
+			sb.WriteString("}\n")
+		case *inputType:
+			sb.WriteString("input " + t.name + " {\n")
+			for _, f := range t.fields {
+				sb.WriteString("  " + f.name + ": " + f.typ.String())
+				if f.def != nil {
+					sb.WriteString(" = " + formatDefault(f.def))
+				}
+				sb.WriteString("\n")
+			}
+			sb.WriteString("}\n")
+		}
+	}
+	return sb.String()
+}
+
+func formatDefault(def interface{}) string {
+	if e, ok := def.(enumLiteral); ok {
+		return string(e)
+	}
+	data, _ := json.Marshal(def)
+	return string(data)
+}
+
+// coerce converts the raw input value (decoded JSON or literal) into the Go value of the type.
+// Input objects are coerced into map[string]interface{}, and lists into []interface{}.
+func (s *schema) coerce(t *typeRef, raw interface{}) (interface{}, error) {
+	if raw == nil {
+		if t.nonNull {
+			return nil, fmt.Errorf("expected non-null %v", t)
+		}
+		return nil, nil
+	}
+	if t.elem != nil {
+		list, ok := raw.([]interface{})
+		if !ok {
+			// a single value is coerced into a list of one
+			list = []interface{}{raw}
+		}
+		out := make([]interface{}, 0, len(list))
+		for i, item := range list {
+			v, err := s.coerce(t.elem, item)
+			if err != nil {
+				return nil, errors.WithMessage(err, fmt.Sprintf("[%d]", i))
+			}
+			out = append(out, v)
+		}
+		return out, nil
+	}
+	switch typ := s.types[t.name].(type) {
+	case *scalarType:
+		if _, ok := raw.(enumLiteral); ok {
+			return nil, fmt.Errorf("expected %v, got enum", t.name)
+		}
+		return typ.parse(raw)
+	case *enumType:
+		var str string
+		switch v := raw.(type) {
+		case enumLiteral:
+			str = string(v)
+		case string:
+			str = v
+		}
+		for _, v := range typ.values {
+			if v == str {
+				return v, nil
+			}
+		}
+		return nil, fmt.Errorf("expected %v, got %v", t.name, formatDefault(raw))
+	case *inputType:
+		obj, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected %v object", t.name)
+		}
+		out := make(map[string]interface{})
+		for name := range obj {
+			if findArg(typ.fields, name) == nil {
+				return nil, fmt.Errorf("unknown field '%v' of %v", name, t.name)
+			}
+		}
+		for _, f := range typ.fields {
+			fv, ok := obj[f.name]
+			if !ok {
+				fv = f.def
+			}
+			v, err := s.coerce(f.typ, fv)
+			if err != nil {
+				return nil, errors.WithMessage(err, f.name)
+			}
+			if v != nil {
+				out[f.name] = v
+			}
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("unknown input type %v", t.name)
+}
+
+func findArg(args []*argDef, name string) *argDef {
+	for _, a := range args {
+		if a.name == name {
+			return a
+		}
+	}
+	return nil
+}
+
+var builtinScalars = []*scalarType{
+	{"Int", func(raw interface{}) (interface{}, error) {
+		if n, ok := raw.(json.Number); ok {
+			if i, err := strconv.ParseInt(string(n), 10, 32); err == nil {
+				return int(i), nil
+			}
+		}
+		return nil, fmt.Errorf("expected Int, got %v", formatDefault(raw))
+	}},
+	{"String", func(raw interface{}) (interface{}, error) {
+		if s, ok := raw.(string); ok {
+			return s, nil
+		}
+		return nil, fmt.Errorf("expected String, got %v", formatDefault(raw))
+	}},
+	{"Boolean", func(raw interface{}) (interface{}, error) {
+		if b, ok := raw.(bool); ok {
+			return b, nil
+		}
+		return nil, fmt.Errorf("expected Boolean, got %v", formatDefault(raw))
+	}},
+}
+
+// custom scalars
+var (
+	longScalar = &scalarType{"Long", func(raw interface{}) (interface{}, error) {
+		var s string
+		switch v := raw.(type) {
+		case json.Number:
+			s = string(v)
+		case string:
+			s = v
+		}
+		if n, err := strconv.ParseUint(s, 0, 64); err == nil {
+			return n, nil
+		}
+		return nil, fmt.Errorf("expected Long, got %v", formatDefault(raw))
+	}}
+	bigIntScalar = &scalarType{"BigInt", func(raw interface{}) (interface{}, error) {
+		var s string
+		switch v := raw.(type) {
+		case json.Number:
+			s = string(v)
+		case string:
+			s = v
+		}
+		if n, ok := new(big.Int).SetString(s, 0); ok && n.Sign() >= 0 && n.BitLen() <= 256 {
+			return n, nil
+		}
+		return nil, fmt.Errorf("expected BigInt, got %v", formatDefault(raw))
+	}}
+	addressScalar = &scalarType{"Address", func(raw interface{}) (interface{}, error) {
+		s, _ := raw.(string)
+		addr, err := workshare.ParseAddress(s)
+		if err != nil {
+			return nil, errors.WithMessage(err, "Address")
+		}
+		return addr, nil
+	}}
+	bytes32Scalar = &scalarType{"Bytes32", func(raw interface{}) (interface{}, error) {
+		s, _ := raw.(string)
+		b, err := workshare.ParseBytes32(s)
+		if err != nil {
+			return nil, errors.WithMessage(err, "Bytes32")
+		}
+		return b, nil
+	}}
+	bytesScalar = &scalarType{"Bytes", func(raw interface{}) (interface{}, error) {
+		s, _ := raw.(string)
+		b, err := hexutil.Decode(s)
+		if err != nil {
+			return nil, errors.WithMessage(err, "Bytes")
+		}
+		return b, nil
+	}}
+)
+
+// argInt returns the Int argument, or def if absent.
+func argInt(args map[string]interface{}, name string, def int) int {
+	if v, ok := args[name].(int); ok {
+		return v
+	}
+	return def
+}
+
+// argUint32 returns the non-negative Int argument as uint32.
+func argUint32(args map[string]interface{}, name string) (uint32, error) {
+	v := argInt(args, name, 0)
+	if v < 0 || int64(v) > math.MaxUint32 {
+		return 0, fmt.Errorf("%v: out of range", name)
+	}
+	return uint32(v), nil
+}
+
+// argString returns the String argument, or def if absent.
+func argString(args map[string]interface{}, name string, def string) string {
+	if v, ok := args[name].(string); ok {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,207 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package graphql
+
+import (
+	"fmt"
+)
+
+// validator checks a document against the schema before execution, so that errors
+// are reported regardless of data, and the depth of the query is limited.
+type validator struct {
+	schema *schema
+	doc    *document
+	vars   map[string]*varDef
+	depths map[string]int // validated fragments with their depth, -1 while visiting
+}
+
+func validate(s *schema, doc *document, op *operation) error {
+	if op.kind != "query" {
+		return fmt.Errorf("%s operations are not supported", op.kind)
+	}
+	v := &validator{
+		schema: s,
+		doc:    doc,
+		vars:   make(map[string]*varDef),
+		depths: make(map[string]int),
+	}
+	for _, def := range op.vars {
+		if _, ok := v.vars[def.name]; ok {
+			return fmt.Errorf("duplicated variable $%s", def.name)
+		}
+		if !s.isInput(def.typ) {
+			return fmt.Errorf("variable $%s: %v is not an input type", def.name, def.typ)
+		}
+		v.vars[def.name] = def
+	}
+	if err := v.directives(op.directives); err != nil {
+		return err
+	}
+	depth, err := v.selections(s.query, op.selections)
+	if err != nil {
+		return err
+	}
+	if depth > maxDepth {
+		return fmt.Errorf("query depth %d exceeds limit %d", depth, maxDepth)
+	}
+	return nil
+}
+
+// selections validates the selection set on type t, and returns its depth.
+func (v *validator) selections(t *objectType, sels []selection) (int, error) {
+	depth := 0
+	for _, sel := range sels {
+		var (
+			d   int
+			err error
+		)
+		switch sel := sel.(type) {
+		case *field:
+			d, err = v.field(t, sel)
+		case *fragmentSpread:
+			if err = v.directives(sel.directives); err == nil {
+				d, err = v.fragment(t, sel.name)
+			}
+		case *inlineFragment:
+			if sel.on != "" && sel.on != t.name {
+				return 0, fmt.Errorf("inline fragment on '%s' cannot be spread within type '%s'", sel.on, t.name)
+			}
+			if err = v.directives(sel.directives); err == nil {
+				d, err = v.selections(t, sel.selections)
+			}
+		}
+		if err != nil {
+			return 0, err
+		}
+		if d > depth {
+			depth = d
+		}
+	}
+	return depth, nil
+}
+
+func (v *validator) fragment(t *objectType, name string) (int, error) {
+	frag, ok := v.doc.fragments[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown fragment '%s'", name)
+	}
+	if frag.on != t.name {
+		return 0, fmt.Errorf("fragment '%s' on '%s' cannot be spread within type '%s'", name, frag.on, t.name)
+	}
+	if d, ok := v.depths[name]; ok {
+		if d < 0 {
+			return 0, fmt.Errorf("fragment '%s' spreads itself", name)
+		}
+		return d, nil
+	}
+	v.depths[name] = -1
+	if err := v.directives(frag.directives); err != nil {
+		return 0, err
+	}
+	d, err := v.selections(t, frag.selections)
+	if err != nil {
+		return 0, err
+	}
+	v.depths[name] = d
+	return d, nil
+}
+
+func (v *validator) field(t *objectType, f *field) (int, error) {
+	if err := v.directives(f.directives); err != nil {
+		return 0, err
+	}
+	if f.name == "__typename" {
+		if len(f.args) > 0 || len(f.selections) > 0 {
+			return 0, fmt.Errorf("invalid selection of '__typename'")
+		}
+		return 1, nil
+	}
+	def, ok := t.index[f.name]
+	if !ok {
+		return 0, fmt.Errorf("cannot query field '%s' on type '%s'", f.name, t.name)
+	}
+	if err := v.arguments(def.args, f.args, fmt.Sprintf("field '%s'", f.name)); err != nil {
+		return 0, err
+	}
+	named := def.typ
+	for named.elem != nil {
+		named = named.elem
+	}
+	obj, isObject := v.schema.types[named.name].(*objectType)
+	if !isObject {
+		if len(f.selections) > 0 {
+			return 0, fmt.Errorf("field '%s' of type '%v' must not have a selection", f.name, def.typ)
+		}
+		return 1, nil
+	}
+	if len(f.selections) == 0 {
+		return 0, fmt.Errorf("field '%s' of type '%v' must have a selection of subfields", f.name, def.typ)
+	}
+	d, err := v.selections(obj, f.selections)
+	if err != nil {
+		return 0, err
+	}
+	return d + 1, nil
+}
+
+func (v *validator) arguments(defs []*argDef, args []*argument, owner string) error {
+	for _, arg := range args {
+		if findArg(defs, arg.name) == nil {
+			return fmt.Errorf("unknown argument '%s' on %s", arg.name, owner)
+		}
+		if err := v.variables(arg.value); err != nil {
+			return err
+		}
+	}
+	for _, def := range defs {
+		if !def.typ.nonNull || def.def != nil {
+			continue
+		}
+		var found bool
+		for _, arg := range args {
+			found = found || arg.name == def.name
+		}
+		if !found {
+			return fmt.Errorf("argument '%s' of type '%v' is required on %s", def.name, def.typ, owner)
+		}
+	}
+	return nil
+}
+
+// variables checks that all variables in the value are defined.
+func (v *validator) variables(val *value) error {
+	switch val.kind {
+	case varValue:
+		if _, ok := v.vars[val.raw]; !ok {
+			return fmt.Errorf("variable $%s is not defined", val.raw)
+		}
+	case listValue:
+		for _, elem := range val.list {
+			if err := v.variables(elem); err != nil {
+				return err
+			}
+		}
+	case objectValue:
+		for _, f := range val.fields {
+			if err := v.variables(f.value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (v *validator) directives(dirs []*directive) error {
+	for _, dir := range dirs {
+		if dir.name != "skip" && dir.name != "include" {
+			return fmt.Errorf("unknown directive '@%s'", dir.name)
+		}
+		if err := v.arguments([]*argDef{newArg("if", "Boolean!")}, dir.args, "directive '@"+dir.name+"'"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
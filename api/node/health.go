@@ -0,0 +1,98 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package node
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/miniBamboo/workshare/api/utils"
+)
+
+// HealthConfig configures health checks. Zero thresholds disable corresponding checks.
+type HealthConfig struct {
+	// MaxBlockAge is the max age of the best block for the node to be ready.
+	MaxBlockAge time.Duration
+	// MinPeers is the min number of connected peers for the node to be ready.
+	MinPeers int
+	// LogDBFailed reports whether the log db writer is failing. Nil if not applicable.
+	LogDBFailed func() bool
+}
+
+func (n *Node) checkLogDB() *HealthCheck {
+	c := &HealthCheck{Name: "logDB", OK: true}
+	if n.health.LogDBFailed != nil && n.health.LogDBFailed() {
+		c.OK = false
+		c.Detail = "failed to write logs"
+	}
+	return c
+}
+
+func (n *Node) checkSynced() *HealthCheck {
+	c := &HealthCheck{Name: "synced"}
+	select {
+	case <-n.nw.Synced():
+		c.OK = true
+	default:
+		c.Detail = "initial sync in progress"
+	}
+	return c
+}
+
+func (n *Node) checkBlockAge() *HealthCheck {
+	best := n.repo.BestBlockSummary().Header
+	age := time.Since(time.Unix(int64(best.Timestamp()), 0)).Truncate(time.Second)
+	c := &HealthCheck{Name: "bestBlockAge", OK: true}
+	if max := n.health.MaxBlockAge; max > 0 {
+		c.OK = age <= max
+		c.Detail = fmt.Sprintf("best block #%v is %v old, max %v", best.Number(), age, max)
+	} else {
+		c.Detail = fmt.Sprintf("best block #%v is %v old", best.Number(), age)
+	}
+	return c
+}
+
+func (n *Node) checkPeers() *HealthCheck {
+	count := n.nw.PeerCount()
+	c := &HealthCheck{Name: "peers", OK: count >= n.health.MinPeers}
+	if n.health.MinPeers > 0 {
+		c.Detail = fmt.Sprintf("%v peers connected, min %v", count, n.health.MinPeers)
+	} else {
+		c.Detail = fmt.Sprintf("%v peers connected", count)
+	}
+	return c
+}
+
+func writeHealthStatus(w http.ResponseWriter, checks ...*HealthCheck) error {
+	status := &HealthStatus{OK: true, Checks: checks}
+	for _, c := range checks {
+		if !c.OK {
+			status.OK = false
+		}
+	}
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Content-Type", utils.JSONContentType)
+	if !status.OK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	return utils.WriteJSON(w, status)
+}
+
+// handleHealth serves liveness probe, which fails only if the node can't recover by itself.
+func (n *Node) handleHealth(w http.ResponseWriter, req *http.Request) error {
+	return writeHealthStatus(w, n.checkLogDB())
+}
+
+// handleReady serves readiness probe, which fails if the node is not able to serve up-to-date data.
+func (n *Node) handleReady(w http.ResponseWriter, req *http.Request) error {
+	return writeHealthStatus(w,
+		n.checkSynced(),
+		n.checkBlockAge(),
+		n.checkPeers(),
+		n.checkLogDB(),
+	)
+}
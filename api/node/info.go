@@ -0,0 +1,110 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package node
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/miniBamboo/workshare/api/utils"
+	"github.com/miniBamboo/workshare/workshare"
+)
+
+// disk usage is cached since walking the data dir is expensive.
+const diskUsageCacheTTL = time.Minute
+
+// Info is the info of the node, which is fixed since startup.
+type Info struct {
+	Version     string
+	GitCommit   string
+	Enode       string             // empty if not joining p2p network
+	Master      *workshare.Address // nil if not a master node
+	Beneficiary *workshare.Address
+	ForkConfig  workshare.ForkConfig
+	DataDir     string // empty if data is in memory
+
+	CallGasLimit   uint64
+	BacktraceLimit uint32
+	MaxBlockRange  uint32
+}
+
+// diskUsage returns the total size of files in the data dir.
+func (n *Node) diskUsage() (uint64, error) {
+	n.diskUsageCache.Lock()
+	defer n.diskUsageCache.Unlock()
+
+	if !n.diskUsageCache.time.IsZero() && time.Since(n.diskUsageCache.time) < diskUsageCacheTTL {
+		return n.diskUsageCache.size, nil
+	}
+	var size uint64
+	if err := filepath.Walk(n.info.DataDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// files may be removed during walking
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += uint64(info.Size())
+		}
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+	n.diskUsageCache.size = size
+	n.diskUsageCache.time = time.Now()
+	return size, nil
+}
+
+func (n *Node) handleInfo(w http.ResponseWriter, req *http.Request) error {
+	var (
+		gene = n.repo.GenesisBlock().Header()
+		best = n.repo.BestBlockSummary().Header
+	)
+	info := &NodeInfo{
+		Version:   n.info.Version,
+		GitCommit: n.info.GitCommit,
+		Genesis: BlockRef{
+			ID:        gene.ID(),
+			Number:    gene.Number(),
+			Timestamp: gene.Timestamp(),
+		},
+		ChainTag: n.repo.ChainTag(),
+		BestBlock: BlockRef{
+			ID:        best.ID(),
+			Number:    best.Number(),
+			Timestamp: best.Timestamp(),
+		},
+		Enode:       n.info.Enode,
+		Master:      n.info.Master,
+		Beneficiary: n.info.Beneficiary,
+		Forks:       []*ForkInfo{},
+		DataDir:     n.info.DataDir,
+		APILimits: APILimits{
+			CallGasLimit:   n.info.CallGasLimit,
+			BacktraceLimit: n.info.BacktraceLimit,
+			MaxBlockRange:  n.info.MaxBlockRange,
+		},
+	}
+	for _, f := range n.info.ForkConfig.Forks() {
+		info.Forks = append(info.Forks, &ForkInfo{
+			Name:        f.Name,
+			BlockNumber: f.BlockNum,
+			Active:      best.Number() >= f.BlockNum,
+		})
+	}
+	if n.info.DataDir != "" {
+		size, err := n.diskUsage()
+		if err != nil {
+			return err
+		}
+		info.DiskUsage = &size
+	}
+	return utils.WriteJSON(w, info)
+}
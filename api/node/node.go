@@ -7,18 +7,33 @@ package node
 
 import (
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/miniBamboo/workshare/api/utils"
+	"github.com/miniBamboo/workshare/chain"
 )
 
 type Node struct {
-	nw Network
+	nw     Network
+	repo   *chain.Repository
+	health HealthConfig
+	info   Info
+
+	diskUsageCache struct {
+		sync.Mutex
+		size uint64
+		time time.Time
+	}
 }
 
-func New(nw Network) *Node {
+func New(nw Network, repo *chain.Repository, health HealthConfig, info Info) *Node {
 	return &Node{
-		nw,
+		nw:     nw,
+		repo:   repo,
+		health: health,
+		info:   info,
 	}
 }
 
@@ -34,4 +49,7 @@ func (n *Node) Mount(root *mux.Router, pathPrefix string) {
 	sub := root.PathPrefix(pathPrefix).Subrouter()
 
 	sub.Path("/network/peers").Methods("Get").HandlerFunc(utils.WrapHandlerFunc(n.handleNetwork))
+	sub.Path("/health").Methods("Get").HandlerFunc(utils.WrapHandlerFunc(n.handleHealth))
+	sub.Path("/ready").Methods("Get").HandlerFunc(utils.WrapHandlerFunc(n.handleReady))
+	sub.Path("/info").Methods("Get").HandlerFunc(utils.WrapHandlerFunc(n.handleInfo))
 }
@@ -20,6 +20,7 @@ import (
 	"github.com/miniBamboo/workshare/muxdb"
 	"github.com/miniBamboo/workshare/state"
 	"github.com/miniBamboo/workshare/txpool"
+	"github.com/miniBamboo/workshare/workshare"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -33,6 +34,42 @@ func TestNode(t *testing.T) {
 		t.Fatal(err)
 	}
 	assert.Equal(t, 0, len(peersStats), "count should be zero")
+
+	res, statusCode := httpGetWithStatus(t, ts.URL+"/node/health")
+	var health node.HealthStatus
+	if err := json.Unmarshal(res, &health); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, http.StatusOK, statusCode)
+	assert.True(t, health.OK)
+
+	res, statusCode = httpGetWithStatus(t, ts.URL+"/node/ready")
+	var ready node.HealthStatus
+	if err := json.Unmarshal(res, &ready); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, http.StatusServiceUnavailable, statusCode)
+	assert.False(t, ready.OK)
+	for _, c := range ready.Checks {
+		switch c.Name {
+		case "synced", "peers":
+			assert.False(t, c.OK, c.Name)
+		case "logDB":
+			assert.True(t, c.OK, c.Name)
+		}
+	}
+
+	res = httpGet(t, ts.URL+"/node/info")
+	var info node.NodeInfo
+	if err := json.Unmarshal(res, &info); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "1.0.0", info.Version)
+	assert.Equal(t, uint32(0), info.BestBlock.Number)
+	assert.Equal(t, info.Genesis.ID, info.BestBlock.ID)
+	assert.Equal(t, info.Genesis.ID[31], info.ChainTag)
+	assert.Empty(t, info.Forks)
+	assert.Nil(t, info.DiskUsage)
 }
 
 func initCommServer(t *testing.T) {
@@ -51,11 +88,16 @@ func initCommServer(t *testing.T) {
 		MaxLifetime:     10 * time.Minute,
 	}))
 	router := mux.NewRouter()
-	node.New(comm).Mount(router, "/node")
+	node.New(comm, repo, node.HealthConfig{MinPeers: 1}, node.Info{Version: "1.0.0", ForkConfig: workshare.NoFork}).Mount(router, "/node")
 	ts = httptest.NewServer(router)
 }
 
 func httpGet(t *testing.T, url string) []byte {
+	r, _ := httpGetWithStatus(t, url)
+	return r
+}
+
+func httpGetWithStatus(t *testing.T, url string) ([]byte, int) {
 	res, err := http.Get(url)
 	if err != nil {
 		t.Fatal(err)
@@ -65,5 +107,5 @@ func httpGet(t *testing.T, url string) []byte {
 	if err != nil {
 		t.Fatal(err)
 	}
-	return r
+	return r, res.StatusCode
 }
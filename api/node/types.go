@@ -12,6 +12,8 @@ import (
 
 type Network interface {
 	PeersStats() []*comm.PeerStats
+	PeerCount() int
+	Synced() <-chan struct{}
 }
 
 type PeerStats struct {
@@ -42,3 +44,50 @@ func ConvertPeersStats(ss []*comm.PeerStats) []*PeerStats {
 	}
 	return peersStats
 }
+
+// HealthCheck is the result of a single health check.
+type HealthCheck struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// HealthStatus is the result of health checks, which is ok only if all checks are ok.
+type HealthStatus struct {
+	OK     bool           `json:"ok"`
+	Checks []*HealthCheck `json:"checks"`
+}
+
+// NodeInfo is the identity, versions and configs of the node.
+type NodeInfo struct {
+	Version     string             `json:"version"`
+	GitCommit   string             `json:"gitCommit"`
+	Genesis     BlockRef           `json:"genesis"`
+	ChainTag    byte               `json:"chainTag"`
+	BestBlock   BlockRef           `json:"bestBlock"`
+	Enode       string             `json:"enode,omitempty"`
+	Master      *workshare.Address `json:"master"`
+	Beneficiary *workshare.Address `json:"beneficiary"`
+	Forks       []*ForkInfo        `json:"forks"`
+	DataDir     string             `json:"dataDir,omitempty"`
+	DiskUsage   *uint64            `json:"diskUsage,omitempty"`
+	APILimits   APILimits          `json:"apiLimits"`
+}
+
+type BlockRef struct {
+	ID        workshare.Bytes32 `json:"id"`
+	Number    uint32            `json:"number"`
+	Timestamp uint64            `json:"timestamp"`
+}
+
+type ForkInfo struct {
+	Name        string `json:"name"`
+	BlockNumber uint32 `json:"blockNumber"`
+	Active      bool   `json:"active"`
+}
+
+type APILimits struct {
+	CallGasLimit   uint64 `json:"callGasLimit"`
+	BacktraceLimit uint32 `json:"backtraceLimit"`
+	MaxBlockRange  uint32 `json:"maxBlockRange"`
+}
@@ -10,18 +10,23 @@ import (
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/miniBamboo/workshare/chain"
+	"github.com/miniBamboo/workshare/tx"
 	"github.com/miniBamboo/workshare/workshare"
 	"github.com/miniBamboo/workshare/workshare/bloom"
 )
 
 type beat2Reader struct {
 	repo        *chain.Repository
+	watchSet    *WatchSet
 	blockReader chain.BlockReader
 }
 
-func newBeat2Reader(repo *chain.Repository, position workshare.Bytes32) *beat2Reader {
+// newBeat2Reader creates the beat2 reader. If watchSet is not nil, only blocks touching
+// the watch set are emitted, along with the matched txs, events and transfers.
+func newBeat2Reader(repo *chain.Repository, position workshare.Bytes32, watchSet *WatchSet) *beat2Reader {
 	return &beat2Reader{
 		repo:        repo,
+		watchSet:    watchSet,
 		blockReader: repo.NewBlockReader(position),
 	}
 }
@@ -50,6 +55,12 @@ func (br *beat2Reader) Read() ([]interface{}, bool, error) {
 			return nil, false, err
 		}
 		txs := block.Transactions()
+		var filtered *FilteredBeat2Message
+		if br.watchSet != nil {
+			if filtered, err = br.filter(block, receipts); err != nil {
+				return nil, false, err
+			}
+		}
 		for i, receipt := range receipts {
 			bloomAdd(receipt.GasPayer.Bytes())
 			for _, output := range receipt.Outputs {
@@ -74,7 +85,7 @@ func (br *beat2Reader) Read() ([]interface{}, bool, error) {
 		const bitsPerKey = 20
 		filter := bloomGenerator.Generate(bitsPerKey, bloom.K(bitsPerKey))
 
-		msgs = append(msgs, &Beat2Message{
+		beat := Beat2Message{
 			Number:      header.Number(),
 			ID:          header.ID(),
 			ParentID:    header.ParentID(),
@@ -84,7 +95,64 @@ func (br *beat2Reader) Read() ([]interface{}, bool, error) {
 			Bloom:       hexutil.Encode(filter.Bits),
 			K:           filter.K,
 			Obsolete:    block.Obsolete,
-		})
+		}
+		if br.watchSet == nil {
+			msgs = append(msgs, &beat)
+		} else if filtered != nil {
+			filtered.Beat2Message = beat
+			msgs = append(msgs, filtered)
+		}
 	}
 	return msgs, len(blocks) > 0, nil
 }
+
+// filter collects txs, events and transfers in the block matching the watch set.
+// Nil is returned if nothing matched.
+func (br *beat2Reader) filter(block *chain.ExtendedBlock, receipts tx.Receipts) (*FilteredBeat2Message, error) {
+	var (
+		header  = block.Header()
+		txs     = block.Transactions()
+		matched bool
+		msg     = &FilteredBeat2Message{
+			Transactions: []workshare.Bytes32{},
+			Events:       []*EventMessage{},
+			Transfers:    []*TransferMessage{},
+		}
+	)
+	for i, receipt := range receipts {
+		origin, err := txs[i].Origin()
+		if err != nil {
+			return nil, err
+		}
+		if br.watchSet.MatchTx(origin, receipt.GasPayer) {
+			msg.Transactions = append(msg.Transactions, txs[i].ID())
+			matched = true
+		}
+		for j, output := range receipt.Outputs {
+			for _, event := range output.Events {
+				if br.watchSet.MatchEvent(event, origin) {
+					m, err := convertEvent(header, txs[i], uint32(j), event, block.Obsolete)
+					if err != nil {
+						return nil, err
+					}
+					msg.Events = append(msg.Events, m)
+					matched = true
+				}
+			}
+			for _, transfer := range output.Transfers {
+				if br.watchSet.MatchTransfer(transfer, origin) {
+					m, err := convertTransfer(header, txs[i], uint32(j), transfer, block.Obsolete)
+					if err != nil {
+						return nil, err
+					}
+					msg.Transfers = append(msg.Transfers, m)
+					matched = true
+				}
+			}
+		}
+	}
+	if !matched {
+		return nil, nil
+	}
+	return msg, nil
+}
@@ -0,0 +1,214 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package subscriptions
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/miniBamboo/workshare/block"
+	"github.com/miniBamboo/workshare/chain"
+	"github.com/miniBamboo/workshare/genesis"
+	"github.com/miniBamboo/workshare/muxdb"
+	"github.com/miniBamboo/workshare/state"
+	"github.com/miniBamboo/workshare/tx"
+	"github.com/miniBamboo/workshare/workshare"
+	"github.com/stretchr/testify/assert"
+)
+
+var accounts = genesis.DevAccounts()
+
+func newTestRepo(t *testing.T) *chain.Repository {
+	db := muxdb.NewMem()
+	b0, _, _, err := genesis.NewDevnet().Build(state.NewStater(db))
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := chain.NewRepository(db, b0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repo
+}
+
+func newTestTx(t *testing.T, repo *chain.Repository, nonce uint64, from genesis.DevAccount) *tx.Transaction {
+	to := accounts[9].Address
+	trx := new(tx.Builder).
+		ChainTag(repo.ChainTag()).
+		Expiration(100).
+		Gas(21000).
+		Nonce(nonce).
+		Clause(tx.NewClause(&to)).
+		Build()
+	sig, err := crypto.Sign(trx.SigningHash().Bytes(), from.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return trx.WithSignature(sig)
+}
+
+// addTestBlock adds the block of txs with given receipts, which are not really executed.
+func addTestBlock(t *testing.T, repo *chain.Repository, parent *block.Block, conflicts uint32, txs tx.Transactions, receipts tx.Receipts) *block.Block {
+	builder := new(block.Builder).
+		ParentID(parent.Header().ID()).
+		Timestamp(parent.Header().Timestamp() + workshare.BlockInterval).
+		TotalScore(parent.Header().TotalScore() + 1)
+	for _, trx := range txs {
+		builder.Transaction(trx)
+	}
+	b := builder.Build()
+	pk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := crypto.Sign(b.Header().SigningHash().Bytes(), pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b = b.WithSignature(sig)
+	if err := repo.AddBlock(b, receipts, conflicts); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestBeat2Reader(t *testing.T) {
+	repo := newTestRepo(t)
+
+	var (
+		emitter      = workshare.BytesToAddress([]byte("emitter"))
+		otherEmitter = workshare.BytesToAddress([]byte("other"))
+		indexed      = workshare.BytesToAddress([]byte("indexed"))
+		topic        = workshare.BytesToBytes32([]byte("topic"))
+		tx1          = newTestTx(t, repo, 1, accounts[0])
+		tx2          = newTestTx(t, repo, 2, accounts[2])
+		tx3          = newTestTx(t, repo, 3, accounts[5])
+	)
+	// tx1 of accounts[0] emits an event with an indexed address, and transfers to accounts[1]
+	b1 := addTestBlock(t, repo, repo.GenesisBlock(), 0, tx.Transactions{tx1}, tx.Receipts{{
+		GasPayer: accounts[0].Address,
+		Outputs: []*tx.Output{{
+			Events:    tx.Events{{Address: emitter, Topics: []workshare.Bytes32{topic, workshare.BytesToBytes32(indexed.Bytes())}}},
+			Transfers: tx.Transfers{{Sender: accounts[0].Address, Recipient: accounts[1].Address, Amount: big.NewInt(1)}},
+		}},
+	}})
+	// tx2 of accounts[2] is paid by accounts[3], and transfers to accounts[4]
+	b2 := addTestBlock(t, repo, b1, 0, tx.Transactions{tx2}, tx.Receipts{{
+		GasPayer: accounts[3].Address,
+		Outputs: []*tx.Output{{
+			Events:    tx.Events{{Address: otherEmitter}},
+			Transfers: tx.Transfers{{Sender: accounts[2].Address, Recipient: accounts[4].Address, Amount: big.NewInt(2)}},
+		}},
+	}})
+	// tx3 of accounts[5] does nothing
+	b3 := addTestBlock(t, repo, b2, 0, tx.Transactions{tx3}, tx.Receipts{{GasPayer: accounts[5].Address, Outputs: []*tx.Output{{}}}})
+	if err := repo.SetBestBlockID(b3.Header().ID()); err != nil {
+		t.Fatal(err)
+	}
+
+	// the matched items of a filtered beat
+	type matched struct {
+		number    uint32
+		obsolete  bool
+		txs       []workshare.Bytes32
+		events    []workshare.Address // emitters
+		transfers []workshare.Address // recipients
+	}
+	readAll := func(br *beat2Reader) (beats []*Beat2Message, filtered []*matched) {
+		for {
+			msgs, ok, err := br.Read()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ok {
+				return
+			}
+			for _, msg := range msgs {
+				switch msg := msg.(type) {
+				case *Beat2Message:
+					beats = append(beats, msg)
+				case *FilteredBeat2Message:
+					m := &matched{number: msg.Number, obsolete: msg.Obsolete, txs: append([]workshare.Bytes32(nil), msg.Transactions...)}
+					for _, ev := range msg.Events {
+						assert.Equal(t, msg.Obsolete, ev.Obsolete)
+						assert.Equal(t, msg.ID, ev.Meta.BlockID)
+						m.events = append(m.events, ev.Address)
+					}
+					for _, tr := range msg.Transfers {
+						assert.Equal(t, msg.Obsolete, tr.Obsolete)
+						assert.Equal(t, msg.ID, tr.Meta.BlockID)
+						m.transfers = append(m.transfers, tr.Recipient)
+					}
+					filtered = append(filtered, m)
+				default:
+					t.Fatalf("unexpected message %T", msg)
+				}
+			}
+		}
+	}
+
+	t.Run("no watch set", func(t *testing.T) {
+		beats, filtered := readAll(newBeat2Reader(repo, repo.GenesisBlock().Header().ID(), nil))
+		assert.Nil(t, filtered)
+		if assert.Len(t, beats, 3) {
+			for i, b := range []*block.Block{b1, b2, b3} {
+				assert.Equal(t, b.Header().ID(), beats[i].ID)
+				assert.False(t, beats[i].Obsolete)
+			}
+		}
+	})
+
+	tests := []struct {
+		name     string
+		watchSet *WatchSet
+		want     []*matched
+	}{
+		{"tx origin", &WatchSet{TxOrigins: []workshare.Address{accounts[0].Address}},
+			[]*matched{{1, false, []workshare.Bytes32{tx1.ID()}, []workshare.Address{emitter}, []workshare.Address{accounts[1].Address}}}},
+		{"origin as address", &WatchSet{Addresses: []workshare.Address{accounts[2].Address}},
+			[]*matched{{2, false, []workshare.Bytes32{tx2.ID()}, nil, []workshare.Address{accounts[4].Address}}}},
+		{"gas payer", &WatchSet{Addresses: []workshare.Address{accounts[3].Address}},
+			[]*matched{{2, false, []workshare.Bytes32{tx2.ID()}, nil, nil}}},
+		{"transfer recipient", &WatchSet{Addresses: []workshare.Address{accounts[4].Address}},
+			[]*matched{{2, false, nil, nil, []workshare.Address{accounts[4].Address}}}},
+		{"event emitter", &WatchSet{Addresses: []workshare.Address{otherEmitter}},
+			[]*matched{{2, false, nil, []workshare.Address{otherEmitter}, nil}}},
+		{"event topic", &WatchSet{Topics: []workshare.Bytes32{topic}},
+			[]*matched{{1, false, nil, []workshare.Address{emitter}, nil}}},
+		{"indexed address", &WatchSet{Addresses: []workshare.Address{indexed}},
+			[]*matched{{1, false, nil, []workshare.Address{emitter}, nil}}},
+		{"multiple blocks", &WatchSet{Addresses: []workshare.Address{accounts[1].Address, accounts[5].Address}},
+			[]*matched{
+				{1, false, nil, nil, []workshare.Address{accounts[1].Address}},
+				{3, false, []workshare.Bytes32{tx3.ID()}, nil, nil},
+			}},
+		{"no match", &WatchSet{Addresses: []workshare.Address{accounts[8].Address}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			beats, filtered := readAll(newBeat2Reader(repo, repo.GenesisBlock().Header().ID(), tt.watchSet))
+			assert.Nil(t, beats)
+			assert.Equal(t, tt.want, filtered)
+		})
+	}
+
+	t.Run("reorg", func(t *testing.T) {
+		br := newBeat2Reader(repo, repo.GenesisBlock().Header().ID(), &WatchSet{Addresses: []workshare.Address{accounts[4].Address}})
+		_, filtered := readAll(br)
+		assert.Equal(t, []*matched{{2, false, nil, nil, []workshare.Address{accounts[4].Address}}}, filtered)
+
+		// b2 and b3 are replaced by a longer fork from b1, which has no matched items
+		b2x := addTestBlock(t, repo, b1, 1, tx.Transactions{newTestTx(t, repo, 4, accounts[6])}, tx.Receipts{{GasPayer: accounts[6].Address, Outputs: []*tx.Output{{}}}})
+		b3x := addTestBlock(t, repo, b2x, 0, nil, nil)
+		b4x := addTestBlock(t, repo, b3x, 0, nil, nil)
+		if err := repo.SetBestBlockID(b4x.Header().ID()); err != nil {
+			t.Fatal(err)
+		}
+		_, filtered = readAll(br)
+		assert.Equal(t, []*matched{{2, true, nil, nil, []workshare.Address{accounts[4].Address}}}, filtered)
+	})
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package subscriptions
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/miniBamboo/workshare/co"
+	"github.com/miniBamboo/workshare/txpool"
+)
+
+// max count of tx events queued for a subscriber.
+const maxPendingTxQueue = 1024
+
+var errPendingTxOverflow = errors.New("pending tx queue overflow")
+
+type pendingTxReader struct {
+	filter   *PendingTxFilter
+	expanded bool
+
+	sub    event.Subscription
+	signal co.Signal
+
+	lock     sync.Mutex
+	queue    []*txpool.TxEvent
+	overflow bool
+}
+
+func newPendingTxReader(pool *txpool.TxPool, filter *PendingTxFilter, expanded bool) *pendingTxReader {
+	r := &pendingTxReader{
+		filter:   filter,
+		expanded: expanded,
+	}
+	ch := make(chan *txpool.TxEvent, maxPendingTxQueue)
+	r.sub = pool.SubscribeTxEvent(ch)
+
+	// drain the feed at once, since the pool is blocked by slow subscribers.
+	go func() {
+		for {
+			select {
+			case ev := <-ch:
+				r.lock.Lock()
+				if len(r.queue) < maxPendingTxQueue {
+					r.queue = append(r.queue, ev)
+				} else {
+					r.overflow = true
+				}
+				r.lock.Unlock()
+				r.signal.Broadcast()
+			case <-r.sub.Err():
+				return
+			}
+		}
+	}()
+	return r
+}
+
+func (r *pendingTxReader) Read() ([]interface{}, bool, error) {
+	r.lock.Lock()
+	queue, overflow := r.queue, r.overflow
+	r.queue = nil
+	r.lock.Unlock()
+
+	if overflow {
+		return nil, false, errPendingTxOverflow
+	}
+
+	var msgs []interface{}
+	for _, ev := range queue {
+		if r.filter.Match(ev.Tx) {
+			msg, err := convertPendingTx(ev.Tx, ev.Executable, r.expanded)
+			if err != nil {
+				return nil, false, err
+			}
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs, false, nil
+}
+
+// NewTicker returns the waiter to be waken up when tx events arrive.
+func (r *pendingTxReader) NewTicker() co.Waiter {
+	return r.signal.NewWaiter()
+}
+
+// Close stops receiving tx events.
+func (r *pendingTxReader) Close() {
+	r.sub.Unsubscribe()
+}
@@ -0,0 +1,185 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package subscriptions
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/miniBamboo/workshare/chain"
+	"github.com/miniBamboo/workshare/genesis"
+	"github.com/miniBamboo/workshare/tx"
+	"github.com/miniBamboo/workshare/txpool"
+	"github.com/miniBamboo/workshare/workshare"
+	"github.com/stretchr/testify/assert"
+)
+
+func newPendingTx(t *testing.T, repo *chain.Repository, nonce uint64, from genesis.DevAccount, to ...*workshare.Address) *tx.Transaction {
+	builder := new(tx.Builder).
+		ChainTag(repo.ChainTag()).
+		Expiration(100).
+		Gas(100000).
+		Nonce(nonce)
+	for _, addr := range to {
+		builder.Clause(tx.NewClause(addr).WithValue(big.NewInt(1)).WithData([]byte{1, 2}))
+	}
+	trx := builder.Build()
+	sig, err := crypto.Sign(trx.SigningHash().Bytes(), from.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return trx.WithSignature(sig)
+}
+
+// waitQueued waits until the reader has queued tx events for the condition.
+func waitQueued(t *testing.T, r *pendingTxReader, cond func(queued int, overflow bool) bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		r.lock.Lock()
+		ok := cond(len(r.queue), r.overflow)
+		r.lock.Unlock()
+		if ok {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("tx events not queued")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// readPendingTxs reads messages of all the n tx events sent by the pool.
+func readPendingTxs(t *testing.T, r *pendingTxReader, n int) []*PendingTxMessage {
+	// the events are queued unfiltered
+	waitQueued(t, r, func(queued int, _ bool) bool { return queued == n })
+	read, ok, err := r.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, ok)
+	var msgs []*PendingTxMessage
+	for _, msg := range read {
+		msgs = append(msgs, msg.(*PendingTxMessage))
+	}
+	return msgs
+}
+
+func TestPendingTxReader(t *testing.T) {
+	repo := newTestRepo(t)
+	// the chain is far from synced, so that txs are added without evaluated against the state
+	pool := txpool.New(repo, nil, txpool.Options{Limit: 10000, LimitPerAccount: 10000, MaxLifetime: time.Hour})
+	defer pool.Close()
+
+	var (
+		origin = accounts[0].Address
+		to     = accounts[1].Address
+		other  = accounts[2].Address
+		txs    = []*tx.Transaction{
+			newPendingTx(t, repo, 1, accounts[0], &to),
+			newPendingTx(t, repo, 2, accounts[0], &other),
+			newPendingTx(t, repo, 3, accounts[0], nil, &other, &to),
+			newPendingTx(t, repo, 4, accounts[3], &to),
+			newPendingTx(t, repo, 5, accounts[3], nil),
+		}
+	)
+
+	tests := []struct {
+		name   string
+		filter *PendingTxFilter
+		want   []*tx.Transaction
+	}{
+		{"no filter", &PendingTxFilter{}, txs},
+		{"origin", &PendingTxFilter{Origin: &origin}, txs[:3]},
+		{"to", &PendingTxFilter{To: &to}, []*tx.Transaction{txs[0], txs[2], txs[3]}},
+		{"origin and to", &PendingTxFilter{Origin: &origin, To: &to}, []*tx.Transaction{txs[0], txs[2]}},
+	}
+	var readers []*pendingTxReader
+	for _, tt := range tests {
+		r := newPendingTxReader(pool, tt.filter, false)
+		defer r.Close()
+		readers = append(readers, r)
+	}
+	expanded := newPendingTxReader(pool, &PendingTxFilter{}, true)
+	defer expanded.Close()
+	// the reader wakes up on tx events
+	ticker := readers[0].NewTicker()
+
+	for _, trx := range txs {
+		if err := pool.Add(trx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	select {
+	case <-ticker.C():
+	case <-time.After(time.Second):
+		t.Fatal("reader not waken up")
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msgs := readPendingTxs(t, readers[i], len(txs))
+			var ids, want []workshare.Bytes32
+			for _, msg := range msgs {
+				ids = append(ids, msg.ID)
+				// the chain is not synced, so that the status is not evaluated
+				assert.Nil(t, msg.Executable)
+				assert.Nil(t, msg.Tx)
+			}
+			for _, trx := range tt.want {
+				want = append(want, trx.ID())
+			}
+			// txs are sent concurrently by the pool
+			assert.ElementsMatch(t, want, ids)
+		})
+	}
+
+	t.Run("expanded", func(t *testing.T) {
+		msgs := readPendingTxs(t, expanded, len(txs))
+		got := make(map[workshare.Bytes32]*PendingTx)
+		for _, msg := range msgs {
+			got[msg.ID] = msg.Tx
+		}
+		trx := txs[2]
+		ptx := got[trx.ID()]
+		if !assert.NotNil(t, ptx) {
+			return
+		}
+		br := trx.BlockRef()
+		assert.Equal(t, &PendingTx{
+			ChainTag:   repo.ChainTag(),
+			BlockRef:   hexutil.Encode(br[:]),
+			Expiration: 100,
+			Clauses: []*Clause{
+				{nil, math.HexOrDecimal256(*big.NewInt(1)), "0x0102"},
+				{&other, math.HexOrDecimal256(*big.NewInt(1)), "0x0102"},
+				{&to, math.HexOrDecimal256(*big.NewInt(1)), "0x0102"},
+			},
+			Gas:    100000,
+			Origin: origin,
+			Nonce:  3,
+			Size:   uint32(trx.Size()),
+		}, ptx)
+	})
+
+	t.Run("overflow", func(t *testing.T) {
+		r := newPendingTxReader(pool, &PendingTxFilter{}, false)
+		defer r.Close()
+
+		for i := 0; i <= maxPendingTxQueue; i++ {
+			if err := pool.Add(newPendingTx(t, repo, uint64(100+i), accounts[4], &to)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		waitQueued(t, r, func(_ int, overflow bool) bool { return overflow })
+		_, _, err := r.Read()
+		assert.Equal(t, errPendingTxOverflow, err)
+	})
+}
@@ -6,7 +6,9 @@
 package subscriptions
 
 import (
+	"fmt"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -16,6 +18,8 @@ import (
 	"github.com/miniBamboo/workshare/api/utils"
 	"github.com/miniBamboo/workshare/block"
 	"github.com/miniBamboo/workshare/chain"
+	"github.com/miniBamboo/workshare/co"
+	"github.com/miniBamboo/workshare/txpool"
 	"github.com/miniBamboo/workshare/workshare"
 	"github.com/pkg/errors"
 )
@@ -23,6 +27,7 @@ import (
 type Subscriptions struct {
 	backtraceLimit uint32
 	repo           *chain.Repository
+	txPool         *txpool.TxPool
 	upgrader       *websocket.Upgrader
 	done           chan struct{}
 	wg             sync.WaitGroup
@@ -32,6 +37,12 @@ type msgReader interface {
 	Read() (msgs []interface{}, hasMore bool, err error)
 }
 
+// tickerReader is the msgReader waken up by its own ticker, rather than best block change.
+type tickerReader interface {
+	msgReader
+	NewTicker() co.Waiter
+}
+
 var (
 	log = log15.New("pkg", "subscriptions")
 )
@@ -41,12 +52,15 @@ const (
 	pongWait = 60 * time.Second
 	// Send pings to peer with this period. Must be less than pongWait.
 	pingPeriod = (pongWait * 7) / 10
+	// Max count of addresses, topics and tx origins in a beat2 watch set.
+	maxWatchSetSize = 100
 )
 
-func New(repo *chain.Repository, allowedOrigins []string, backtraceLimit uint32) *Subscriptions {
+func New(repo *chain.Repository, allowedOrigins []string, backtraceLimit uint32, txPool *txpool.TxPool) *Subscriptions {
 	return &Subscriptions{
 		backtraceLimit: backtraceLimit,
 		repo:           repo,
+		txPool:         txPool,
 		upgrader: &websocket.Upgrader{
 			EnableCompression: true,
 			CheckOrigin: func(r *http.Request) bool {
@@ -79,37 +93,24 @@ func (s *Subscriptions) handleEventReader(w http.ResponseWriter, req *http.Reque
 	if err != nil {
 		return nil, err
 	}
-	address, err := parseAddress(req.URL.Query().Get("addr"))
+	address, err := parseAddresses(req.URL.Query().Get("addr"))
 	if err != nil {
 		return nil, utils.BadRequest(errors.WithMessage(err, "addr"))
 	}
-	t0, err := parseTopic(req.URL.Query().Get("t0"))
-	if err != nil {
-		return nil, utils.BadRequest(errors.WithMessage(err, "t0"))
-	}
-	t1, err := parseTopic(req.URL.Query().Get("t1"))
-	if err != nil {
-		return nil, utils.BadRequest(errors.WithMessage(err, "t1"))
-	}
-	t2, err := parseTopic(req.URL.Query().Get("t2"))
-	if err != nil {
-		return nil, utils.BadRequest(errors.WithMessage(err, "t2"))
-	}
-	t3, err := parseTopic(req.URL.Query().Get("t3"))
-	if err != nil {
-		return nil, utils.BadRequest(errors.WithMessage(err, "t3"))
-	}
-	t4, err := parseTopic(req.URL.Query().Get("t4"))
-	if err != nil {
-		return nil, utils.BadRequest(errors.WithMessage(err, "t4"))
+	var topics [5][]workshare.Bytes32
+	for i := range topics {
+		key := fmt.Sprintf("t%d", i)
+		if topics[i], err = parseTopics(req.URL.Query().Get(key)); err != nil {
+			return nil, utils.BadRequest(errors.WithMessage(err, key))
+		}
 	}
 	eventFilter := &EventFilter{
 		Address: address,
-		Topic0:  t0,
-		Topic1:  t1,
-		Topic2:  t2,
-		Topic3:  t3,
-		Topic4:  t4,
+		Topic0:  topics[0],
+		Topic1:  topics[1],
+		Topic2:  topics[2],
+		Topic3:  topics[3],
+		Topic4:  topics[4],
 	}
 	return newEventReader(s.repo, position, eventFilter), nil
 }
@@ -139,6 +140,31 @@ func (s *Subscriptions) handleTransferReader(w http.ResponseWriter, req *http.Re
 	return newTransferReader(s.repo, position, transferFilter), nil
 }
 
+func (s *Subscriptions) handleTokenTransferReader(w http.ResponseWriter, req *http.Request) (*tokenTransferReader, error) {
+	position, err := s.parsePosition(req.URL.Query().Get("pos"))
+	if err != nil {
+		return nil, err
+	}
+	token, err := parseAddress(req.URL.Query().Get("token"))
+	if err != nil {
+		return nil, utils.BadRequest(errors.WithMessage(err, "token"))
+	}
+	sender, err := parseAddress(req.URL.Query().Get("sender"))
+	if err != nil {
+		return nil, utils.BadRequest(errors.WithMessage(err, "sender"))
+	}
+	recipient, err := parseAddress(req.URL.Query().Get("recipient"))
+	if err != nil {
+		return nil, utils.BadRequest(errors.WithMessage(err, "recipient"))
+	}
+	filter := &TokenTransferFilter{
+		Token:     token,
+		Sender:    sender,
+		Recipient: recipient,
+	}
+	return newTokenTransferReader(s.repo, position, filter), nil
+}
+
 func (s *Subscriptions) handleBeatReader(w http.ResponseWriter, req *http.Request) (*beatReader, error) {
 	position, err := s.parsePosition(req.URL.Query().Get("pos"))
 	if err != nil {
@@ -152,7 +178,50 @@ func (s *Subscriptions) handleBeat2Reader(w http.ResponseWriter, req *http.Reque
 	if err != nil {
 		return nil, err
 	}
-	return newBeat2Reader(s.repo, position), nil
+	addrs, err := parseAddresses(req.URL.Query().Get("addr"))
+	if err != nil {
+		return nil, utils.BadRequest(errors.WithMessage(err, "addr"))
+	}
+	topics, err := parseTopics(req.URL.Query().Get("topic"))
+	if err != nil {
+		return nil, utils.BadRequest(errors.WithMessage(err, "topic"))
+	}
+	txOrigins, err := parseAddresses(req.URL.Query().Get("txOrigin"))
+	if err != nil {
+		return nil, utils.BadRequest(errors.WithMessage(err, "txOrigin"))
+	}
+	if len(addrs)+len(topics)+len(txOrigins) > maxWatchSetSize {
+		return nil, utils.BadRequest(fmt.Errorf("watch set: exceeds limit %d", maxWatchSetSize))
+	}
+	var watchSet *WatchSet
+	if len(addrs) > 0 || len(topics) > 0 || len(txOrigins) > 0 {
+		watchSet = &WatchSet{
+			Addresses: addrs,
+			Topics:    topics,
+			TxOrigins: txOrigins,
+		}
+	}
+	return newBeat2Reader(s.repo, position, watchSet), nil
+}
+
+func (s *Subscriptions) handlePendingTxReader(w http.ResponseWriter, req *http.Request) (*pendingTxReader, error) {
+	origin, err := parseAddress(req.URL.Query().Get("origin"))
+	if err != nil {
+		return nil, utils.BadRequest(errors.WithMessage(err, "origin"))
+	}
+	to, err := parseAddress(req.URL.Query().Get("to"))
+	if err != nil {
+		return nil, utils.BadRequest(errors.WithMessage(err, "to"))
+	}
+	expanded := req.URL.Query().Get("expanded")
+	if expanded != "" && expanded != "false" && expanded != "true" {
+		return nil, utils.BadRequest(errors.WithMessage(errors.New("should be boolean"), "expanded"))
+	}
+	filter := &PendingTxFilter{
+		Origin: origin,
+		To:     to,
+	}
+	return newPendingTxReader(s.txPool, filter, expanded == "true"), nil
 }
 
 func (s *Subscriptions) handleSubject(w http.ResponseWriter, req *http.Request) error {
@@ -176,6 +245,10 @@ func (s *Subscriptions) handleSubject(w http.ResponseWriter, req *http.Request)
 		if reader, err = s.handleTransferReader(w, req); err != nil {
 			return err
 		}
+	case "token-transfer":
+		if reader, err = s.handleTokenTransferReader(w, req); err != nil {
+			return err
+		}
 	case "beat":
 		if reader, err = s.handleBeatReader(w, req); err != nil {
 			return err
@@ -184,6 +257,13 @@ func (s *Subscriptions) handleSubject(w http.ResponseWriter, req *http.Request)
 		if reader, err = s.handleBeat2Reader(w, req); err != nil {
 			return err
 		}
+	case "txpool":
+		pendingTxReader, err := s.handlePendingTxReader(w, req)
+		if err != nil {
+			return err
+		}
+		defer pendingTxReader.Close()
+		reader = pendingTxReader
 	default:
 		return utils.HTTPError(errors.New("not found"), http.StatusNotFound)
 	}
@@ -234,6 +314,9 @@ func (s *Subscriptions) pipe(conn *websocket.Conn, reader msgReader) error {
 		}
 	}()
 	ticker := s.repo.NewTicker()
+	if r, ok := reader.(tickerReader); ok {
+		ticker = r.NewTicker()
+	}
 	pingTicker := time.NewTicker(pingPeriod)
 	defer pingTicker.Stop()
 	for {
@@ -285,15 +368,36 @@ func (s *Subscriptions) parsePosition(posStr string) (workshare.Bytes32, error)
 	return pos, nil
 }
 
-func parseTopic(t string) (*workshare.Bytes32, error) {
+// parseTopics parses comma separated topics.
+func parseTopics(t string) ([]workshare.Bytes32, error) {
 	if t == "" {
 		return nil, nil
 	}
-	topic, err := workshare.ParseBytes32(t)
-	if err != nil {
-		return nil, err
+	var topics []workshare.Bytes32
+	for _, str := range strings.Split(t, ",") {
+		topic, err := workshare.ParseBytes32(strings.TrimSpace(str))
+		if err != nil {
+			return nil, err
+		}
+		topics = append(topics, topic)
+	}
+	return topics, nil
+}
+
+// parseAddresses parses comma separated addresses.
+func parseAddresses(addrs string) ([]workshare.Address, error) {
+	if addrs == "" {
+		return nil, nil
+	}
+	var addresses []workshare.Address
+	for _, str := range strings.Split(addrs, ",") {
+		address, err := workshare.ParseAddress(strings.TrimSpace(str))
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, address)
 	}
-	return &topic, nil
+	return addresses, nil
 }
 
 func parseAddress(addr string) (*workshare.Address, error) {
@@ -0,0 +1,56 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package subscriptions
+
+import (
+	"github.com/miniBamboo/workshare/chain"
+	"github.com/miniBamboo/workshare/logdb"
+	"github.com/miniBamboo/workshare/workshare"
+)
+
+type tokenTransferReader struct {
+	repo        *chain.Repository
+	filter      *TokenTransferFilter
+	blockReader chain.BlockReader
+}
+
+func newTokenTransferReader(repo *chain.Repository, position workshare.Bytes32, filter *TokenTransferFilter) *tokenTransferReader {
+	return &tokenTransferReader{
+		repo:        repo,
+		filter:      filter,
+		blockReader: repo.NewBlockReader(position),
+	}
+}
+
+func (tr *tokenTransferReader) Read() ([]interface{}, bool, error) {
+	blocks, err := tr.blockReader.Read()
+	if err != nil {
+		return nil, false, err
+	}
+	var msgs []interface{}
+	for _, block := range blocks {
+		receipts, err := tr.repo.GetBlockReceipts(block.Header().ID())
+		if err != nil {
+			return nil, false, err
+		}
+		txs := block.Transactions()
+		for i, receipt := range receipts {
+			for j, output := range receipt.Outputs {
+				for _, event := range output.Events {
+					sender, recipient, amount, ok := logdb.DecodeTokenTransfer(event)
+					if ok && tr.filter.Match(event.Address, sender, recipient) {
+						msg, err := convertTokenTransfer(block.Header(), txs[i], uint32(j), event, sender, recipient, amount, block.Obsolete)
+						if err != nil {
+							return nil, false, err
+						}
+						msgs = append(msgs, msg)
+					}
+				}
+			}
+		}
+	}
+	return msgs, len(blocks) > 0, nil
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package subscriptions
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/miniBamboo/workshare/logdb"
+	"github.com/miniBamboo/workshare/tx"
+	"github.com/miniBamboo/workshare/workshare"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTokenTransferEvent(token, sender, recipient workshare.Address, amount int64) *tx.Event {
+	return &tx.Event{
+		Address: token,
+		Topics: []workshare.Bytes32{
+			logdb.TokenTransferEventID,
+			workshare.BytesToBytes32(sender.Bytes()),
+			workshare.BytesToBytes32(recipient.Bytes()),
+		},
+		Data: workshare.BytesToBytes32(big.NewInt(amount).Bytes()).Bytes(),
+	}
+}
+
+func TestTokenTransferReader(t *testing.T) {
+	repo := newTestRepo(t)
+
+	var (
+		tokenA = workshare.BytesToAddress([]byte("tokenA"))
+		tokenB = workshare.BytesToAddress([]byte("tokenB"))
+		alice  = accounts[1].Address
+		bob    = accounts[2].Address
+		tx1    = newTestTx(t, repo, 1, accounts[0])
+		tx2    = newTestTx(t, repo, 2, accounts[0])
+	)
+	b1 := addTestBlock(t, repo, repo.GenesisBlock(), 0, tx.Transactions{tx1}, tx.Receipts{{Outputs: []*tx.Output{
+		{Events: tx.Events{
+			newTokenTransferEvent(tokenA, alice, bob, 1),
+			// not token transfers, with an unpadded address or another event id
+			{Address: tokenA, Topics: []workshare.Bytes32{logdb.TokenTransferEventID, {1}, {}}, Data: make([]byte, 32)},
+			{Address: tokenA, Topics: []workshare.Bytes32{{1}, {}, {}}, Data: make([]byte, 32)},
+		}},
+		{Events: tx.Events{newTokenTransferEvent(tokenB, bob, alice, 2)}},
+	}}})
+	b2 := addTestBlock(t, repo, b1, 0, tx.Transactions{tx2}, tx.Receipts{{Outputs: []*tx.Output{
+		{Events: tx.Events{newTokenTransferEvent(tokenA, bob, alice, 3)}},
+	}}})
+	if err := repo.SetBestBlockID(b2.Header().ID()); err != nil {
+		t.Fatal(err)
+	}
+
+	readAll := func(r *tokenTransferReader) (msgs []*TokenTransferMessage) {
+		for {
+			read, ok, err := r.Read()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ok {
+				return
+			}
+			for _, msg := range read {
+				msgs = append(msgs, msg.(*TokenTransferMessage))
+			}
+		}
+	}
+	amounts := func(msgs []*TokenTransferMessage) (amounts []int64) {
+		for _, msg := range msgs {
+			amounts = append(amounts, (*big.Int)(msg.Amount).Int64())
+		}
+		return
+	}
+
+	t.Run("message", func(t *testing.T) {
+		msgs := readAll(newTokenTransferReader(repo, b1.Header().ParentID(), &TokenTransferFilter{Token: &tokenB}))
+		if assert.Len(t, msgs, 1) {
+			amount := math.HexOrDecimal256(*big.NewInt(2))
+			assert.Equal(t, &TokenTransferMessage{
+				Token:     tokenB,
+				Sender:    bob,
+				Recipient: alice,
+				Amount:    &amount,
+				Meta: LogMeta{
+					BlockID:        b1.Header().ID(),
+					BlockNumber:    1,
+					BlockTimestamp: b1.Header().Timestamp(),
+					TxID:           tx1.ID(),
+					TxOrigin:       accounts[0].Address,
+					ClauseIndex:    1,
+				},
+			}, msgs[0])
+		}
+	})
+
+	tests := []struct {
+		name   string
+		filter *TokenTransferFilter
+		want   []int64
+	}{
+		{"no filter", &TokenTransferFilter{}, []int64{1, 2, 3}},
+		{"token", &TokenTransferFilter{Token: &tokenA}, []int64{1, 3}},
+		{"sender", &TokenTransferFilter{Sender: &bob}, []int64{2, 3}},
+		{"recipient", &TokenTransferFilter{Recipient: &alice}, []int64{2, 3}},
+		{"all fields", &TokenTransferFilter{Token: &tokenA, Sender: &bob, Recipient: &alice}, []int64{3}},
+		{"no match", &TokenTransferFilter{Token: &tokenB, Sender: &alice}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, amounts(readAll(newTokenTransferReader(repo, b1.Header().ParentID(), tt.filter))))
+		})
+	}
+
+	t.Run("reorg", func(t *testing.T) {
+		r := newTokenTransferReader(repo, b1.Header().ParentID(), &TokenTransferFilter{Token: &tokenA})
+		assert.Equal(t, []int64{1, 3}, amounts(readAll(r)))
+
+		// b2 is replaced by a longer fork from b1
+		b2x := addTestBlock(t, repo, b1, 1, nil, nil)
+		b3x := addTestBlock(t, repo, b2x, 0, nil, nil)
+		if err := repo.SetBestBlockID(b3x.Header().ID()); err != nil {
+			t.Fatal(err)
+		}
+		msgs := readAll(r)
+		if assert.Len(t, msgs, 1) {
+			assert.Equal(t, b2.Header().ID(), msgs[0].Meta.BlockID)
+			assert.True(t, msgs[0].Obsolete)
+		}
+	})
+}
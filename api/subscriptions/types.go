@@ -6,6 +6,8 @@
 package subscriptions
 
 import (
+	"math/big"
+
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/miniBamboo/workshare/block"
@@ -106,6 +108,39 @@ func convertTransfer(header *block.Header, tx *tx.Transaction, clauseIndex uint3
 	}, nil
 }
 
+// TokenTransferMessage token transfer piped by websocket
+type TokenTransferMessage struct {
+	Token     workshare.Address     `json:"token"`
+	Sender    workshare.Address     `json:"sender"`
+	Recipient workshare.Address     `json:"recipient"`
+	Amount    *math.HexOrDecimal256 `json:"amount"`
+	Meta      LogMeta               `json:"meta"`
+	Obsolete  bool                  `json:"obsolete"`
+}
+
+func convertTokenTransfer(header *block.Header, tx *tx.Transaction, clauseIndex uint32, event *tx.Event, sender, recipient workshare.Address, amount *big.Int, obsolete bool) (*TokenTransferMessage, error) {
+	origin, err := tx.Origin()
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenTransferMessage{
+		Token:     event.Address,
+		Sender:    sender,
+		Recipient: recipient,
+		Amount:    (*math.HexOrDecimal256)(amount),
+		Meta: LogMeta{
+			BlockID:        header.ID(),
+			BlockNumber:    header.Number(),
+			BlockTimestamp: header.Timestamp(),
+			TxID:           tx.ID(),
+			TxOrigin:       origin,
+			ClauseIndex:    clauseIndex,
+		},
+		Obsolete: obsolete,
+	}, nil
+}
+
 //EventMessage event piped by websocket
 type EventMessage struct {
 	Address  workshare.Address   `json:"address"`
@@ -137,30 +172,42 @@ func convertEvent(header *block.Header, tx *tx.Transaction, clauseIndex uint32,
 }
 
 // EventFilter contains options for contract event filtering.
+// Each field is an OR-set, that an empty set matches any value.
 type EventFilter struct {
-	Address *workshare.Address // restricts matches to events created by specific contracts
-	Topic0  *workshare.Bytes32
-	Topic1  *workshare.Bytes32
-	Topic2  *workshare.Bytes32
-	Topic3  *workshare.Bytes32
-	Topic4  *workshare.Bytes32
+	Address []workshare.Address // restricts matches to events created by specific contracts
+	Topic0  []workshare.Bytes32
+	Topic1  []workshare.Bytes32
+	Topic2  []workshare.Bytes32
+	Topic3  []workshare.Bytes32
+	Topic4  []workshare.Bytes32
 }
 
 // Match returs whether event matches filter
 func (ef *EventFilter) Match(event *tx.Event) bool {
-	if (ef.Address != nil) && (*ef.Address != event.Address) {
-		return false
+	if len(ef.Address) > 0 {
+		matched := false
+		for _, addr := range ef.Address {
+			if addr == event.Address {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
 	}
 
-	matchTopic := func(topic *workshare.Bytes32, index int) bool {
-		if topic != nil {
+	matchTopic := func(topics []workshare.Bytes32, index int) bool {
+		if len(topics) > 0 {
 			if len(event.Topics) <= index {
 				return false
 			}
-
-			if *topic != event.Topics[index] {
-				return false
+			for _, topic := range topics {
+				if topic == event.Topics[index] {
+					return true
+				}
 			}
+			return false
 		}
 		return true
 	}
@@ -195,6 +242,28 @@ func (tf *TransferFilter) Match(transfer *tx.Transfer, origin workshare.Address)
 	return true
 }
 
+type TokenTransferFilter struct {
+	Token     *workshare.Address // the token contract
+	Sender    *workshare.Address
+	Recipient *workshare.Address
+}
+
+// Match returns whether the token transfer matches filter
+func (tf *TokenTransferFilter) Match(token, sender, recipient workshare.Address) bool {
+	if (tf.Token != nil) && (*tf.Token != token) {
+		return false
+	}
+
+	if (tf.Sender != nil) && (*tf.Sender != sender) {
+		return false
+	}
+
+	if (tf.Recipient != nil) && (*tf.Recipient != recipient) {
+		return false
+	}
+	return true
+}
+
 type BeatMessage struct {
 	Number      uint32            `json:"number"`
 	ID          workshare.Bytes32 `json:"id"`
@@ -217,3 +286,160 @@ type Beat2Message struct {
 	K           uint8             `json:"k"`
 	Obsolete    bool              `json:"obsolete"`
 }
+
+// WatchSet contains options for beat2 filtering. Each field is an OR-set, and
+// a tx, event or transfer is matched if it hits any value.
+type WatchSet struct {
+	Addresses []workshare.Address // matches event emitters and indexed params, transfer parties, tx origins and gas payers
+	Topics    []workshare.Bytes32 // matches event topics at any position
+	TxOrigins []workshare.Address // matches txs, and all events and transfers in them
+}
+
+func (ws *WatchSet) hasAddress(addr workshare.Address) bool {
+	for _, a := range ws.Addresses {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
+
+func (ws *WatchSet) hasTxOrigin(origin workshare.Address) bool {
+	for _, o := range ws.TxOrigins {
+		if o == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchTx returns whether the tx is sent or paid by watched addresses.
+func (ws *WatchSet) MatchTx(origin workshare.Address, gasPayer workshare.Address) bool {
+	return ws.hasTxOrigin(origin) || ws.hasAddress(origin) || ws.hasAddress(gasPayer)
+}
+
+// MatchEvent returns whether the event matches the watch set.
+// Topics are also matched against addresses, since indexed address params are left padded into topics.
+func (ws *WatchSet) MatchEvent(event *tx.Event, origin workshare.Address) bool {
+	if ws.hasTxOrigin(origin) || ws.hasAddress(event.Address) {
+		return true
+	}
+	for _, topic := range event.Topics {
+		for _, t := range ws.Topics {
+			if t == topic {
+				return true
+			}
+		}
+		if workshare.BytesToBytes32(topic[:12]).IsZero() && ws.hasAddress(workshare.BytesToAddress(topic[12:])) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchTransfer returns whether the transfer matches the watch set.
+func (ws *WatchSet) MatchTransfer(transfer *tx.Transfer, origin workshare.Address) bool {
+	return ws.hasTxOrigin(origin) || ws.hasAddress(transfer.Sender) || ws.hasAddress(transfer.Recipient)
+}
+
+// FilteredBeat2Message is the beat2 of a block touching the watch set, with matched items inlined.
+type FilteredBeat2Message struct {
+	Beat2Message
+	Transactions []workshare.Bytes32 `json:"transactions"`
+	Events       []*EventMessage     `json:"events"`
+	Transfers    []*TransferMessage  `json:"transfers"`
+}
+
+// Clause clause of pending tx.
+type Clause struct {
+	To    *workshare.Address   `json:"to"`
+	Value math.HexOrDecimal256 `json:"value"`
+	Data  string               `json:"data"`
+}
+
+// PendingTx the full pending tx.
+type PendingTx struct {
+	ChainTag     byte                `json:"chainTag"`
+	BlockRef     string              `json:"blockRef"`
+	Expiration   uint32              `json:"expiration"`
+	Clauses      []*Clause           `json:"clauses"`
+	GasPriceCoef uint8               `json:"gasPriceCoef"`
+	Gas          uint64              `json:"gas"`
+	Origin       workshare.Address   `json:"origin"`
+	Delegator    *workshare.Address  `json:"delegator"`
+	Nonce        math.HexOrDecimal64 `json:"nonce"`
+	DependsOn    *workshare.Bytes32  `json:"dependsOn"`
+	Size         uint32              `json:"size"`
+}
+
+// PendingTxMessage is piped when a tx is added into the pool, or its executable status changed.
+type PendingTxMessage struct {
+	ID         workshare.Bytes32 `json:"id"`
+	Executable *bool             `json:"executable"` // nil if the status is not evaluated
+	Tx         *PendingTx        `json:"tx,omitempty"`
+}
+
+func convertPendingTx(trx *tx.Transaction, executable *bool, expanded bool) (*PendingTxMessage, error) {
+	msg := &PendingTxMessage{
+		ID:         trx.ID(),
+		Executable: executable,
+	}
+	if !expanded {
+		return msg, nil
+	}
+	origin, err := trx.Origin()
+	if err != nil {
+		return nil, err
+	}
+	delegator, err := trx.Delegator()
+	if err != nil {
+		return nil, err
+	}
+	clauses := make([]*Clause, len(trx.Clauses()))
+	for i, c := range trx.Clauses() {
+		clauses[i] = &Clause{
+			c.To(),
+			math.HexOrDecimal256(*c.Value()),
+			hexutil.Encode(c.Data()),
+		}
+	}
+	br := trx.BlockRef()
+	msg.Tx = &PendingTx{
+		ChainTag:     trx.ChainTag(),
+		BlockRef:     hexutil.Encode(br[:]),
+		Expiration:   trx.Expiration(),
+		Clauses:      clauses,
+		GasPriceCoef: trx.GasPriceCoef(),
+		Gas:          trx.Gas(),
+		Origin:       origin,
+		Delegator:    delegator,
+		Nonce:        math.HexOrDecimal64(trx.Nonce()),
+		DependsOn:    trx.DependsOn(),
+		Size:         uint32(trx.Size()),
+	}
+	return msg, nil
+}
+
+// PendingTxFilter contains options for pending tx filtering.
+type PendingTxFilter struct {
+	Origin *workshare.Address // who send transaction
+	To     *workshare.Address // matches if any clause is sent to
+}
+
+// Match returns whether the pending tx matches filter
+func (pf *PendingTxFilter) Match(trx *tx.Transaction) bool {
+	if pf.Origin != nil {
+		if origin, err := trx.Origin(); err != nil || origin != *pf.Origin {
+			return false
+		}
+	}
+	if pf.To != nil {
+		for _, c := range trx.Clauses() {
+			if to := c.To(); to != nil && *to == *pf.To {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
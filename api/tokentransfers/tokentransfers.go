@@ -0,0 +1,82 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package tokentransfers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/miniBamboo/workshare/api/events"
+	"github.com/miniBamboo/workshare/api/utils"
+	"github.com/miniBamboo/workshare/chain"
+	"github.com/miniBamboo/workshare/logdb"
+	"github.com/pkg/errors"
+)
+
+// TokenTransfers serves transfers of fungible tokens, decoded from standard Transfer(address,address,uint256) events.
+type TokenTransfers struct {
+	repo *chain.Repository
+	db   *logdb.LogDB
+}
+
+func New(repo *chain.Repository, db *logdb.LogDB) *TokenTransfers {
+	return &TokenTransfers{
+		repo,
+		db,
+	}
+}
+
+// filter query logs with option, and returns the cursor of the next page if any.
+func (t *TokenTransfers) filter(ctx context.Context, filter *TokenTransferFilter) ([]*FilteredTokenTransfer, *logdb.Cursor, error) {
+	rng, err := events.ConvertRange(t.repo.NewBestChain(), filter.Range)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	transfers, err := t.db.FilterTokenTransfers(ctx, &logdb.TokenTransferFilter{
+		CriteriaSet: filter.CriteriaSet,
+		Range:       rng,
+		Options:     filter.Options,
+		Order:       filter.Order,
+	})
+	if err != nil {
+		return nil, nil, events.ConvertFilterError(err)
+	}
+	tLogs := make([]*FilteredTokenTransfer, len(transfers))
+	for i, trans := range transfers {
+		tLogs[i] = convertTokenTransfer(trans)
+	}
+	var next *logdb.Cursor
+	if n := len(transfers); n > 0 && filter.Options != nil && uint64(n) == filter.Options.Limit {
+		next = transfers[n-1].Cursor()
+	}
+	return tLogs, next, nil
+}
+
+func (t *TokenTransfers) handleFilterTokenTransferLogs(w http.ResponseWriter, req *http.Request) error {
+	var filter TokenTransferFilter
+	if err := utils.ParseJSON(req.Body, &filter); err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "body"))
+	}
+	if err := events.ValidateOptions(filter.Options); err != nil {
+		return err
+	}
+	tLogs, next, err := t.filter(req.Context(), &filter)
+	if err != nil {
+		return err
+	}
+	if next != nil {
+		w.Header().Set(events.NextCursorHeader, next.String())
+	}
+	return utils.WriteJSON(w, tLogs)
+}
+
+func (t *TokenTransfers) Mount(root *mux.Router, pathPrefix string) {
+	sub := root.PathPrefix(pathPrefix).Subrouter()
+
+	sub.Path("").Methods("POST").HandlerFunc(utils.WrapHandlerFunc(t.handleFilterTokenTransferLogs))
+}
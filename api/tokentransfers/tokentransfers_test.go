@@ -0,0 +1,234 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package tokentransfers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gorilla/mux"
+	"github.com/miniBamboo/workshare/api/events"
+	"github.com/miniBamboo/workshare/api/tokentransfers"
+	"github.com/miniBamboo/workshare/block"
+	"github.com/miniBamboo/workshare/chain"
+	"github.com/miniBamboo/workshare/genesis"
+	"github.com/miniBamboo/workshare/logdb"
+	"github.com/miniBamboo/workshare/muxdb"
+	"github.com/miniBamboo/workshare/state"
+	"github.com/miniBamboo/workshare/tx"
+	"github.com/miniBamboo/workshare/workshare"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	ts      *httptest.Server
+	tokens  = []workshare.Address{workshare.BytesToAddress([]byte("tokenA")), workshare.BytesToAddress([]byte("tokenB"))}
+	holders = []workshare.Address{workshare.BytesToAddress([]byte("h0")), workshare.BytesToAddress([]byte("h1")), workshare.BytesToAddress([]byte("h2"))}
+	// the token transfer of each block, from block 1
+	transfers  []*tokentransfers.FilteredTokenTransfer
+	launchTime uint64
+)
+
+func TestTokenTransfers(t *testing.T) {
+	initTokenTransfersServer(t)
+	defer ts.Close()
+
+	all := func(nums ...int) (want []*tokentransfers.FilteredTokenTransfer) {
+		for _, n := range nums {
+			want = append(want, transfers[n-1])
+		}
+		return
+	}
+	tests := []struct {
+		name   string
+		filter map[string]interface{}
+		want   []*tokentransfers.FilteredTokenTransfer
+	}{
+		{"all", map[string]interface{}{}, all(1, 2, 3, 4, 5)},
+		{"token", map[string]interface{}{
+			"criteriaSet": []map[string]interface{}{{"token": tokens[0].String()}},
+		}, all(1, 3, 5)},
+		{"sender", map[string]interface{}{
+			"criteriaSet": []map[string]interface{}{{"sender": holders[1].String()}},
+		}, all(1, 4)},
+		{"all fields of criteria", map[string]interface{}{
+			"criteriaSet": []map[string]interface{}{{"token": tokens[1].String(), "sender": holders[2].String(), "recipient": holders[0].String()}},
+		}, all(2)},
+		{"any of criteria", map[string]interface{}{
+			"criteriaSet": []map[string]interface{}{{"recipient": holders[0].String()}, {"token": tokens[1].String()}},
+		}, all(2, 4, 5)},
+		{"block range", map[string]interface{}{
+			"range": map[string]interface{}{"unit": "block", "from": 2, "to": 3},
+		}, all(2, 3)},
+		{"time range", map[string]interface{}{
+			"range": map[string]interface{}{"unit": "time", "from": launchTime + 15, "to": launchTime + 40},
+		}, all(2, 3, 4)},
+		{"time range after head", map[string]interface{}{
+			"range": map[string]interface{}{"unit": "time", "from": launchTime + 100, "to": launchTime + 200},
+		}, nil},
+		{"desc", map[string]interface{}{
+			"criteriaSet": []map[string]interface{}{{"token": tokens[0].String()}},
+			"order":       "desc",
+		}, all(5, 3, 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, next := filter(t, tt.filter, http.StatusOK)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, "", next)
+		})
+	}
+
+	t.Run("cursor paging", func(t *testing.T) {
+		for _, order := range []string{"asc", "desc"} {
+			var (
+				pages  [][]*tokentransfers.FilteredTokenTransfer
+				cursor string
+			)
+			for {
+				options := map[string]interface{}{"limit": 2}
+				if cursor != "" {
+					options["cursor"] = cursor
+				}
+				got, next := filter(t, map[string]interface{}{"options": options, "order": order}, http.StatusOK)
+				pages = append(pages, got)
+				if next == "" {
+					break
+				}
+				cursor = next
+			}
+			if order == "asc" {
+				assert.Equal(t, [][]*tokentransfers.FilteredTokenTransfer{all(1, 2), all(3, 4), all(5)}, pages)
+			} else {
+				assert.Equal(t, [][]*tokentransfers.FilteredTokenTransfer{all(5, 4), all(3, 2), all(1)}, pages)
+			}
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, cursor := filter(t, map[string]interface{}{"options": map[string]interface{}{"limit": 2}}, http.StatusOK)
+		filter(t, map[string]interface{}{"options": map[string]interface{}{"limit": 2, "offset": 1, "cursor": cursor}}, http.StatusBadRequest)
+		filter(t, map[string]interface{}{"options": map[string]interface{}{"limit": 2, "cursor": "invalid"}}, http.StatusBadRequest)
+		filter(t, map[string]interface{}{"criteriaSet": []map[string]interface{}{{"token": "0xzz"}}}, http.StatusBadRequest)
+	})
+}
+
+// filter posts the filter, and returns the token transfers and the next cursor.
+func filter(t *testing.T, filter map[string]interface{}, wantStatus int) ([]*tokentransfers.FilteredTokenTransfer, string) {
+	data, err := json.Marshal(filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.Post(ts.URL+"/logs/tokenTransfer", "application/json", bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.Equal(t, wantStatus, res.StatusCode, string(body)) || wantStatus != http.StatusOK {
+		return nil, ""
+	}
+	var got []*tokentransfers.FilteredTokenTransfer
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) == 0 {
+		got = nil
+	}
+	return got, res.Header.Get(events.NextCursorHeader)
+}
+
+// initTokenTransfersServer builds 5 blocks, each has a token transfer.
+func initTokenTransfersServer(t *testing.T) {
+	muxDB := muxdb.NewMem()
+	b, _, _, err := genesis.NewDevnet().Build(state.NewStater(muxDB))
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := chain.NewRepository(muxDB, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := logdb.NewMem()
+	if err != nil {
+		t.Fatal(err)
+	}
+	launchTime = b.Header().Timestamp()
+
+	w := db.NewWriter()
+	for n := 1; n <= 5; n++ {
+		var (
+			token     = tokens[(n+1)%2]
+			sender    = holders[n%3]
+			recipient = holders[(n+1)%3]
+			amount    = big.NewInt(int64(n))
+			account   = genesis.DevAccounts()[n]
+		)
+		trx := new(tx.Builder).ChainTag(repo.ChainTag()).Expiration(100).Gas(21000).Nonce(uint64(n)).Build()
+		sig, err := crypto.Sign(trx.SigningHash().Bytes(), account.PrivateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		trx = trx.WithSignature(sig)
+
+		b = new(block.Builder).
+			ParentID(b.Header().ID()).
+			Timestamp(launchTime + uint64(n)*10).
+			TotalScore(b.Header().TotalScore() + 1).
+			Transaction(trx).
+			Build()
+		receipts := tx.Receipts{{Outputs: []*tx.Output{{Events: tx.Events{{
+			Address: token,
+			Topics: []workshare.Bytes32{
+				logdb.TokenTransferEventID,
+				workshare.BytesToBytes32(sender.Bytes()),
+				workshare.BytesToBytes32(recipient.Bytes()),
+			},
+			Data: workshare.BytesToBytes32(amount.Bytes()).Bytes(),
+		}}}}}}
+		if err := repo.AddBlock(b, receipts, 0); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Write(b, receipts); err != nil {
+			t.Fatal(err)
+		}
+
+		v := math.HexOrDecimal256(*amount)
+		transfers = append(transfers, &tokentransfers.FilteredTokenTransfer{
+			Token:     token,
+			Sender:    sender,
+			Recipient: recipient,
+			Amount:    &v,
+			Meta: events.LogMeta{
+				BlockID:        b.Header().ID(),
+				BlockNumber:    b.Header().Number(),
+				BlockTimestamp: b.Header().Timestamp(),
+				TxID:           trx.ID(),
+				TxOrigin:       account.Address,
+			},
+		})
+	}
+	if err := w.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.SetBestBlockID(b.Header().ID()); err != nil {
+		t.Fatal(err)
+	}
+
+	router := mux.NewRouter()
+	tokentransfers.New(repo, db).Mount(router, "/logs/tokenTransfer")
+	ts = httptest.NewServer(router)
+}
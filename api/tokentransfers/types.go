@@ -0,0 +1,46 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package tokentransfers
+
+import (
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/miniBamboo/workshare/api/events"
+	"github.com/miniBamboo/workshare/logdb"
+	"github.com/miniBamboo/workshare/workshare"
+)
+
+type FilteredTokenTransfer struct {
+	Token     workshare.Address     `json:"token"`
+	Sender    workshare.Address     `json:"sender"`
+	Recipient workshare.Address     `json:"recipient"`
+	Amount    *math.HexOrDecimal256 `json:"amount"`
+	Meta      events.LogMeta        `json:"meta"`
+}
+
+func convertTokenTransfer(transfer *logdb.TokenTransfer) *FilteredTokenTransfer {
+	v := math.HexOrDecimal256(*transfer.Amount)
+	return &FilteredTokenTransfer{
+		Token:     transfer.Token,
+		Sender:    transfer.Sender,
+		Recipient: transfer.Recipient,
+		Amount:    &v,
+		Meta: events.LogMeta{
+			BlockID:        transfer.BlockID,
+			BlockNumber:    transfer.BlockNumber,
+			BlockTimestamp: transfer.BlockTime,
+			TxID:           transfer.TxID,
+			TxOrigin:       transfer.TxOrigin,
+			ClauseIndex:    transfer.ClauseIndex,
+		},
+	}
+}
+
+type TokenTransferFilter struct {
+	CriteriaSet []*logdb.TokenTransferCriteria
+	Range       *events.Range
+	Options     *logdb.Options
+	Order       logdb.Order //default asc
+}
@@ -11,22 +11,35 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/gorilla/mux"
+	"github.com/miniBamboo/workshare/abi"
+	"github.com/miniBamboo/workshare/api/abis"
 	"github.com/miniBamboo/workshare/api/utils"
 	"github.com/miniBamboo/workshare/chain"
+	"github.com/miniBamboo/workshare/runtime"
+	"github.com/miniBamboo/workshare/state"
+	"github.com/miniBamboo/workshare/tx"
 	"github.com/miniBamboo/workshare/txpool"
+	"github.com/miniBamboo/workshare/vm"
 	"github.com/miniBamboo/workshare/workshare"
+	"github.com/miniBamboo/workshare/xenv"
 	"github.com/pkg/errors"
 )
 
 type Transactions struct {
-	repo *chain.Repository
-	pool *txpool.TxPool
+	repo       *chain.Repository
+	stater     *state.Stater
+	pool       *txpool.TxPool
+	forkConfig workshare.ForkConfig
+	abis       *abis.Registry
 }
 
-func New(repo *chain.Repository, pool *txpool.TxPool) *Transactions {
+func New(repo *chain.Repository, stater *state.Stater, pool *txpool.TxPool, forkConfig workshare.ForkConfig, abis *abis.Registry) *Transactions {
 	return &Transactions{
 		repo,
+		stater,
 		pool,
+		forkConfig,
+		abis,
 	}
 }
 
@@ -92,7 +105,7 @@ func (t *Transactions) getTransactionByID(txID workshare.Bytes32, head workshare
 }
 
 //GetTransactionReceiptByID get tx's receipt
-func (t *Transactions) getTransactionReceiptByID(txID workshare.Bytes32, head workshare.Bytes32) (*Receipt, error) {
+func (t *Transactions) getTransactionReceiptByID(txID workshare.Bytes32, head workshare.Bytes32, decoded bool) (*Receipt, error) {
 	chain := t.repo.NewChain(head)
 	tx, meta, err := chain.GetTransaction(txID)
 	if err != nil {
@@ -112,7 +125,18 @@ func (t *Transactions) getTransactionReceiptByID(txID workshare.Bytes32, head wo
 		return nil, err
 	}
 
-	return convertReceipt(receipt, summary.Header, tx)
+	converted, err := convertReceipt(receipt, summary.Header, tx)
+	if err != nil {
+		return nil, err
+	}
+	if decoded {
+		// contracts are resolved by code hash in the state after the block
+		decoder := t.abis.NewDecoder(t.stater.NewState(summary.Header.StateRoot(), summary.Header.Number(), summary.Conflicts, summary.SteadyNum))
+		if err := decodeOutputs(decoder, receipt.Outputs, converted.Outputs); err != nil {
+			return nil, err
+		}
+	}
+	return converted, nil
 }
 func (t *Transactions) handleSendTransaction(w http.ResponseWriter, req *http.Request) error {
 	var rawTx *RawTx
@@ -194,8 +218,112 @@ func (t *Transactions) handleGetTransactionReceiptByID(w http.ResponseWriter, re
 			return utils.BadRequest(errors.WithMessage(err, "head"))
 		}
 	}
+	decoded, err := utils.ParseDecoded(req)
+	if err != nil {
+		return err
+	}
+
+	receipt, err := t.getTransactionReceiptByID(txID, head, decoded)
+	if err != nil {
+		return err
+	}
+	return utils.WriteJSON(w, receipt)
+}
+
+// simulateTransaction executes the tx on a throwaway state, as if it's packed in the block next to the given parent.
+// The beneficiary and signer of the parent are reused, since the next proposer is unknown.
+func (t *Transactions) simulateTransaction(trx *tx.Transaction, parent *chain.BlockSummary) (*SimulatedReceipt, error) {
+	header := parent.Header
+	chain := t.repo.NewChain(header.ID())
+
+	number := header.Number() + 1
+	switch {
+	case trx.ChainTag() != t.repo.ChainTag():
+		return nil, utils.Forbidden(errors.New("chain tag mismatch"))
+	case number < trx.BlockRef().Number():
+		return nil, utils.Forbidden(errors.New("block ref out of schedule"))
+	case trx.IsExpired(number):
+		return nil, utils.Forbidden(errors.New("expired"))
+	}
+
+	if _, err := chain.GetTransactionMeta(trx.ID()); err == nil {
+		return nil, utils.Forbidden(errors.New("known tx"))
+	} else if !t.repo.IsNotFound(err) {
+		return nil, err
+	}
+	if dependsOn := trx.DependsOn(); dependsOn != nil {
+		meta, err := chain.GetTransactionMeta(*dependsOn)
+		if err != nil {
+			if t.repo.IsNotFound(err) {
+				return nil, utils.Forbidden(errors.New("dependency not found"))
+			}
+			return nil, err
+		}
+		if meta.Reverted {
+			return nil, utils.Forbidden(errors.New("dependency reverted"))
+		}
+	}
 
-	receipt, err := t.getTransactionReceiptByID(txID, head)
+	signer, _ := header.Signer()
+	rt := runtime.New(
+		chain,
+		t.stater.NewState(header.StateRoot(), header.Number(), parent.Conflicts, parent.SteadyNum),
+		&xenv.BlockContext{
+			Beneficiary: header.Beneficiary(),
+			Signer:      signer,
+			Number:      number,
+			Time:        header.Timestamp() + workshare.BlockInterval,
+			GasLimit:    header.GasLimit(),
+			TotalScore:  header.TotalScore() + 1,
+		},
+		t.forkConfig)
+
+	executor, err := rt.PrepareTransaction(trx)
+	if err != nil {
+		return nil, utils.Forbidden(err)
+	}
+	var vmErr *runtime.Output
+	for executor.HasNextClause() {
+		_, output, err := executor.NextClause()
+		if err != nil {
+			return nil, err
+		}
+		if output.VMErr != nil {
+			vmErr = output
+		}
+	}
+	receipt, err := executor.Finalize()
+	if err != nil {
+		return nil, err
+	}
+
+	simulated, err := convertSimulatedReceipt(receipt, trx, number, header.Timestamp()+workshare.BlockInterval)
+	if err != nil {
+		return nil, err
+	}
+	if vmErr != nil {
+		simulated.VMError = vmErr.VMErr.Error()
+		if vmErr.VMErr == vm.ErrExecutionReverted {
+			simulated.RevertReason, _ = abi.UnpackRevert(vmErr.Data)
+		}
+	}
+	return simulated, nil
+}
+
+func (t *Transactions) handleSimulateTransaction(w http.ResponseWriter, req *http.Request) error {
+	var rawTx *RawTx
+	if err := utils.ParseJSON(req.Body, &rawTx); err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "body"))
+	}
+	tx, err := rawTx.decode()
+	if err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "raw"))
+	}
+	summary, err := utils.ParseRevision(t.repo, req.URL.Query().Get("revision"))
+	if err != nil {
+		return err
+	}
+	receipt, err := t.simulateTransaction(tx, summary)
 	if err != nil {
 		return err
 	}
@@ -217,6 +345,7 @@ func (t *Transactions) Mount(root *mux.Router, pathPrefix string) {
 	sub := root.PathPrefix(pathPrefix).Subrouter()
 
 	sub.Path("").Methods("POST").HandlerFunc(utils.WrapHandlerFunc(t.handleSendTransaction))
+	sub.Path("/simulate").Methods("POST").HandlerFunc(utils.WrapHandlerFunc(t.handleSimulateTransaction))
 	sub.Path("/{id}").Methods("GET").HandlerFunc(utils.WrapHandlerFunc(t.handleGetTransactionByID))
 	sub.Path("/{id}/receipt").Methods("GET").HandlerFunc(utils.WrapHandlerFunc(t.handleGetTransactionReceiptByID))
 }
@@ -19,6 +19,7 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/gorilla/mux"
+	"github.com/miniBamboo/workshare/api/abis"
 	"github.com/miniBamboo/workshare/api/transactions"
 	"github.com/miniBamboo/workshare/chain"
 	"github.com/miniBamboo/workshare/genesis"
@@ -40,6 +41,7 @@ func TestTransaction(t *testing.T) {
 	defer ts.Close()
 	getTx(t)
 	getTxReceipt(t)
+	simulateTx(t)
 	senTx(t)
 }
 
@@ -70,6 +72,10 @@ func getTxReceipt(t *testing.T) {
 		t.Fatal(err)
 	}
 	assert.Equal(t, uint64(receipt.GasUsed), transaction.Gas(), "gas should be equal")
+
+	// no event to be decoded, the receipt is the same
+	decoded := httpGet(t, ts.URL+"/transactions/"+transaction.ID().String()+"/receipt?decoded=true")
+	assert.Equal(t, string(r), string(decoded))
 }
 
 func senTx(t *testing.T) {
@@ -102,6 +108,38 @@ func senTx(t *testing.T) {
 	assert.Equal(t, tx.ID().String(), txObj["id"], "should be the same transaction id")
 }
 
+func simulateTx(t *testing.T) {
+	to := genesis.DevAccounts()[1].Address
+	tx := new(tx.Builder).
+		BlockRef(tx.NewBlockRef(0)).
+		ChainTag(repo.ChainTag()).
+		Expiration(10).
+		Gas(21000).
+		Clause(tx.NewClause(&to).WithValue(big.NewInt(10))).
+		Build()
+	sig, err := crypto.Sign(tx.SigningHash().Bytes(), genesis.DevAccounts()[0].PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx = tx.WithSignature(sig)
+	rlpTx, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := httpPost(t, ts.URL+"/transactions/simulate", transactions.RawTx{Raw: hexutil.Encode(rlpTx)})
+	var receipt *transactions.SimulatedReceipt
+	if err := json.Unmarshal(res, &receipt); err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, receipt.Reverted)
+	assert.Equal(t, uint64(21000), receipt.GasUsed)
+	assert.Equal(t, genesis.DevAccounts()[0].Address, receipt.GasPayer)
+	assert.Equal(t, tx.ID(), receipt.Meta.TxID)
+	assert.Equal(t, 1, len(receipt.Outputs))
+	assert.Equal(t, 1, len(receipt.Outputs[0].Transfers))
+}
+
 func httpPost(t *testing.T, url string, obj interface{}) []byte {
 	data, err := json.Marshal(obj)
 	if err != nil {
@@ -169,8 +207,12 @@ func initTransactionServer(t *testing.T) {
 	if err := repo.SetBestBlockID(b.Header().ID()); err != nil {
 		t.Fatal(err)
 	}
+	abiRegistry, err := abis.NewRegistry("")
+	if err != nil {
+		t.Fatal(err)
+	}
 	router := mux.NewRouter()
-	transactions.New(repo, txpool.New(repo, stater, txpool.Options{Limit: 10000, LimitPerAccount: 16, MaxLifetime: 10 * time.Minute})).Mount(router, "/transactions")
+	transactions.New(repo, stater, txpool.New(repo, stater, txpool.Options{Limit: 10000, LimitPerAccount: 16, MaxLifetime: 10 * time.Minute}), workshare.NoFork, abiRegistry).Mount(router, "/transactions")
 	ts = httptest.NewServer(router)
 
 }
@@ -11,6 +11,7 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/miniBamboo/workshare/api/abis"
 	"github.com/miniBamboo/workshare/block"
 	"github.com/miniBamboo/workshare/tx"
 	"github.com/miniBamboo/workshare/workshare"
@@ -156,6 +157,7 @@ type Event struct {
 	Address workshare.Address   `json:"address"`
 	Topics  []workshare.Bytes32 `json:"topics"`
 	Data    string              `json:"data"`
+	Decoded *abis.DecodedEvent  `json:"decoded,omitempty"`
 }
 
 // Transfer transfer log.
@@ -187,8 +189,25 @@ func convertReceipt(txReceipt *tx.Receipt, header *block.Header, tx *tx.Transact
 			origin,
 		},
 	}
-	receipt.Outputs = make([]*Output, len(txReceipt.Outputs))
-	for i, output := range txReceipt.Outputs {
+	receipt.Outputs = convertOutputs(txReceipt.Outputs, tx)
+	return receipt, nil
+}
+
+// decodeOutputs sets decoded events of the converted outputs.
+func decodeOutputs(decoder *abis.Decoder, txOutputs []*tx.Output, outputs []*Output) (err error) {
+	for i, output := range txOutputs {
+		for j, ev := range output.Events {
+			if outputs[i].Events[j].Decoded, err = decoder.DecodeEvent(ev.Address, ev.Topics, ev.Data); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func convertOutputs(txOutputs []*tx.Output, tx *tx.Transaction) []*Output {
+	outputs := make([]*Output, len(txOutputs))
+	for i, output := range txOutputs {
 		clause := tx.Clauses()[i]
 		var contractAddr *workshare.Address
 		if clause.To() == nil {
@@ -219,7 +238,49 @@ func convertReceipt(txReceipt *tx.Receipt, header *block.Header, tx *tx.Transact
 			}
 			otp.Transfers[j] = transfer
 		}
-		receipt.Outputs[i] = otp
+		outputs[i] = otp
 	}
-	return receipt, nil
+	return outputs
+}
+
+// SimulatedReceipt is the receipt a tx would produce if packed in the next block.
+type SimulatedReceipt struct {
+	GasUsed      uint64                `json:"gasUsed"`
+	GasPayer     workshare.Address     `json:"gasPayer"`
+	Paid         *math.HexOrDecimal256 `json:"paid"`
+	Reward       *math.HexOrDecimal256 `json:"reward"`
+	Reverted     bool                  `json:"reverted"`
+	VMError      string                `json:"vmError"`
+	RevertReason string                `json:"revertReason"`
+	Meta         SimulatedReceiptMeta  `json:"meta"`
+	Outputs      []*Output             `json:"outputs"`
+}
+
+// SimulatedReceiptMeta describes the block the tx is simulated in.
+type SimulatedReceiptMeta struct {
+	BlockNumber    uint32            `json:"blockNumber"`
+	BlockTimestamp uint64            `json:"blockTimestamp"`
+	TxID           workshare.Bytes32 `json:"txID"`
+	TxOrigin       workshare.Address `json:"txOrigin"`
+}
+
+func convertSimulatedReceipt(txReceipt *tx.Receipt, tx *tx.Transaction, blockNumber uint32, blockTime uint64) (*SimulatedReceipt, error) {
+	origin, err := tx.Origin()
+	if err != nil {
+		return nil, err
+	}
+	return &SimulatedReceipt{
+		GasUsed:  txReceipt.GasUsed,
+		GasPayer: txReceipt.GasPayer,
+		Paid:     (*math.HexOrDecimal256)(txReceipt.Paid),
+		Reward:   (*math.HexOrDecimal256)(txReceipt.Reward),
+		Reverted: txReceipt.Reverted,
+		Meta: SimulatedReceiptMeta{
+			blockNumber,
+			blockTime,
+			tx.ID(),
+			origin,
+		},
+		Outputs: convertOutputs(txReceipt.Outputs, tx),
+	}, nil
 }
@@ -29,11 +29,11 @@ func New(repo *chain.Repository, db *logdb.LogDB) *Transfers {
 	}
 }
 
-//Filter query logs with option
-func (t *Transfers) filter(ctx context.Context, filter *TransferFilter) ([]*FilteredTransfer, error) {
+//Filter query logs with option, and returns the cursor of the next page if any.
+func (t *Transfers) filter(ctx context.Context, filter *TransferFilter) ([]*FilteredTransfer, *logdb.Cursor, error) {
 	rng, err := events.ConvertRange(t.repo.NewBestChain(), filter.Range)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	transfers, err := t.db.FilterTransfers(ctx, &logdb.TransferFilter{
@@ -43,13 +43,17 @@ func (t *Transfers) filter(ctx context.Context, filter *TransferFilter) ([]*Filt
 		Order:       filter.Order,
 	})
 	if err != nil {
-		return nil, err
+		return nil, nil, events.ConvertFilterError(err)
 	}
 	tLogs := make([]*FilteredTransfer, len(transfers))
 	for i, trans := range transfers {
 		tLogs[i] = convertTransfer(trans)
 	}
-	return tLogs, nil
+	var next *logdb.Cursor
+	if n := len(transfers); n > 0 && filter.Options != nil && uint64(n) == filter.Options.Limit {
+		next = transfers[n-1].Cursor()
+	}
+	return tLogs, next, nil
 }
 
 func (t *Transfers) handleFilterTransferLogs(w http.ResponseWriter, req *http.Request) error {
@@ -57,10 +61,16 @@ func (t *Transfers) handleFilterTransferLogs(w http.ResponseWriter, req *http.Re
 	if err := utils.ParseJSON(req.Body, &filter); err != nil {
 		return utils.BadRequest(errors.WithMessage(err, "body"))
 	}
-	tLogs, err := t.filter(req.Context(), &filter)
+	if err := events.ValidateOptions(filter.Options); err != nil {
+		return err
+	}
+	tLogs, next, err := t.filter(req.Context(), &filter)
 	if err != nil {
 		return err
 	}
+	if next != nil {
+		w.Header().Set(events.NextCursorHeader, next.String())
+	}
 	return utils.WriteJSON(w, tLogs)
 }
 
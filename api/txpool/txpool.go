@@ -0,0 +1,103 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package txpool
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/miniBamboo/workshare/api/utils"
+	"github.com/miniBamboo/workshare/txpool"
+	"github.com/miniBamboo/workshare/workshare"
+	"github.com/pkg/errors"
+)
+
+type TxPool struct {
+	pool *txpool.TxPool
+}
+
+func New(pool *txpool.TxPool) *TxPool {
+	return &TxPool{
+		pool,
+	}
+}
+
+func (p *TxPool) handleGetTxs(w http.ResponseWriter, req *http.Request) error {
+	origin, err := parseAddress(req.URL.Query().Get("origin"))
+	if err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "origin"))
+	}
+	delegator, err := parseAddress(req.URL.Query().Get("delegator"))
+	if err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "delegator"))
+	}
+
+	statuses := p.pool.Inspect(func(status *txpool.TxStatus) bool {
+		if origin != nil && *origin != status.Origin {
+			return false
+		}
+		if delegator != nil && (status.Delegator == nil || *delegator != *status.Delegator) {
+			return false
+		}
+		return true
+	})
+	txs := make([]*PooledTx, len(statuses))
+	for i, status := range statuses {
+		txs[i] = convertTxStatus(status)
+	}
+	return utils.WriteJSON(w, txs)
+}
+
+func (p *TxPool) handleGetTx(w http.ResponseWriter, req *http.Request) error {
+	id, err := workshare.ParseBytes32(mux.Vars(req)["id"])
+	if err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "id"))
+	}
+	status := p.pool.InspectTx(id)
+	if status == nil {
+		return utils.WriteJSON(w, nil)
+	}
+	return utils.WriteJSON(w, convertTxStatus(status))
+}
+
+func (p *TxPool) handleGetStats(w http.ResponseWriter, req *http.Request) error {
+	origin, err := parseAddress(req.URL.Query().Get("origin"))
+	if err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "origin"))
+	}
+	s := p.pool.Stats()
+	stats := &Stats{
+		Total:           s.Total,
+		Executables:     s.Executables,
+		Accounts:        s.Accounts,
+		Limit:           s.Limit,
+		LimitPerAccount: s.LimitPerAccount,
+	}
+	if origin != nil {
+		quota := p.pool.Quota(*origin)
+		stats.Quota = &quota
+	}
+	return utils.WriteJSON(w, stats)
+}
+
+func parseAddress(addr string) (*workshare.Address, error) {
+	if addr == "" {
+		return nil, nil
+	}
+	address, err := workshare.ParseAddress(addr)
+	if err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
+
+func (p *TxPool) Mount(root *mux.Router, pathPrefix string) {
+	sub := root.PathPrefix(pathPrefix).Subrouter()
+
+	sub.Path("").Methods("GET").HandlerFunc(utils.WrapHandlerFunc(p.handleGetTxs))
+	sub.Path("/stats").Methods("GET").HandlerFunc(utils.WrapHandlerFunc(p.handleGetStats))
+	sub.Path("/{id}").Methods("GET").HandlerFunc(utils.WrapHandlerFunc(p.handleGetTx))
+}
@@ -0,0 +1,210 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package txpool_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gorilla/mux"
+	pool "github.com/miniBamboo/workshare/api/txpool"
+	"github.com/miniBamboo/workshare/chain"
+	"github.com/miniBamboo/workshare/genesis"
+	"github.com/miniBamboo/workshare/muxdb"
+	"github.com/miniBamboo/workshare/state"
+	"github.com/miniBamboo/workshare/tx"
+	"github.com/miniBamboo/workshare/txpool"
+	"github.com/miniBamboo/workshare/workshare"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	ts       *httptest.Server
+	txPool   *txpool.TxPool
+	chainTag byte
+	nonce    uint64
+	accounts = genesis.DevAccounts()
+)
+
+func TestTxPool(t *testing.T) {
+	initTxPoolServer(t)
+	defer ts.Close()
+	defer txPool.Close()
+
+	var (
+		executable  = newTx(t, tx.NewBlockRef(0), 21000, nil, false, accounts[0])
+		future      = newTx(t, tx.NewBlockRef(10), 21000, nil, false, accounts[0])
+		dependent   = newTx(t, tx.NewBlockRef(0), 21000, &workshare.Bytes32{1}, false, accounts[1])
+		gasTooLarge = newTx(t, tx.NewBlockRef(0), math.MaxUint64, nil, false, accounts[1])
+		delegated   = newTx(t, tx.NewBlockRef(0), 21000, nil, true, accounts[2], accounts[3])
+	)
+	for _, trx := range []*tx.Transaction{executable, future, dependent} {
+		if err := txPool.Add(trx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// over the limit per account
+	assert.Error(t, txPool.Add(newTx(t, tx.NewBlockRef(0), 21000, nil, false, accounts[0])))
+	// invalid ones are only filled without validation, and delegation is not yet activated at genesis
+	txPool.Fill(tx.Transactions{gasTooLarge, delegated}, false)
+
+	t.Run("txs", func(t *testing.T) {
+		tests := []struct {
+			query string
+			want  []*tx.Transaction
+		}{
+			{"", []*tx.Transaction{executable, future, dependent, gasTooLarge, delegated}},
+			{"?origin=" + accounts[0].Address.String(), []*tx.Transaction{executable, future}},
+			{"?delegator=" + accounts[3].Address.String(), []*tx.Transaction{delegated}},
+			{"?origin=" + accounts[2].Address.String() + "&delegator=" + accounts[3].Address.String(), []*tx.Transaction{delegated}},
+			{"?origin=" + accounts[0].Address.String() + "&delegator=" + accounts[3].Address.String(), nil},
+			{"?origin=" + accounts[4].Address.String(), nil},
+		}
+		for _, tt := range tests {
+			var txs []*pool.PooledTx
+			httpGetJSON(t, "/txpool"+tt.query, http.StatusOK, &txs)
+			var ids []workshare.Bytes32
+			for _, ptx := range txs {
+				ids = append(ids, ptx.ID)
+			}
+			var want []workshare.Bytes32
+			for _, trx := range tt.want {
+				want = append(want, trx.ID())
+			}
+			assert.ElementsMatch(t, want, ids, tt.query)
+		}
+
+		httpGetJSON(t, "/txpool?origin=0xzz", http.StatusBadRequest, nil)
+		httpGetJSON(t, "/txpool?delegator=0xzz", http.StatusBadRequest, nil)
+	})
+
+	t.Run("tx", func(t *testing.T) {
+		tests := []struct {
+			trx        *tx.Transaction
+			executable bool
+			reason     string
+		}{
+			{executable, true, ""},
+			{future, false, "future block ref"},
+			{dependent, false, "dependency not settled"},
+			{gasTooLarge, false, "gas too large"},
+			{delegated, true, ""},
+		}
+		for _, tt := range tests {
+			var ptx *pool.PooledTx
+			httpGetJSON(t, "/txpool/"+tt.trx.ID().String(), http.StatusOK, &ptx)
+			if !assert.NotNil(t, ptx) {
+				continue
+			}
+			origin, _ := tt.trx.Origin()
+			delegator, _ := tt.trx.Delegator()
+			assert.Equal(t, tt.trx.ID(), ptx.ID)
+			assert.Equal(t, origin, ptx.Origin)
+			assert.Equal(t, delegator, ptx.Delegator)
+			assert.Equal(t, tt.trx.Gas(), ptx.Gas)
+			assert.Equal(t, tt.executable, ptx.Executable, tt.reason)
+			assert.Equal(t, tt.reason, ptx.Reason)
+		}
+
+		var ptx *pool.PooledTx
+		httpGetJSON(t, "/txpool/"+workshare.Bytes32{}.String(), http.StatusOK, &ptx)
+		assert.Nil(t, ptx)
+		httpGetJSON(t, "/txpool/0xzz", http.StatusBadRequest, nil)
+	})
+
+	t.Run("stats", func(t *testing.T) {
+		var stats pool.Stats
+		httpGetJSON(t, "/txpool/stats", http.StatusOK, &stats)
+		assert.Equal(t, pool.Stats{Total: 5, Accounts: 3, Limit: 10, LimitPerAccount: 2}, stats)
+
+		quota := func(origin workshare.Address) *int {
+			var stats pool.Stats
+			httpGetJSON(t, "/txpool/stats?origin="+origin.String(), http.StatusOK, &stats)
+			return stats.Quota
+		}
+		two, zero := 2, 0
+		assert.Equal(t, &two, quota(accounts[0].Address))
+		assert.Equal(t, &two, quota(accounts[1].Address))
+		assert.Equal(t, &zero, quota(accounts[4].Address))
+
+		httpGetJSON(t, "/txpool/stats?origin=0xzz", http.StatusBadRequest, nil)
+	})
+}
+
+func newTx(t *testing.T, blockRef tx.BlockRef, gas uint64, dependsOn *workshare.Bytes32, delegated bool, signers ...genesis.DevAccount) *tx.Transaction {
+	var features tx.Features
+	features.SetDelegated(delegated)
+	to := accounts[9].Address
+	nonce++
+	trx := new(tx.Builder).
+		ChainTag(chainTag).
+		BlockRef(blockRef).
+		Expiration(100).
+		Gas(gas).
+		DependsOn(dependsOn).
+		Features(features).
+		Nonce(nonce).
+		Clause(tx.NewClause(&to).WithValue(big.NewInt(1))).
+		Build()
+
+	sig, err := crypto.Sign(trx.SigningHash().Bytes(), signers[0].PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delegated {
+		delegatorSig, err := crypto.Sign(trx.DelegatorSigningHash(signers[0].Address).Bytes(), signers[1].PrivateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig = append(sig, delegatorSig...)
+	}
+	return trx.WithSignature(sig)
+}
+
+func initTxPoolServer(t *testing.T) {
+	db := muxdb.NewMem()
+	stater := state.NewStater(db)
+	b, _, _, err := genesis.NewDevnet().Build(stater)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := chain.NewRepository(db, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainTag = repo.ChainTag()
+
+	// the chain is far from synced, so that txs are neither validated nor washed by the pool
+	txPool = txpool.New(repo, stater, txpool.Options{Limit: 10, LimitPerAccount: 2, MaxLifetime: time.Hour})
+	router := mux.NewRouter()
+	pool.New(txPool).Mount(router, "/txpool")
+	ts = httptest.NewServer(router)
+}
+
+func httpGetJSON(t *testing.T, path string, wantStatus int, v interface{}) {
+	res, err := http.Get(ts.URL + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.Equal(t, wantStatus, res.StatusCode, path+": "+string(data)) || v == nil {
+		return
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package txpool
+
+import (
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/miniBamboo/workshare/txpool"
+	"github.com/miniBamboo/workshare/workshare"
+)
+
+// PooledTx is a tx in the pool with its status.
+type PooledTx struct {
+	ID           workshare.Bytes32  `json:"id"`
+	Origin       workshare.Address  `json:"origin"`
+	Delegator    *workshare.Address `json:"delegator"`
+	BlockRef     string             `json:"blockRef"`
+	Expiration   uint32             `json:"expiration"`
+	GasPriceCoef uint8              `json:"gasPriceCoef"`
+	Gas          uint64             `json:"gas"`
+	DependsOn    *workshare.Bytes32 `json:"dependsOn"`
+	TimeAdded    uint64             `json:"timeAdded"`
+	Executable   bool               `json:"executable"`
+	Reason       string             `json:"reason"`
+}
+
+func convertTxStatus(status *txpool.TxStatus) *PooledTx {
+	br := status.Tx.BlockRef()
+	return &PooledTx{
+		ID:           status.Tx.ID(),
+		Origin:       status.Origin,
+		Delegator:    status.Delegator,
+		BlockRef:     hexutil.Encode(br[:]),
+		Expiration:   status.Tx.Expiration(),
+		GasPriceCoef: status.Tx.GasPriceCoef(),
+		Gas:          status.Tx.Gas(),
+		DependsOn:    status.Tx.DependsOn(),
+		TimeAdded:    uint64(status.TimeAdded.Unix()),
+		Executable:   status.Executable,
+		Reason:       status.Reason,
+	}
+}
+
+// Stats contains the size of the pool versus its limits.
+type Stats struct {
+	Total           int  `json:"total"`
+	Executables     int  `json:"executables"`
+	Accounts        int  `json:"accounts"`
+	Limit           int  `json:"limit"`
+	LimitPerAccount int  `json:"limitPerAccount"`
+	Quota           *int `json:"quota,omitempty"` // count of txs of the queried origin
+}
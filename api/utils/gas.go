@@ -0,0 +1,29 @@
+// Copyright (c) 2018 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package utils
+
+// SearchGas binary searches the minimal gas for which exec succeeds.
+// The execution is known to succeed with gas limit hi, and to consume used gas in that run.
+// The used gas is the lower bound, since the execution never succeeds with less gas.
+func SearchGas(hi, used uint64, exec func(gas uint64) (ok bool, err error)) (uint64, error) {
+	if used == 0 {
+		return 0, nil
+	}
+	lo := used - 1
+	for lo+1 < hi {
+		mid := lo + (hi-lo)/2
+		ok, err := exec(mid)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	return hi, nil
+}
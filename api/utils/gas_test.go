@@ -0,0 +1,52 @@
+// Copyright (c) 2018 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package utils
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchGas(t *testing.T) {
+	// succeeds with at least min gas, and uses used gas
+	exec := func(min uint64, calls *int) func(uint64) (bool, error) {
+		return func(gas uint64) (bool, error) {
+			*calls++
+			return gas >= min, nil
+		}
+	}
+
+	tests := []struct {
+		name      string
+		hi, used  uint64
+		min, want uint64
+	}{
+		{"no gas used", 100, 0, 0, 0},
+		{"used is enough", 100, 30, 30, 30},
+		{"needs more than used", 100, 30, 64, 64},
+		{"needs all", 100, 30, 100, 100},
+		{"max limit", math.MaxUint64, 21000, 21001, 21001},
+		{"max limit needs all", math.MaxUint64, 21000, math.MaxUint64, math.MaxUint64},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			got, err := SearchGas(tt.hi, tt.used, exec(tt.min, &calls))
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.LessOrEqual(t, calls, 64)
+		})
+	}
+
+	t.Run("error", func(t *testing.T) {
+		want := errors.New("boom")
+		_, err := SearchGas(100, 30, func(uint64) (bool, error) { return false, want })
+		assert.Equal(t, want, err)
+	})
+}
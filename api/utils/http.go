@@ -9,6 +9,8 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
+
+	"github.com/pkg/errors"
 )
 
 type httpError struct {
@@ -71,6 +73,7 @@ func WrapHandlerFunc(f HandlerFunc) http.HandlerFunc {
 const (
 	JSONContentType        = "application/json; charset=utf-8"
 	OctetStreamContentType = "application/octet-stream"
+	NDJSONContentType      = "application/x-ndjson"
 )
 
 // ParseJSON parse a JSON object using strict mode.
@@ -80,6 +83,15 @@ func ParseJSON(r io.Reader, v interface{}) error {
 	return decoder.Decode(v)
 }
 
+// ParseDecoded parses the 'decoded' query param, which opts in ABI decoding of events and call results.
+func ParseDecoded(req *http.Request) (bool, error) {
+	decoded := req.URL.Query().Get("decoded")
+	if decoded != "" && decoded != "false" && decoded != "true" {
+		return false, BadRequest(errors.WithMessage(errors.New("should be boolean"), "decoded"))
+	}
+	return decoded == "true", nil
+}
+
 // WriteJSON response an object in JSON encoding.
 func WriteJSON(w http.ResponseWriter, obj interface{}) error {
 	w.Header().Set("Content-Type", JSONContentType)
@@ -0,0 +1,40 @@
+// Copyright (c) 2018 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDecoded(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    bool
+		wantErr bool
+	}{
+		{"", false, false},
+		{"?decoded=false", false, false},
+		{"?decoded=true", true, false},
+		{"?decoded=1", false, true},
+		{"?decoded=TRUE", false, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseDecoded(httptest.NewRequest(http.MethodGet, "/"+tt.query, nil))
+		assert.Equal(t, tt.want, got, tt.query)
+		if tt.wantErr {
+			if assert.Error(t, err, tt.query) {
+				assert.Equal(t, "decoded: should be boolean", err.Error())
+				assert.Equal(t, http.StatusBadRequest, err.(*httpError).status)
+			}
+		} else {
+			assert.NoError(t, err, tt.query)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2018 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package utils
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/miniBamboo/workshare/chain"
+	"github.com/miniBamboo/workshare/runtime"
+	"github.com/miniBamboo/workshare/state"
+	"github.com/miniBamboo/workshare/workshare"
+	"github.com/miniBamboo/workshare/xenv"
+	"github.com/pkg/errors"
+)
+
+// ParseRevision returns the summary of the block specified by the revision query, which is
+// a block ID, a block number on the best chain, or empty/"best" for the best block.
+func ParseRevision(repo *chain.Repository, revision string) (*chain.BlockSummary, error) {
+	if revision == "" || revision == "best" {
+		return repo.BestBlockSummary(), nil
+	}
+	if len(revision) == 66 || len(revision) == 64 {
+		blockID, err := workshare.ParseBytes32(revision)
+		if err != nil {
+			return nil, BadRequest(errors.WithMessage(err, "revision"))
+		}
+		summary, err := repo.GetBlockSummary(blockID)
+		if err != nil {
+			if repo.IsNotFound(err) {
+				return nil, BadRequest(errors.WithMessage(err, "revision"))
+			}
+			return nil, err
+		}
+		return summary, nil
+	}
+	n, err := strconv.ParseUint(revision, 0, 0)
+	if err != nil {
+		return nil, BadRequest(errors.WithMessage(err, "revision"))
+	}
+	if n > math.MaxUint32 {
+		return nil, BadRequest(errors.WithMessage(errors.New("block number out of max uint32"), "revision"))
+	}
+	summary, err := repo.NewBestChain().GetBlockSummary(uint32(n))
+	if err != nil {
+		if repo.IsNotFound(err) {
+			return nil, BadRequest(errors.WithMessage(err, "revision"))
+		}
+		return nil, err
+	}
+	return summary, nil
+}
+
+// NewRuntime creates a runtime to execute calls on the state of the given block, with the block's context.
+func NewRuntime(repo *chain.Repository, stater *state.Stater, summary *chain.BlockSummary, forkConfig workshare.ForkConfig) *runtime.Runtime {
+	header := summary.Header
+	state := stater.NewState(header.StateRoot(), header.Number(), summary.Conflicts, summary.SteadyNum)
+
+	signer, _ := header.Signer()
+	return runtime.New(repo.NewChain(header.ParentID()), state,
+		&xenv.BlockContext{
+			Beneficiary: header.Beneficiary(),
+			Signer:      signer,
+			Number:      header.Number(),
+			Time:        header.Timestamp(),
+			GasLimit:    header.GasLimit(),
+			TotalScore:  header.TotalScore(),
+		},
+		forkConfig)
+}
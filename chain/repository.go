@@ -12,6 +12,7 @@ import (
 	"github.com/miniBamboo/workshare/block"
 	"github.com/miniBamboo/workshare/co"
 	"github.com/miniBamboo/workshare/kv"
+	"github.com/miniBamboo/workshare/metric"
 	"github.com/miniBamboo/workshare/muxdb"
 	"github.com/miniBamboo/workshare/tx"
 	"github.com/miniBamboo/workshare/workshare"
@@ -29,6 +30,8 @@ var (
 	errNotFound      = errors.New("not found")
 	bestBlockIDKey   = []byte("best-block-id")
 	steadyBlockIDKey = []byte("steady-block-id")
+
+	metricBestBlockNumber = metric.NewGauge("chain_best_block_number", "Number of the best block.")
 )
 
 // Repository stores block headers, txs and receipts.
@@ -151,6 +154,7 @@ func (r *Repository) setBestBlockSummary(summary *BlockSummary) error {
 		return err
 	}
 	r.bestSummary.Store(summary)
+	metricBestBlockNumber.Set(float64(summary.Header.Number()))
 	return nil
 }
 
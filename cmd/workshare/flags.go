@@ -55,6 +55,50 @@ var (
 		Value: 1000,
 		Usage: "limit the distance between 'position' and best block for subscriptions APIs",
 	}
+	apiMaxBlockRangeFlag = cli.IntFlag{
+		Name:  "api-max-block-range",
+		Value: 100,
+		Usage: "limit the number of blocks in a request of block range API",
+	}
+	apiGraphQLCostLimitFlag = cli.IntFlag{
+		Name:  "api-graphql-cost-limit",
+		Value: 20000,
+		Usage: "limit the cost of a GraphQL query",
+	}
+	apiABIDirFlag = cli.StringFlag{
+		Name:  "api-abi-dir",
+		Usage: "directory of contract ABI files named '<address or code hash>.json', to decode API responses",
+	}
+	apiReadyMaxBlockAgeFlag = cli.IntFlag{
+		Name:  "api-ready-max-block-age",
+		Value: 60,
+		Usage: "max age in seconds of the best block for the node to be ready",
+	}
+	apiReadyMinPeersFlag = cli.IntFlag{
+		Name:  "api-ready-min-peers",
+		Value: 1,
+		Usage: "min number of connected peers for the node to be ready",
+	}
+	apiKeysFlag = cli.StringFlag{
+		Name:  "api-keys",
+		Usage: "path to API keys file, which enables API authentication, reloaded on change",
+	}
+	apiTLSCertFlag = cli.StringFlag{
+		Name:  "api-tls-cert",
+		Usage: "path to certificate file, which enables TLS for API, reloaded on change",
+	}
+	apiTLSKeyFlag = cli.StringFlag{
+		Name:  "api-tls-key",
+		Usage: "path to private key file of the API certificate",
+	}
+	apiTLSClientCAFlag = cli.StringFlag{
+		Name:  "api-tls-client-ca",
+		Usage: "path to CA certificates file to verify API clients, which enables mutual TLS",
+	}
+	apiTLSSelfSignedFlag = cli.BoolFlag{
+		Name:  "api-tls-self-signed",
+		Usage: "enable TLS for API with a self-signed certificate (for dev only)",
+	}
 	verbosityFlag = cli.IntFlag{
 		Name:  "verbosity",
 		Value: int(log15.LvlInfo),
@@ -97,6 +141,29 @@ var (
 		Name:  "export",
 		Usage: "export master key to keystore",
 	}
+	exportDirFlag = cli.StringFlag{
+		Name:  "out",
+		Usage: "output directory of exported files",
+		Value: "logs-export",
+	}
+	exportFormatFlag = cli.StringFlag{
+		Name:  "format",
+		Usage: "format of exported files (parquet|csv)",
+		Value: "parquet",
+	}
+	exportFromFlag = cli.IntFlag{
+		Name:  "from",
+		Usage: "number of the first block to export",
+	}
+	exportToFlag = cli.IntFlag{
+		Name:  "to",
+		Usage: "number of the last block to export (default: the newest block in log db)",
+	}
+	exportPartitionSizeFlag = cli.IntFlag{
+		Name:  "partition-size",
+		Usage: "number of blocks in each exported file",
+		Value: 100000,
+	}
 	targetGasLimitFlag = cli.IntFlag{
 		Name:  "target-gas-limit",
 		Value: 0,
@@ -110,10 +177,26 @@ var (
 		Name:  "pprof",
 		Usage: "turn on go-pprof",
 	}
+	adminAddrFlag = cli.StringFlag{
+		Name:  "admin-addr",
+		Usage: "admin API service listening address, which should not be exposed publicly (disabled if empty)",
+	}
+	metricsFlag = cli.BoolFlag{
+		Name:  "metrics",
+		Usage: "enable Prometheus metrics at /metrics of API",
+	}
+	metricsAddrFlag = cli.StringFlag{
+		Name:  "metrics-addr",
+		Usage: "serve Prometheus metrics on a separate listening address, instead of API",
+	}
 	skipLogsFlag = cli.BoolFlag{
 		Name:  "skip-logs",
 		Usage: "skip writing event|transfer logs (/logs API will be disabled)",
 	}
+	logRetentionFlag = cli.StringFlag{
+		Name:  "log-retention",
+		Usage: "keep event|transfer|tokenTransfer logs of the last N blocks (e.g. '1000000') or days (e.g. '90d'), optionally per table (e.g. '90d,transfer=30d'), older logs are pruned",
+	}
 	verifyLogsFlag = cli.BoolFlag{
 		Name:   "verify-logs",
 		Usage:  "verify log db at startup",
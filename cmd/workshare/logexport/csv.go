@@ -0,0 +1,59 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package logexport
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// csvWriter writes rows with a header line. Null values are written as empty fields.
+type csvWriter struct {
+	w      io.WriteCloser
+	bw     *bufio.Writer
+	cw     *csv.Writer
+	record []string
+}
+
+func newCSVWriter(w io.WriteCloser, columns []*column) (*csvWriter, error) {
+	bw := bufio.NewWriter(w)
+	cw := csv.NewWriter(bw)
+	header := make([]string, len(columns))
+	for i, c := range columns {
+		header[i] = c.name
+	}
+	if err := cw.Write(header); err != nil {
+		return nil, err
+	}
+	return &csvWriter{w, bw, cw, make([]string, len(columns))}, nil
+}
+
+func (c *csvWriter) Write(row []interface{}) error {
+	for i, v := range row {
+		switch v := v.(type) {
+		case nil:
+			c.record[i] = ""
+		case int64:
+			c.record[i] = strconv.FormatInt(v, 10)
+		case string:
+			c.record[i] = v
+		}
+	}
+	return c.cw.Write(c.record)
+}
+
+func (c *csvWriter) Close() error {
+	c.cw.Flush()
+	if err := c.cw.Error(); err != nil {
+		return err
+	}
+	if err := c.bw.Flush(); err != nil {
+		return err
+	}
+	return c.w.Close()
+}
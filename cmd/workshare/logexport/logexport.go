@@ -0,0 +1,297 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package logexport
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/inconshreveable/log15"
+	"github.com/miniBamboo/workshare/logdb"
+	"github.com/pkg/errors"
+)
+
+var log = log15.New("pkg", "logexport")
+
+const pageSize = 10000 // logs queried at a time
+
+// Format is the format of exported files.
+type Format string
+
+const (
+	Parquet Format = "parquet"
+	CSV     Format = "csv"
+)
+
+type columnType int
+
+const (
+	int64Type columnType = iota
+	stringType
+)
+
+type column struct {
+	name     string
+	typ      columnType
+	optional bool
+}
+
+// rowWriter writes rows into a file. Values are int64, string or nil for null.
+type rowWriter interface {
+	Write(row []interface{}) error
+	Close() error
+}
+
+var (
+	eventColumns = []*column{
+		{"blockNumber", int64Type, false},
+		{"blockID", stringType, false},
+		{"blockTime", int64Type, false},
+		{"txID", stringType, false},
+		{"txOrigin", stringType, false},
+		{"clauseIndex", int64Type, false},
+		{"logIndex", int64Type, false},
+		{"address", stringType, false},
+		{"topic0", stringType, true},
+		{"topic1", stringType, true},
+		{"topic2", stringType, true},
+		{"topic3", stringType, true},
+		{"topic4", stringType, true},
+		{"data", stringType, false},
+	}
+	transferColumns = []*column{
+		{"blockNumber", int64Type, false},
+		{"blockID", stringType, false},
+		{"blockTime", int64Type, false},
+		{"txID", stringType, false},
+		{"txOrigin", stringType, false},
+		{"clauseIndex", int64Type, false},
+		{"logIndex", int64Type, false},
+		{"sender", stringType, false},
+		{"recipient", stringType, false},
+		{"amount", stringType, false}, // in decimal, which may overflow int64
+	}
+)
+
+// Exporter exports event and transfer logs into files partitioned by block range.
+//
+// Files are named '<table>/<from>-<to>.<format>' in the dir, where from and to are block numbers
+// (both included) zero-padded to 10 digits. Partitions are aligned to multiples of the partition size,
+// and only complete ones are exported, so that a partition is never appended by later exports.
+// The first partition starts from the first block exported, and it's replaced if a later export starts
+// earlier. A file appears only after its partition is completely written, and existing ones are skipped,
+// so an interrupted export resumes from the last written partition.
+type Exporter struct {
+	db            *logdb.LogDB
+	dir           string
+	format        Format
+	partitionSize uint32
+}
+
+// New creates an exporter.
+func New(db *logdb.LogDB, dir string, format Format, partitionSize uint32) (*Exporter, error) {
+	if format != Parquet && format != CSV {
+		return nil, fmt.Errorf("unsupported format %q", string(format))
+	}
+	if partitionSize == 0 {
+		return nil, errors.New("zero partition size")
+	}
+	return &Exporter{db, dir, format, partitionSize}, nil
+}
+
+// Export exports logs of complete partitions in blocks [from, to]. Blocks of pruned logs are skipped.
+func (e *Exporter) Export(ctx context.Context, from, to uint32) error {
+	for _, table := range []logdb.Table{logdb.EventTable, logdb.TransferTable} {
+		start := from
+		if before := e.db.PrunedBefore(table); start < before {
+			log.Warn("skip pruned logs", "table", table, "before", before)
+			start = before
+		}
+		for p := start / e.partitionSize; ; p++ {
+			pFrom, pTo := p*e.partitionSize, p*e.partitionSize+(e.partitionSize-1)
+			if pTo < pFrom { // overflowed
+				pTo = math.MaxUint32
+			}
+			if pTo > to {
+				log.Info("skip incomplete partition", "table", table, "from", pFrom, "to", pTo)
+				break
+			}
+			if pFrom < start {
+				pFrom = start
+			}
+			if err := e.exportPartition(ctx, table, pFrom, pTo); err != nil {
+				return errors.Wrapf(err, "export %v logs [%v, %v]", table, pFrom, pTo)
+			}
+			if pTo == math.MaxUint32 {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// exportPartition exports logs of blocks [from, to], where to is the end of the partition.
+// It's skipped if the partition has been exported from the same or an earlier block,
+// otherwise files of the partition exported from later blocks are replaced.
+func (e *Exporter) exportPartition(ctx context.Context, table logdb.Table, from, to uint32) (err error) {
+	dir := filepath.Join(e.dir, string(table))
+	path := filepath.Join(dir, fmt.Sprintf("%010d-%010d.%v", from, to, e.format))
+
+	existing, err := filepath.Glob(filepath.Join(dir, fmt.Sprintf("*-%010d.%v", to, e.format)))
+	if err != nil {
+		return err
+	}
+	for _, f := range existing {
+		var eFrom uint32
+		if _, err := fmt.Sscanf(filepath.Base(f), "%010d-", &eFrom); err != nil {
+			return fmt.Errorf("unexpected file %v", f)
+		}
+		if eFrom <= from {
+			log.Debug("skip exported partition", "path", f)
+			return nil
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmpPath := path + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	columns := eventColumns
+	if table == logdb.TransferTable {
+		columns = transferColumns
+	}
+	var w rowWriter
+	if e.format == Parquet {
+		w, err = newParquetWriter(file, columns)
+	} else {
+		w, err = newCSVWriter(file, columns)
+	}
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = file.Close()
+			_ = os.Remove(tmpPath)
+		}
+	}()
+
+	var n int
+	if table == logdb.EventTable {
+		n, err = e.writeEvents(ctx, w, from, to)
+	} else {
+		n, err = e.writeTransfers(ctx, w, from, to)
+	}
+	if err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+	// the replaced files only contain logs of later blocks
+	for _, f := range existing {
+		if err := os.Remove(f); err != nil {
+			return err
+		}
+	}
+	log.Info("exported logs", "table", table, "from", from, "to", to, "count", n)
+	return nil
+}
+
+func (e *Exporter) writeEvents(ctx context.Context, w rowWriter, from, to uint32) (int, error) {
+	var (
+		n      int
+		cursor *logdb.Cursor
+	)
+	for {
+		events, err := e.db.FilterEvents(ctx, &logdb.EventFilter{
+			Range:   &logdb.Range{From: from, To: to},
+			Options: &logdb.Options{Limit: pageSize, Cursor: cursor},
+			Order:   logdb.ASC,
+		})
+		if err != nil {
+			return 0, err
+		}
+		for _, ev := range events {
+			row := []interface{}{
+				int64(ev.BlockNumber),
+				ev.BlockID.String(),
+				int64(ev.BlockTime),
+				ev.TxID.String(),
+				ev.TxOrigin.String(),
+				int64(ev.ClauseIndex),
+				int64(ev.Index),
+				ev.Address.String(),
+				nil, nil, nil, nil, nil,
+				hexutil.Encode(ev.Data),
+			}
+			for i, topic := range ev.Topics {
+				if topic != nil {
+					row[8+i] = topic.String()
+				}
+			}
+			if err := w.Write(row); err != nil {
+				return 0, err
+			}
+		}
+		n += len(events)
+		if len(events) < pageSize {
+			return n, nil
+		}
+		cursor = events[len(events)-1].Cursor()
+	}
+}
+
+func (e *Exporter) writeTransfers(ctx context.Context, w rowWriter, from, to uint32) (int, error) {
+	var (
+		n      int
+		cursor *logdb.Cursor
+	)
+	for {
+		transfers, err := e.db.FilterTransfers(ctx, &logdb.TransferFilter{
+			Range:   &logdb.Range{From: from, To: to},
+			Options: &logdb.Options{Limit: pageSize, Cursor: cursor},
+			Order:   logdb.ASC,
+		})
+		if err != nil {
+			return 0, err
+		}
+		for _, tr := range transfers {
+			if err := w.Write([]interface{}{
+				int64(tr.BlockNumber),
+				tr.BlockID.String(),
+				int64(tr.BlockTime),
+				tr.TxID.String(),
+				tr.TxOrigin.String(),
+				int64(tr.ClauseIndex),
+				int64(tr.Index),
+				tr.Sender.String(),
+				tr.Recipient.String(),
+				tr.Amount.String(),
+			}); err != nil {
+				return 0, err
+			}
+		}
+		n += len(transfers)
+		if len(transfers) < pageSize {
+			return n, nil
+		}
+		cursor = transfers[len(transfers)-1].Cursor()
+	}
+}
@@ -0,0 +1,486 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package logexport
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/miniBamboo/workshare/block"
+	"github.com/miniBamboo/workshare/logdb"
+	"github.com/miniBamboo/workshare/tx"
+	"github.com/miniBamboo/workshare/workshare"
+	"github.com/stretchr/testify/assert"
+)
+
+func newLogDB(t *testing.T) *logdb.LogDB {
+	db, err := logdb.NewMem()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// logs are in blocks 1 to 10
+	b := new(block.Builder).ParentID(workshare.Bytes32{0xff, 0xff, 0xff, 0xff}).Build()
+	for i := 0; i < 10; i++ {
+		b = new(block.Builder).ParentID(b.Header().ID()).Timestamp(uint64(i)).Build()
+		receipt := &tx.Receipt{Outputs: []*tx.Output{{
+			Events: tx.Events{{
+				Address: workshare.BytesToAddress([]byte{byte(i)}),
+				Topics:  []workshare.Bytes32{workshare.BytesToBytes32([]byte{byte(i)})},
+				Data:    []byte{byte(i)},
+			}},
+			Transfers: tx.Transfers{{
+				Sender:    workshare.BytesToAddress([]byte("sender")),
+				Recipient: workshare.BytesToAddress([]byte("recipient")),
+				Amount:    big.NewInt(int64(i)),
+			}},
+		}}}
+		w := db.NewWriter()
+		if err := w.Write(b, tx.Receipts{receipt}); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+func TestExportCSV(t *testing.T) {
+	db := newLogDB(t)
+	defer db.Close()
+
+	dir := t.TempDir()
+	exporter, err := New(db, dir, CSV, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := exporter.Export(context.Background(), 2, 9); err != nil {
+		t.Fatal(err)
+	}
+
+	// the incomplete partition [8, 11] is not exported
+	for _, table := range []string{"event", "transfer"} {
+		files, _ := filepath.Glob(filepath.Join(dir, table, "*"))
+		assert.Equal(t, []string{
+			filepath.Join(dir, table, "0000000002-0000000003.csv"),
+			filepath.Join(dir, table, "0000000004-0000000007.csv"),
+		}, files)
+	}
+
+	records := readCSV(t, filepath.Join(dir, "event", "0000000004-0000000007.csv"))
+	assert.Equal(t, 5, len(records))
+	assert.Equal(t, []string{"blockNumber", "blockID", "blockTime", "txID", "txOrigin", "clauseIndex", "logIndex",
+		"address", "topic0", "topic1", "topic2", "topic3", "topic4", "data"}, records[0])
+	assert.Equal(t, "4", records[1][0])
+	assert.Equal(t, "0x0000000000000000000000000000000000000003", records[1][7])
+	assert.Equal(t, "0x0000000000000000000000000000000000000000000000000000000000000003", records[1][8])
+	assert.Equal(t, "", records[1][9])
+	assert.Equal(t, "0x03", records[1][13])
+
+	records = readCSV(t, filepath.Join(dir, "transfer", "0000000004-0000000007.csv"))
+	assert.Equal(t, 5, len(records))
+	assert.Equal(t, []string{"7", workshare.BytesToAddress([]byte("recipient")).String(), "6"},
+		[]string{records[4][0], records[4][8], records[4][9]})
+
+	// resumes from the missing partition
+	path := filepath.Join(dir, "event", "0000000004-0000000007.csv")
+	os.Remove(path)
+	other := filepath.Join(dir, "event", "0000000002-0000000003.csv")
+	ioutil.WriteFile(other, []byte("kept"), 0644)
+	if err := exporter.Export(context.Background(), 2, 9); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 5, len(readCSV(t, path)))
+	data, _ := ioutil.ReadFile(other)
+	assert.Equal(t, "kept", string(data))
+}
+
+func TestExportResume(t *testing.T) {
+	db := newLogDB(t)
+	defer db.Close()
+
+	dir := t.TempDir()
+	exporter, err := New(db, dir, CSV, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	export := func(from, to uint32) []string {
+		if err := exporter.Export(context.Background(), from, to); err != nil {
+			t.Fatal(err)
+		}
+		files, _ := filepath.Glob(filepath.Join(dir, "event", "*"))
+		return files
+	}
+	kept := filepath.Join(dir, "event", "0000000004-0000000007.csv")
+	name := func(from, to int) string {
+		return filepath.Join(dir, "event", fmt.Sprintf("%010d-%010d.csv", from, to))
+	}
+
+	assert.Equal(t, []string{name(2, 3), name(4, 7)}, export(2, 9))
+	ioutil.WriteFile(kept, []byte("kept"), 0644)
+
+	// the growing range appends partitions, and the first partition is replaced by the earlier start
+	assert.Equal(t, []string{name(0, 3), name(4, 7), name(8, 11)}, export(0, 11))
+	data, _ := ioutil.ReadFile(kept)
+	assert.Equal(t, "kept", string(data))
+
+	// exported partitions cover a later start
+	assert.Equal(t, []string{name(0, 3), name(4, 7), name(8, 11)}, export(5, 12))
+
+	var blocks []string
+	for _, f := range []string{name(0, 3), name(8, 11)} {
+		for _, r := range readCSV(t, f)[1:] {
+			blocks = append(blocks, r[0])
+		}
+	}
+	assert.Equal(t, []string{"1", "2", "3", "8", "9", "10"}, blocks)
+}
+
+func TestExportPruned(t *testing.T) {
+	db := newLogDB(t)
+	defer db.Close()
+	if _, err := db.Prune(context.Background(), logdb.EventTable, 6, 100); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	exporter, err := New(db, dir, CSV, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := exporter.Export(context.Background(), 0, 10); err != nil {
+		t.Fatal(err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*", "*"))
+	assert.Equal(t, []string{
+		filepath.Join(dir, "event", "0000000006-0000000009.csv"),
+		filepath.Join(dir, "transfer", "0000000000-0000000004.csv"),
+		filepath.Join(dir, "transfer", "0000000005-0000000009.csv"),
+	}, files)
+}
+
+func TestExportParquet(t *testing.T) {
+	db := newLogDB(t)
+	defer db.Close()
+
+	dir := t.TempDir()
+	exporter, err := New(db, dir, Parquet, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := exporter.Export(context.Background(), 0, 99); err != nil {
+		t.Fatal(err)
+	}
+
+	columns := readParquet(t, filepath.Join(dir, "event", "0000000000-0000000099.parquet"), eventColumns)
+	assert.Equal(t, 10, len(columns[0]))
+	assert.Equal(t, int64(1), columns[0][0])
+	assert.Equal(t, int64(10), columns[0][9])
+	assert.Equal(t, "0x0000000000000000000000000000000000000000000000000000000000000009", columns[8][9])
+	assert.Equal(t, []interface{}{nil, nil, nil, nil, nil, nil, nil, nil, nil, nil}, columns[9])
+	assert.Equal(t, "0x09", columns[13][9])
+
+	columns = readParquet(t, filepath.Join(dir, "transfer", "0000000000-0000000099.parquet"), transferColumns)
+	assert.Equal(t, []interface{}{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"}, columns[9])
+}
+
+func TestParquetRowGroups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.parquet")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	columns := []*column{{"n", int64Type, false}, {"s", stringType, true}}
+	w, err := newParquetWriter(file, columns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const nRows = parquetRowGroupSize + 10
+	for i := 0; i < nRows; i++ {
+		var s interface{}
+		if i%3 == 0 {
+			s = "v"
+		}
+		if err := w.Write([]interface{}{int64(i), s}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assert.NotNil(t, w.Write([]interface{}{nil, nil}), "required")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	values := readParquet(t, path, columns)
+	assert.Equal(t, nRows, len(values[0]))
+	for i := 0; i < nRows; i++ {
+		assert.Equal(t, int64(i), values[0][i])
+		if i%3 == 0 {
+			assert.Equal(t, "v", values[1][i])
+		} else {
+			assert.Nil(t, values[1][i])
+		}
+	}
+}
+
+var updateGolden = flag.Bool("update", false, "update golden files")
+
+// goldenRows are written into testdata/golden.parquet in row groups of 4 rows, and
+// testdata/golden.json holds the expected values by column.
+// The golden file only pins the output, and is read back by readParquet of this package,
+// so it's not verified against any other Parquet reader. It's to be checked with one after
+// updated, e.g. comparing golden.json with the output of
+//
+//	python3 -c 'import pyarrow.parquet as pq; print(pq.read_table("golden.parquet").to_pydict())'
+var (
+	goldenColumns = []*column{{"n", int64Type, false}, {"s", stringType, true}, {"t", stringType, false}}
+	goldenRows    = [][]interface{}{
+		{int64(0), "a", ""},
+		{int64(-1), nil, "0x00"},
+		{int64(math.MaxInt64), "日本", "b"},
+		{int64(math.MinInt64), nil, "c"},
+		{int64(4), nil, "d"},
+		{int64(5), "", "e"},
+		{int64(6), nil, "f"},
+		{int64(7), nil, "g"},
+		{int64(8), nil, "h"},
+		{int64(9), "z", "i"},
+	}
+)
+
+func TestParquetGolden(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "golden.parquet")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := newParquetWriter(file, goldenColumns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.rowGroupSize = 4
+	for _, row := range goldenRows {
+		if err := w.Write(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	goldenPath := filepath.Join("testdata", "golden.parquet")
+	if *updateGolden {
+		if err := ioutil.WriteFile(goldenPath, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	golden, err := ioutil.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, golden, data, "output changed, run with -update and check it with an external reader")
+
+	// values of the golden file, as read by readParquet
+	var want map[string][]interface{}
+	blob, err := ioutil.ReadFile(filepath.Join("testdata", "golden.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(blob))
+	dec.UseNumber()
+	if err := dec.Decode(&want); err != nil {
+		t.Fatal(err)
+	}
+	for i, values := range readParquet(t, goldenPath, goldenColumns) {
+		c := goldenColumns[i]
+		assert.Equal(t, len(want[c.name]), len(values), c.name)
+		for j, v := range want[c.name] {
+			if n, ok := v.(json.Number); ok {
+				v, _ = n.Int64()
+			}
+			assert.Equal(t, v, values[j], "%v[%v]", c.name, j)
+		}
+	}
+}
+
+func readCSV(t *testing.T, path string) [][]string {
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return records
+}
+
+// readParquet reads values of each column, checking the schema against columns.
+func readParquet(t *testing.T, path string, columns []*column) [][]interface{} {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := len(data)
+	if string(data[:4]) != parquetMagic || string(data[n-4:]) != parquetMagic {
+		t.Fatal("bad magic")
+	}
+	footerLen := int(binary.LittleEndian.Uint32(data[n-8:]))
+	meta := newThriftReader(data[n-8-footerLen : n-8]).readStruct()
+
+	schema := meta[2].([]interface{})
+	assert.Equal(t, "schema", string(schema[0].(map[int16]interface{})[4].([]byte)))
+	assert.Equal(t, int64(len(columns)), schema[0].(map[int16]interface{})[5])
+	for i, c := range columns {
+		el := schema[i+1].(map[int16]interface{})
+		assert.Equal(t, c.name, string(el[4].([]byte)))
+		if c.optional {
+			assert.Equal(t, int64(parquetOptional), el[3])
+		} else {
+			assert.Equal(t, int64(parquetRequired), el[3])
+		}
+	}
+
+	values := make([][]interface{}, len(columns))
+	var nRows int64
+	for _, rg := range meta[4].([]interface{}) {
+		rg := rg.(map[int16]interface{})
+		nRows += rg[3].(int64)
+		for i, cc := range rg[1].([]interface{}) {
+			cm := cc.(map[int16]interface{})[3].(map[int16]interface{})
+			assert.Equal(t, c2s(columns[i].name), cm[3])
+			offset := cm[9].(int64)
+			r := newThriftReader(data[offset:])
+			header := r.readStruct()
+			page := data[int(offset)+r.pos : int(offset)+r.pos+int(header[3].(int64))]
+			assert.Equal(t, cm[7].(int64), int64(r.pos)+header[3].(int64))
+			nValues := int(header[5].(map[int16]interface{})[1].(int64))
+			assert.Equal(t, cm[5].(int64), int64(nValues))
+
+			present := make([]bool, nValues)
+			if columns[i].optional {
+				l := int(binary.LittleEndian.Uint32(page))
+				levels := page[4 : 4+l]
+				page = page[4+l:]
+				h, m := binary.Uvarint(levels)
+				assert.Equal(t, uint64(1), h&1, "bit-packed")
+				for j := range present {
+					present[j] = levels[m+j/8]&(1<<uint(j%8)) != 0
+				}
+			} else {
+				for j := range present {
+					present[j] = true
+				}
+			}
+			for j := 0; j < nValues; j++ {
+				if !present[j] {
+					values[i] = append(values[i], nil)
+					continue
+				}
+				if columns[i].typ == int64Type {
+					values[i] = append(values[i], int64(binary.LittleEndian.Uint64(page)))
+					page = page[8:]
+				} else {
+					l := int(binary.LittleEndian.Uint32(page))
+					values[i] = append(values[i], string(page[4:4+l]))
+					page = page[4+l:]
+				}
+			}
+			assert.Equal(t, 0, len(page))
+		}
+	}
+	assert.Equal(t, meta[3], nRows)
+	return values
+}
+
+func c2s(s string) []interface{} {
+	return []interface{}{[]byte(s)}
+}
+
+// thriftReader decodes structs in the thrift compact protocol, into maps of field id to value.
+type thriftReader struct {
+	data []byte
+	pos  int
+}
+
+func newThriftReader(data []byte) *thriftReader {
+	return &thriftReader{data: data}
+}
+
+func (r *thriftReader) byte() byte {
+	b := r.data[r.pos]
+	r.pos++
+	return b
+}
+
+func (r *thriftReader) varint() uint64 {
+	v, n := binary.Uvarint(r.data[r.pos:])
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) zigzag() int64 {
+	v := r.varint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) value(typ byte) interface{} {
+	switch typ {
+	case thriftI32, thriftI64:
+		return r.zigzag()
+	case thriftBinary:
+		n := int(r.varint())
+		b := append([]byte(nil), r.data[r.pos:r.pos+n]...)
+		r.pos += n
+		return b
+	case thriftList:
+		h := r.byte()
+		n := int(h >> 4)
+		if n == 15 {
+			n = int(r.varint())
+		}
+		list := make([]interface{}, n)
+		for i := range list {
+			list[i] = r.value(h & 0x0f)
+		}
+		return list
+	case thriftStruct:
+		return r.readStruct()
+	}
+	panic("unsupported type")
+}
+
+func (r *thriftReader) readStruct() map[int16]interface{} {
+	fields := make(map[int16]interface{})
+	var last int16
+	for {
+		h := r.byte()
+		if h == 0 {
+			return fields
+		}
+		id := last + int16(h>>4)
+		if h>>4 == 0 {
+			id = int16(r.zigzag())
+		}
+		fields[id] = r.value(h & 0x0f)
+		last = id
+	}
+}
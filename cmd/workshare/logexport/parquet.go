@@ -0,0 +1,348 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package logexport
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// A minimal Parquet writer, which writes columns of INT64 and UTF8 BYTE_ARRAY types,
+// in PLAIN encoding without compression, one data page per column chunk.
+// See https://github.com/apache/parquet-format.
+
+const (
+	parquetMagic        = "PAR1"
+	parquetRowGroupSize = 64 * 1024 // default max rows in a row group
+
+	// physical types
+	parquetInt64     = 2
+	parquetByteArray = 6
+
+	// repetition types
+	parquetRequired = 0
+	parquetOptional = 1
+
+	// encodings
+	parquetPlain = 0
+	parquetRLE   = 3
+
+	parquetUTF8 = 0 // converted type
+)
+
+type parquetColumn struct {
+	*column
+	values    bytes.Buffer // plain encoded non-null values
+	defLevels []bool       // whether each value is present, for optional columns
+	nValues   int
+}
+
+type parquetChunk struct {
+	offset  int64
+	size    int64
+	nValues int64
+}
+
+type parquetRowGroup struct {
+	chunks []parquetChunk
+	nRows  int64
+}
+
+type parquetWriter struct {
+	w            io.WriteCloser
+	offset       int64
+	columns      []*parquetColumn
+	rowGroupSize int
+	nRows        int // rows in the current row group
+	rowGroups    []*parquetRowGroup
+}
+
+func newParquetWriter(w io.WriteCloser, columns []*column) (*parquetWriter, error) {
+	pw := &parquetWriter{w: w, rowGroupSize: parquetRowGroupSize}
+	for _, c := range columns {
+		pw.columns = append(pw.columns, &parquetColumn{column: c})
+	}
+	if err := pw.write([]byte(parquetMagic)); err != nil {
+		return nil, err
+	}
+	return pw, nil
+}
+
+func (pw *parquetWriter) write(b []byte) error {
+	n, err := pw.w.Write(b)
+	pw.offset += int64(n)
+	return err
+}
+
+func (pw *parquetWriter) Write(row []interface{}) error {
+	if len(row) != len(pw.columns) {
+		return fmt.Errorf("expected %v values, got %v", len(pw.columns), len(row))
+	}
+	for i, c := range pw.columns {
+		if row[i] == nil && !c.optional {
+			return fmt.Errorf("column %v: missing value", c.name)
+		}
+	}
+	for i, c := range pw.columns {
+		v := row[i]
+		if c.optional {
+			c.defLevels = append(c.defLevels, v != nil)
+		}
+		c.nValues++
+		if v == nil {
+			continue
+		}
+		switch c.typ {
+		case int64Type:
+			var b [8]byte
+			binary.LittleEndian.PutUint64(b[:], uint64(v.(int64)))
+			c.values.Write(b[:])
+		case stringType:
+			s := v.(string)
+			var b [4]byte
+			binary.LittleEndian.PutUint32(b[:], uint32(len(s)))
+			c.values.Write(b[:])
+			c.values.WriteString(s)
+		}
+	}
+	pw.nRows++
+	if pw.nRows >= pw.rowGroupSize {
+		return pw.flush()
+	}
+	return nil
+}
+
+// flush writes buffered rows as a row group.
+func (pw *parquetWriter) flush() error {
+	rg := &parquetRowGroup{nRows: int64(pw.nRows)}
+	for _, c := range pw.columns {
+		var data bytes.Buffer
+		if c.optional {
+			levels := encodeDefLevels(c.defLevels)
+			var b [4]byte
+			binary.LittleEndian.PutUint32(b[:], uint32(len(levels)))
+			data.Write(b[:])
+			data.Write(levels)
+		}
+		data.Write(c.values.Bytes())
+
+		var t thriftWriter
+		t.beginStruct() // PageHeader
+		t.i32(1, 0)     // DATA_PAGE
+		t.i32(2, int32(data.Len()))
+		t.i32(3, int32(data.Len()))
+		t.beginStructField(5) // DataPageHeader
+		t.i32(1, int32(c.nValues))
+		t.i32(2, parquetPlain)
+		t.i32(3, parquetRLE)
+		t.i32(4, parquetRLE)
+		t.endStruct()
+		t.endStruct()
+
+		chunk := parquetChunk{offset: pw.offset, nValues: int64(c.nValues)}
+		if err := pw.write(t.buf.Bytes()); err != nil {
+			return err
+		}
+		if err := pw.write(data.Bytes()); err != nil {
+			return err
+		}
+		chunk.size = pw.offset - chunk.offset
+		rg.chunks = append(rg.chunks, chunk)
+
+		c.values.Reset()
+		c.defLevels = c.defLevels[:0]
+		c.nValues = 0
+	}
+	pw.rowGroups = append(pw.rowGroups, rg)
+	pw.nRows = 0
+	return nil
+}
+
+// encodeDefLevels encodes levels of bit width 1 in a bit-packed run of the RLE/bit-packing hybrid encoding.
+func encodeDefLevels(levels []bool) []byte {
+	nGroups := (len(levels) + 7) / 8
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], uint64(nGroups)<<1|1)
+	b := make([]byte, n+nGroups)
+	copy(b, buf[:n])
+	for i, present := range levels {
+		if present {
+			b[n+i/8] |= 1 << uint(i%8)
+		}
+	}
+	return b
+}
+
+// Close writes the footer and closes the underlying writer.
+func (pw *parquetWriter) Close() error {
+	if pw.nRows > 0 {
+		if err := pw.flush(); err != nil {
+			return err
+		}
+	}
+
+	var (
+		t     thriftWriter
+		nRows int64
+	)
+	for _, rg := range pw.rowGroups {
+		nRows += rg.nRows
+	}
+
+	t.beginStruct() // FileMetaData
+	t.i32(1, 1)     // version
+	t.list(2, thriftStruct, len(pw.columns)+1)
+	t.beginStruct() // root SchemaElement
+	t.binary(4, "schema")
+	t.i32(5, int32(len(pw.columns)))
+	t.endStruct()
+	for _, c := range pw.columns {
+		t.beginStruct()
+		t.i32(1, c.physicalType())
+		if c.optional {
+			t.i32(3, parquetOptional)
+		} else {
+			t.i32(3, parquetRequired)
+		}
+		t.binary(4, c.name)
+		if c.typ == stringType {
+			t.i32(6, parquetUTF8)
+		}
+		t.endStruct()
+	}
+	t.i64(3, nRows)
+	t.list(4, thriftStruct, len(pw.rowGroups))
+	for _, rg := range pw.rowGroups {
+		var totalSize int64
+		t.beginStruct() // RowGroup
+		t.list(1, thriftStruct, len(rg.chunks))
+		for i, chunk := range rg.chunks {
+			c := pw.columns[i]
+			t.beginStruct() // ColumnChunk
+			t.i64(2, chunk.offset)
+			t.beginStructField(3) // ColumnMetaData
+			t.i32(1, c.physicalType())
+			if c.optional {
+				t.list(2, thriftI32, 2)
+				t.varint(zigzag(parquetPlain))
+				t.varint(zigzag(parquetRLE))
+			} else {
+				t.list(2, thriftI32, 1)
+				t.varint(zigzag(parquetPlain))
+			}
+			t.list(3, thriftBinary, 1)
+			t.varint(uint64(len(c.name)))
+			t.buf.WriteString(c.name)
+			t.i32(4, 0) // UNCOMPRESSED
+			t.i64(5, chunk.nValues)
+			t.i64(6, chunk.size)
+			t.i64(7, chunk.size)
+			t.i64(9, chunk.offset)
+			t.endStruct()
+			t.endStruct()
+			totalSize += chunk.size
+		}
+		t.i64(2, totalSize)
+		t.i64(3, rg.nRows)
+		t.endStruct()
+	}
+	t.binary(6, "workshare")
+	t.endStruct()
+
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], uint32(t.buf.Len()))
+	for _, data := range [][]byte{t.buf.Bytes(), b[:], []byte(parquetMagic)} {
+		if err := pw.write(data); err != nil {
+			return err
+		}
+	}
+	return pw.w.Close()
+}
+
+func (c *parquetColumn) physicalType() int32 {
+	if c.typ == int64Type {
+		return parquetInt64
+	}
+	return parquetByteArray
+}
+
+// types of the thrift compact protocol
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes structs in the thrift compact protocol.
+type thriftWriter struct {
+	buf     bytes.Buffer
+	lastIDs []int16 // the last field id of each struct being written
+}
+
+func zigzag(v int64) uint64 {
+	return uint64((v << 1) ^ (v >> 63))
+}
+
+func (t *thriftWriter) varint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	t.buf.Write(b[:binary.PutUvarint(b[:], v)])
+}
+
+func (t *thriftWriter) field(id int16, typ byte) {
+	last := &t.lastIDs[len(t.lastIDs)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		t.buf.WriteByte(typ)
+		t.varint(zigzag(int64(id)))
+	}
+	*last = id
+}
+
+func (t *thriftWriter) beginStruct() {
+	t.lastIDs = append(t.lastIDs, 0)
+}
+
+func (t *thriftWriter) beginStructField(id int16) {
+	t.field(id, thriftStruct)
+	t.beginStruct()
+}
+
+func (t *thriftWriter) endStruct() {
+	t.buf.WriteByte(0) // stop
+	t.lastIDs = t.lastIDs[:len(t.lastIDs)-1]
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.varint(zigzag(int64(v)))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.varint(zigzag(v))
+}
+
+func (t *thriftWriter) binary(id int16, s string) {
+	t.field(id, thriftBinary)
+	t.varint(uint64(len(s)))
+	t.buf.WriteString(s)
+}
+
+// list writes the list header, followed by n elements written by the caller.
+func (t *thriftWriter) list(id int16, elemType byte, n int) {
+	t.field(id, thriftList)
+	if n < 15 {
+		t.buf.WriteByte(byte(n)<<4 | elemType)
+	} else {
+		t.buf.WriteByte(0xf0 | elemType)
+		t.varint(uint64(n))
+	}
+}
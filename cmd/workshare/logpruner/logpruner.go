@@ -0,0 +1,229 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package logpruner
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/miniBamboo/workshare/block"
+	"github.com/miniBamboo/workshare/chain"
+	"github.com/miniBamboo/workshare/co"
+	"github.com/miniBamboo/workshare/logdb"
+	"github.com/miniBamboo/workshare/metric"
+	"github.com/pkg/errors"
+)
+
+var (
+	log = log15.New("pkg", "logpruner")
+
+	metricPrunedBefore = metric.NewGaugeVec("logpruner_block_number", "Block number before which logs have been pruned, by table.", "table")
+)
+
+const (
+	interval  = time.Minute // the interval to enforce the policy
+	batchSize = 1000        // max logs deleted in a write transaction
+)
+
+// Retention is how long logs are kept. Logs are kept forever if both are zero,
+// and the longer one is taken if both are set.
+type Retention struct {
+	Blocks uint32 // keep logs of the last n blocks
+	Days   uint32 // keep logs of blocks produced in the last n days
+}
+
+// Policy is the retention of each log table. Tables absent are kept forever.
+type Policy map[logdb.Table]Retention
+
+// ParsePolicy parses comma separated retentions, each of which is the number of blocks (e.g. '1000000'),
+// or days (e.g. '90d'), optionally prefixed with the table name (e.g. 'transfer=30d').
+// Retention without table name applies to all tables not specified.
+func ParsePolicy(s string) (Policy, error) {
+	var (
+		policy = make(Policy)
+		def    *Retention
+	)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		var table logdb.Table
+		if i := strings.Index(item, "="); i >= 0 {
+			table = logdb.Table(strings.TrimSpace(item[:i]))
+			item = strings.TrimSpace(item[i+1:])
+			if !isTable(table) {
+				return nil, fmt.Errorf("unknown table %q", string(table))
+			}
+		}
+		r, err := parseRetention(item)
+		if err != nil {
+			return nil, err
+		}
+		if table == "" {
+			if def != nil {
+				return nil, errors.New("duplicated default retention")
+			}
+			def = &r
+		} else {
+			if _, ok := policy[table]; ok {
+				return nil, fmt.Errorf("duplicated retention of table %q", string(table))
+			}
+			policy[table] = r
+		}
+	}
+	if def != nil {
+		for _, table := range logdb.Tables {
+			if _, ok := policy[table]; !ok {
+				policy[table] = *def
+			}
+		}
+	}
+	return policy, nil
+}
+
+func parseRetention(s string) (Retention, error) {
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.ParseUint(strings.TrimSuffix(s, "d"), 10, 32)
+		if err != nil || days == 0 {
+			return Retention{}, fmt.Errorf("invalid retention %q", s)
+		}
+		return Retention{Days: uint32(days)}, nil
+	}
+	blocks, err := strconv.ParseUint(s, 10, 32)
+	if err != nil || blocks == 0 {
+		return Retention{}, fmt.Errorf("invalid retention %q", s)
+	}
+	return Retention{Blocks: uint32(blocks)}, nil
+}
+
+func isTable(t logdb.Table) bool {
+	for _, table := range logdb.Tables {
+		if table == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Pruner is a background task to delete logs out of retention.
+type Pruner struct {
+	db     *logdb.LogDB
+	repo   *chain.Repository
+	policy Policy
+	ctx    context.Context
+	cancel func()
+	goes   co.Goes
+}
+
+// New creates and starts the pruner.
+func New(db *logdb.LogDB, repo *chain.Repository, policy Policy) *Pruner {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Pruner{
+		db:     db,
+		repo:   repo,
+		policy: policy,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	p.goes.Go(func() {
+		if err := p.loop(); err != nil {
+			if err != context.Canceled && errors.Cause(err) != context.Canceled {
+				log.Warn("log pruner interrupted", "error", err)
+			}
+		}
+	})
+	return p
+}
+
+// Stop stops the pruner.
+func (p *Pruner) Stop() {
+	p.cancel()
+	p.goes.Wait()
+}
+
+// loop is the main loop.
+func (p *Pruner) loop() error {
+	log.Info("log pruner started")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.prune(); err != nil {
+			if p.ctx.Err() != nil {
+				return p.ctx.Err()
+			}
+			// retry in the next round
+			log.Warn("failed to prune logs", "error", err)
+		}
+		select {
+		case <-p.ctx.Done():
+			return p.ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// prune enforces the policy on each table.
+func (p *Pruner) prune() error {
+	var (
+		best  = p.repo.BestBlockSummary().Header
+		chain = p.repo.NewChain(best.ID())
+	)
+	for _, table := range logdb.Tables {
+		r, ok := p.policy[table]
+		if !ok {
+			continue
+		}
+		before, err := cutoff(chain, best, r)
+		if err != nil {
+			return errors.Wrap(err, "cutoff")
+		}
+		if before == 0 {
+			continue
+		}
+		startTime := time.Now()
+		n, err := p.db.Prune(p.ctx, table, before, batchSize)
+		if err != nil {
+			return errors.Wrap(err, "prune "+string(table))
+		}
+		metricPrunedBefore.With(string(table)).Set(float64(p.db.PrunedBefore(table)))
+		if n > 0 {
+			log.Info("pruned logs", "table", table, "before", before, "count", n, "et", time.Since(startTime))
+		}
+	}
+	return nil
+}
+
+// cutoff returns the number of the oldest block to keep by the retention.
+func cutoff(chain *chain.Chain, best *block.Header, r Retention) (uint32, error) {
+	var before uint32
+	if r.Blocks > 0 {
+		if best.Number() < r.Blocks {
+			return 0, nil
+		}
+		before = best.Number() - r.Blocks + 1
+	}
+	if r.Days > 0 {
+		earliest := int64(best.Timestamp()) - int64(r.Days)*24*3600
+		if earliest <= 0 {
+			return 0, nil
+		}
+		header, err := chain.FindBlockHeaderByTimestamp(uint64(earliest), 1)
+		if err != nil {
+			return 0, err
+		}
+		if r.Blocks == 0 || header.Number() < before {
+			before = header.Number()
+		}
+	}
+	return before, nil
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package logpruner
+
+import (
+	"testing"
+
+	"github.com/miniBamboo/workshare/block"
+	"github.com/miniBamboo/workshare/chain"
+	"github.com/miniBamboo/workshare/logdb"
+	"github.com/miniBamboo/workshare/muxdb"
+	"github.com/miniBamboo/workshare/workshare"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    Policy
+		wantErr bool
+	}{
+		{"", Policy{}, false},
+		{"1000", Policy{
+			logdb.EventTable:         {Blocks: 1000},
+			logdb.TransferTable:      {Blocks: 1000},
+			logdb.TokenTransferTable: {Blocks: 1000},
+		}, false},
+		{"90d, transfer=1000", Policy{
+			logdb.EventTable:         {Days: 90},
+			logdb.TransferTable:      {Blocks: 1000},
+			logdb.TokenTransferTable: {Days: 90},
+		}, false},
+		{"event=30d", Policy{logdb.EventTable: {Days: 30}}, false},
+		{"ref=30d", nil, true},
+		{"0", nil, true},
+		{"30days", nil, true},
+		{"30d,60d", nil, true},
+		{"event=30d,event=60d", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParsePolicy(tt.s)
+		if tt.wantErr {
+			assert.NotNil(t, err, tt.s)
+		} else {
+			assert.Nil(t, err, tt.s)
+			assert.Equal(t, tt.want, got, tt.s)
+		}
+	}
+}
+
+func TestCutoff(t *testing.T) {
+	const day = 24 * 3600
+
+	// a block every half day
+	b := new(block.Builder).ParentID(workshare.Bytes32{0xff, 0xff, 0xff, 0xff}).Timestamp(day).Build()
+	repo, err := chain.NewRepository(muxdb.NewMem(), b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		b = new(block.Builder).ParentID(b.Header().ID()).Timestamp(b.Header().Timestamp() + day/2).Build()
+		if err := repo.AddBlock(b, nil, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	best := b.Header()
+	c := repo.NewChain(best.ID())
+
+	tests := []struct {
+		r    Retention
+		want uint32
+	}{
+		{Retention{Blocks: 4}, 7},
+		{Retention{Blocks: 11}, 0},
+		{Retention{Days: 2}, 6},
+		{Retention{Days: 100}, 0},
+		{Retention{Blocks: 2, Days: 2}, 6},
+		{Retention{Blocks: 6, Days: 1}, 5},
+	}
+	for _, tt := range tests {
+		got, err := cutoff(c, best, tt.r)
+		assert.Nil(t, err)
+		assert.Equal(t, tt.want, got, "%+v", tt.r)
+	}
+}
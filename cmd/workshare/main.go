@@ -18,6 +18,11 @@ import (
 	"github.com/inconshreveable/log15"
 	isatty "github.com/mattn/go-isatty"
 	"github.com/miniBamboo/workshare/api"
+	"github.com/miniBamboo/workshare/api/abis"
+	apiNode "github.com/miniBamboo/workshare/api/node"
+	"github.com/miniBamboo/workshare/block"
+	"github.com/miniBamboo/workshare/cmd/workshare/logexport"
+	"github.com/miniBamboo/workshare/cmd/workshare/logpruner"
 	"github.com/miniBamboo/workshare/cmd/workshare/node"
 	"github.com/miniBamboo/workshare/cmd/workshare/optimizer"
 	"github.com/miniBamboo/workshare/cmd/workshare/solo"
@@ -57,7 +62,15 @@ func fullVersion() string {
 }
 
 func main() {
-	app := cli.App{
+	if err := newApp().Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// newApp creates the app with its commands and their flags.
+func newApp() *cli.App {
+	return &cli.App{
 		Version:   fullVersion(),
 		Name:      "Thor",
 		Usage:     "Node of VeChain Thor Network",
@@ -74,13 +87,26 @@ func main() {
 			apiTimeoutFlag,
 			apiCallGasLimitFlag,
 			apiBacktraceLimitFlag,
+			apiMaxBlockRangeFlag,
+			apiGraphQLCostLimitFlag,
+			apiABIDirFlag,
+			apiKeysFlag,
+			apiTLSCertFlag,
+			apiTLSKeyFlag,
+			apiTLSClientCAFlag,
+			apiReadyMaxBlockAgeFlag,
+			apiReadyMinPeersFlag,
 			verbosityFlag,
 			maxPeersFlag,
 			p2pPortFlag,
 			natFlag,
 			bootNodeFlag,
 			skipLogsFlag,
+			logRetentionFlag,
 			pprofFlag,
+			metricsFlag,
+			metricsAddrFlag,
+			adminAddrFlag,
 			verifyLogsFlag,
 			disablePrunerFlag,
 		},
@@ -97,13 +123,24 @@ func main() {
 					apiTimeoutFlag,
 					apiCallGasLimitFlag,
 					apiBacktraceLimitFlag,
+					apiMaxBlockRangeFlag,
+					apiGraphQLCostLimitFlag,
+					apiABIDirFlag,
+					apiKeysFlag,
+					apiTLSCertFlag,
+					apiTLSKeyFlag,
+					apiTLSClientCAFlag,
+					apiTLSSelfSignedFlag,
 					onDemandFlag,
 					persistFlag,
 					gasLimitFlag,
 					verbosityFlag,
 					pprofFlag,
+					metricsFlag,
+					metricsAddrFlag,
 					verifyLogsFlag,
 					skipLogsFlag,
+					logRetentionFlag,
 					txPoolLimitFlag,
 					txPoolLimitPerAccountFlag,
 					disablePrunerFlag,
@@ -120,13 +157,30 @@ func main() {
 				},
 				Action: masterKeyAction,
 			},
+			{
+				Name:  "logs",
+				Usage: "log db management",
+				Subcommands: []cli.Command{
+					{
+						Name:  "export",
+						Usage: "export event and transfer logs to files of complete block range partitions, resuming from the last written partition",
+						Flags: []cli.Flag{
+							networkFlag,
+							dataDirFlag,
+							disablePrunerFlag,
+							exportDirFlag,
+							exportFormatFlag,
+							exportFromFlag,
+							exportToFlag,
+							exportPartitionSizeFlag,
+							verbosityFlag,
+						},
+						Action: logsExportAction,
+					},
+				},
+			},
 		},
 	}
-
-	if err := app.Run(os.Args); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
-	}
 }
 
 func defaultAction(ctx *cli.Context) error {
@@ -135,6 +189,10 @@ func defaultAction(ctx *cli.Context) error {
 	defer func() { log.Info("exited") }()
 
 	initLogger(ctx)
+	logRetention, err := logpruner.ParsePolicy(ctx.String(logRetentionFlag.Name))
+	if err != nil {
+		return errors.WithMessage(err, logRetentionFlag.Name)
+	}
 	gene, forkConfig, err := selectGenesis(ctx)
 	if err != nil {
 		return err
@@ -184,6 +242,32 @@ func defaultAction(ctx *cli.Context) error {
 	if err != nil {
 		return err
 	}
+	n := node.New(
+		master,
+		repo,
+		state.NewStater(mainDB),
+		logDB,
+		txPool,
+		filepath.Join(instanceDir, "tx.stash"),
+		p2pcom.comm,
+		uint64(ctx.Int(targetGasLimitFlag.Name)),
+		skipLogs,
+		forkConfig)
+
+	apiAuth, err := loadAPIAuth(ctx)
+	if err != nil {
+		return err
+	}
+	if apiAuth != nil {
+		defer apiAuth.Close()
+	}
+
+	abiRegistry, err := abis.NewRegistry(ctx.String(apiABIDirFlag.Name))
+	if err != nil {
+		return errors.Wrap(err, "load ABIs")
+	}
+
+	masterAddr := master.Address()
 	apiHandler, apiCloser := api.New(
 		repo,
 		state.NewStater(mainDB),
@@ -193,7 +277,26 @@ func defaultAction(ctx *cli.Context) error {
 		ctx.String(apiCorsFlag.Name),
 		uint32(ctx.Int(apiBacktraceLimitFlag.Name)),
 		uint64(ctx.Int(apiCallGasLimitFlag.Name)),
+		uint32(ctx.Int(apiMaxBlockRangeFlag.Name)),
+		uint64(ctx.Int(apiGraphQLCostLimitFlag.Name)),
+		abiRegistry,
+		apiAuth,
+		apiNode.HealthConfig{
+			MaxBlockAge: time.Duration(ctx.Int(apiReadyMaxBlockAgeFlag.Name)) * time.Second,
+			MinPeers:    ctx.Int(apiReadyMinPeersFlag.Name),
+			LogDBFailed: n.LogDBFailed,
+		},
+		apiNode.Info{
+			Version:     fullVersion(),
+			GitCommit:   gitCommit,
+			Enode:       p2pcom.enode,
+			Master:      &masterAddr,
+			Beneficiary: master.Beneficiary,
+			ForkConfig:  forkConfig,
+			DataDir:     instanceDir,
+		},
 		ctx.Bool(pprofFlag.Name),
+		ctx.Bool(metricsFlag.Name) && ctx.String(metricsAddrFlag.Name) == "",
 		skipLogs,
 		forkConfig)
 	defer func() { log.Info("closing API..."); apiCloser() }()
@@ -204,6 +307,18 @@ func defaultAction(ctx *cli.Context) error {
 	}
 	defer func() { log.Info("stopping API server..."); srvCloser() }()
 
+	metricsCloser, err := startMetricsServer(ctx)
+	if err != nil {
+		return err
+	}
+	defer metricsCloser()
+
+	adminCloser, err := startAdminServer(ctx, p2pcom.p2pSrv, abiRegistry)
+	if err != nil {
+		return err
+	}
+	defer adminCloser()
+
 	printStartupMessage2(apiURL, p2pcom.enode)
 
 	if err := p2pcom.Start(); err != nil {
@@ -214,17 +329,12 @@ func defaultAction(ctx *cli.Context) error {
 	optimizer := optimizer.New(mainDB, repo, !ctx.Bool(disablePrunerFlag.Name))
 	defer func() { log.Info("stopping optimizer..."); optimizer.Stop() }()
 
-	return node.New(
-		master,
-		repo,
-		state.NewStater(mainDB),
-		logDB,
-		txPool,
-		filepath.Join(instanceDir, "tx.stash"),
-		p2pcom.comm,
-		uint64(ctx.Int(targetGasLimitFlag.Name)),
-		skipLogs,
-		forkConfig).Run(exitSignal)
+	if !skipLogs && len(logRetention) > 0 {
+		logPruner := logpruner.New(logDB, repo, logRetention)
+		defer func() { log.Info("stopping log pruner..."); logPruner.Stop() }()
+	}
+
+	return n.Run(exitSignal)
 }
 
 func soloAction(ctx *cli.Context) error {
@@ -232,6 +342,10 @@ func soloAction(ctx *cli.Context) error {
 	defer func() { log.Info("exited") }()
 
 	initLogger(ctx)
+	logRetention, err := logpruner.ParsePolicy(ctx.String(logRetentionFlag.Name))
+	if err != nil {
+		return errors.WithMessage(err, logRetentionFlag.Name)
+	}
 	gene := genesis.NewDevnet()
 	// Solo forks from the start
 	forkConfig := workshare.ForkConfig{}
@@ -239,7 +353,6 @@ func soloAction(ctx *cli.Context) error {
 	var mainDB *muxdb.MuxDB
 	var logDB *logdb.LogDB
 	var instanceDir string
-	var err error
 
 	if ctx.Bool(persistFlag.Name) {
 		if instanceDir, err = makeInstanceDir(ctx, gene); err != nil {
@@ -279,6 +392,23 @@ func soloAction(ctx *cli.Context) error {
 	txPool := txpool.New(repo, state.NewStater(mainDB), txPoolOption)
 	defer func() { log.Info("closing tx pool..."); txPool.Close() }()
 
+	apiAuth, err := loadAPIAuth(ctx)
+	if err != nil {
+		return err
+	}
+	if apiAuth != nil {
+		defer apiAuth.Close()
+	}
+
+	abiRegistry, err := abis.NewRegistry(ctx.String(apiABIDirFlag.Name))
+	if err != nil {
+		return errors.Wrap(err, "load ABIs")
+	}
+
+	var dataDir string
+	if ctx.Bool(persistFlag.Name) {
+		dataDir = instanceDir
+	}
 	apiHandler, apiCloser := api.New(
 		repo,
 		state.NewStater(mainDB),
@@ -288,7 +418,19 @@ func soloAction(ctx *cli.Context) error {
 		ctx.String(apiCorsFlag.Name),
 		uint32(ctx.Int(apiBacktraceLimitFlag.Name)),
 		uint64(ctx.Int(apiCallGasLimitFlag.Name)),
+		uint32(ctx.Int(apiMaxBlockRangeFlag.Name)),
+		uint64(ctx.Int(apiGraphQLCostLimitFlag.Name)),
+		abiRegistry,
+		apiAuth,
+		apiNode.HealthConfig{},
+		apiNode.Info{
+			Version:    fullVersion(),
+			GitCommit:  gitCommit,
+			ForkConfig: forkConfig,
+			DataDir:    dataDir,
+		},
 		ctx.Bool(pprofFlag.Name),
+		ctx.Bool(metricsFlag.Name) && ctx.String(metricsAddrFlag.Name) == "",
 		skipLogs,
 		forkConfig)
 	defer func() { log.Info("closing API..."); apiCloser() }()
@@ -299,11 +441,22 @@ func soloAction(ctx *cli.Context) error {
 	}
 	defer func() { log.Info("stopping API server..."); srvCloser() }()
 
+	metricsCloser, err := startMetricsServer(ctx)
+	if err != nil {
+		return err
+	}
+	defer metricsCloser()
+
 	printSoloStartupMessage(gene, repo, instanceDir, apiURL, forkConfig)
 
 	optimizer := optimizer.New(mainDB, repo, !ctx.Bool(disablePrunerFlag.Name))
 	defer func() { log.Info("stopping optimizer..."); optimizer.Stop() }()
 
+	if !skipLogs && len(logRetention) > 0 {
+		logPruner := logpruner.New(logDB, repo, logRetention)
+		defer func() { log.Info("stopping log pruner..."); logPruner.Stop() }()
+	}
+
 	return solo.New(repo,
 		state.NewStater(mainDB),
 		logDB,
@@ -402,3 +555,48 @@ func masterKeyAction(ctx *cli.Context) error {
 	}
 	return nil
 }
+
+func logsExportAction(ctx *cli.Context) error {
+	exitSignal := handleExitSignal()
+
+	initLogger(ctx)
+	gene, _, err := selectGenesis(ctx)
+	if err != nil {
+		return err
+	}
+	instanceDir, err := instanceDirPath(ctx, gene)
+	if err != nil {
+		return err
+	}
+
+	// opened read-only, so it's safe to export while the node is running
+	path := filepath.Join(instanceDir, "logs.db")
+	logDB, err := logdb.NewReadOnly(path)
+	if err != nil {
+		return errors.Wrapf(err, "open log database [%v]", path)
+	}
+	defer logDB.Close()
+
+	from := uint32(ctx.Int(exportFromFlag.Name))
+	to := uint32(ctx.Int(exportToFlag.Name))
+	if !ctx.IsSet(exportToFlag.Name) {
+		newestID, err := logDB.NewestBlockID()
+		if err != nil {
+			return err
+		}
+		to = block.Number(newestID)
+	}
+	if from > to {
+		return fmt.Errorf("flag %s should not be greater than %s", exportFromFlag.Name, exportToFlag.Name)
+	}
+
+	exporter, err := logexport.New(
+		logDB,
+		ctx.String(exportDirFlag.Name),
+		logexport.Format(ctx.String(exportFormatFlag.Name)),
+		uint32(ctx.Int(exportPartitionSizeFlag.Name)))
+	if err != nil {
+		return err
+	}
+	return exporter.Export(exitSignal, from, to)
+}
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/beevik/ntp"
@@ -53,7 +54,7 @@ type Node struct {
 	comm           *comm.Communicator
 	targetGasLimit uint64
 	skipLogs       bool
-	logDBFailed    bool
+	logDBFailed    uint32 // accessed atomically
 	bandwidth      bandwidth.Bandwidth
 	maxBlockNum    uint32
 	processLock    sync.Mutex
@@ -86,6 +87,11 @@ func New(
 	}
 }
 
+// LogDBFailed returns whether writing logs has failed, after which logs are no longer written.
+func (n *Node) LogDBFailed() bool {
+	return atomic.LoadUint32(&n.logDBFailed) != 0
+}
+
 func (n *Node) Run(ctx context.Context) error {
 	logWorker := newWorker()
 	defer logWorker.Close()
@@ -303,7 +309,7 @@ func (n *Node) processBlock(newBlock *block.Block, stats *blockStats) (bool, err
 			startTime     = mclock.Now()
 			oldBest       = n.repo.BestBlockSummary()
 			becomeNewBest = newBlock.Header().BetterThan(oldBest.Header)
-			logEnabled    = becomeNewBest && !n.skipLogs && !n.logDBFailed
+			logEnabled    = becomeNewBest && !n.skipLogs && !n.LogDBFailed()
 		)
 
 		isTrunk = &becomeNewBest
@@ -338,7 +344,7 @@ func (n *Node) processBlock(newBlock *block.Block, stats *blockStats) (bool, err
 		if logEnabled {
 			if err := n.logWorker.Sync(); err != nil {
 				log.Warn("failed to write logs", "err", err)
-				n.logDBFailed = true
+				atomic.StoreUint32(&n.logDBFailed, 1)
 			}
 		}
 
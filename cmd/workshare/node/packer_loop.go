@@ -8,6 +8,7 @@ package node
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -118,7 +119,7 @@ func (n *Node) pack(flow *packer.Flow) error {
 	return n.guardBlockProcessing(flow.ParentHeader().Number()+1, func(conflicts uint32) error {
 		var (
 			startTime  = mclock.Now()
-			logEnabled = !n.skipLogs && !n.logDBFailed
+			logEnabled = !n.skipLogs && !n.LogDBFailed()
 			oldBest    = n.repo.BestBlockSummary()
 		)
 
@@ -164,7 +165,7 @@ func (n *Node) pack(flow *packer.Flow) error {
 		if logEnabled {
 			if err := n.logWorker.Sync(); err != nil {
 				log.Warn("failed to write logs", "err", err)
-				n.logDBFailed = true
+				atomic.StoreUint32(&n.logDBFailed, 1)
 			}
 		}
 
@@ -7,13 +7,28 @@ package node
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/mclock"
 	"github.com/miniBamboo/workshare/block"
+	"github.com/miniBamboo/workshare/metric"
 	"github.com/miniBamboo/workshare/workshare"
 )
 
+var (
+	metricBlocks          = metric.NewCounterVec("node_blocks_total", "Number of received blocks by result.", "result")
+	metricBlocksProcessed = metricBlocks.With("processed")
+	metricBlocksQueued    = metricBlocks.With("queued")
+	metricBlocksIgnored   = metricBlocks.With("ignored")
+	metricBlockTxs        = metric.NewCounter("node_block_txs_total", "Number of txs in processed blocks.")
+	metricBlockGasUsed    = metric.NewCounter("node_block_gas_used_total", "Gas used by processed blocks.")
+	metricBlockImport     = metric.NewHistogramVec("node_block_import_duration_seconds", "Latency of importing a block by stage.", nil, "stage")
+	metricBlockExec       = metricBlockImport.With("exec")
+	metricBlockCommit     = metricBlockImport.With("commit")
+	metricBlockTotal      = metricBlockImport.With("total")
+)
+
 type blockStats struct {
 	exec, commit, real         mclock.AbsTime
 	txs                        int
@@ -28,14 +43,25 @@ func (s *blockStats) UpdateProcessed(n int, txs int, exec, commit, real mclock.A
 	s.commit += commit
 	s.real += real
 	s.usedGas += usedGas
+
+	metricBlocksProcessed.Add(float64(n))
+	metricBlockTxs.Add(float64(txs))
+	metricBlockGasUsed.Add(float64(usedGas))
+	if n > 0 {
+		metricBlockExec.ObserveDuration(time.Duration(exec) / time.Duration(n))
+		metricBlockCommit.ObserveDuration(time.Duration(commit) / time.Duration(n))
+		metricBlockTotal.ObserveDuration(time.Duration(real) / time.Duration(n))
+	}
 }
 
 func (s *blockStats) UpdateIgnored(n int) {
 	s.ignored += n
+	metricBlocksIgnored.Add(float64(n))
 }
 
 func (s *blockStats) UpdateQueued(n int) {
 	s.queued += n
+	metricBlocksQueued.Add(float64(n))
 }
 
 func (s *blockStats) LogContext(last *block.Header) []interface{} {
@@ -16,6 +16,7 @@ import (
 	"github.com/miniBamboo/workshare/block"
 	"github.com/miniBamboo/workshare/chain"
 	"github.com/miniBamboo/workshare/co"
+	"github.com/miniBamboo/workshare/metric"
 	"github.com/miniBamboo/workshare/muxdb"
 	"github.com/miniBamboo/workshare/state"
 	"github.com/miniBamboo/workshare/trie"
@@ -23,7 +24,13 @@ import (
 	"github.com/pkg/errors"
 )
 
-var log = log15.New("pkg", "optimizer")
+var (
+	log = log15.New("pkg", "optimizer")
+
+	metricProgress = metric.NewGaugeVec("optimizer_block_number", "Block number the optimizer has processed to, by task.", "task")
+	metricOptimize = metricProgress.With("optimize")
+	metricPrune    = metricProgress.With("prune")
+)
 
 const (
 	propsStoreName = "optimizer.props"
@@ -82,6 +89,8 @@ func (p *Optimizer) loop(prune bool) error {
 	if err := status.Load(propsStore); err != nil {
 		return errors.Wrap(err, "load status")
 	}
+	metricOptimize.Set(float64(status.Base))
+	metricPrune.Set(float64(status.PruneBase))
 
 	for {
 		// select target
@@ -105,6 +114,7 @@ func (p *Optimizer) loop(prune bool) error {
 					return errors.Wrap(err, "prune tries")
 				}
 				status.PruneBase = pruneTarget
+				metricPrune.Set(float64(pruneTarget))
 			}
 		}
 
@@ -119,6 +129,7 @@ func (p *Optimizer) loop(prune bool) error {
 		if err := status.Save(propsStore); err != nil {
 			return errors.Wrap(err, "save status")
 		}
+		metricOptimize.Set(float64(target))
 	}
 }
 
@@ -15,3 +15,19 @@ type Communicator struct {
 func (comm Communicator) PeersStats() []*comm.PeerStats {
 	return nil
 }
+
+// PeerCount returns 0 since solo doesn't join p2p network
+func (comm Communicator) PeerCount() int {
+	return 0
+}
+
+var synced = func() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}()
+
+// Synced returns a closed channel since solo is always synced
+func (comm Communicator) Synced() <-chan struct{} {
+	return synced
+}
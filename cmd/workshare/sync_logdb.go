@@ -56,6 +56,8 @@ func syncLogDB(ctx context.Context, repo *chain.Repository, logDB *logdb.LogDB,
 	defer func() { pb.NotPrint = true }()
 
 	w := logDB.NewWriterSyncOff()
+	// drops logs left uncommitted when returning early, a no-op after the final commit
+	defer w.Rollback()
 
 	if err := w.Truncate(startPos); err != nil {
 		return err
@@ -155,9 +157,20 @@ func seekLogDBSyncPosition(repo *chain.Repository, logDB *logdb.LogDB) (uint32,
 }
 
 func verifyLogDB(ctx context.Context, endBlockNum uint32, repo *chain.Repository, logDB *logdb.LogDB) error {
+	// logs before are pruned
+	startBlockNum := uint32(1)
+	for _, table := range []logdb.Table{logdb.EventTable, logdb.TransferTable} {
+		if before := logDB.PrunedBefore(table); before > startBlockNum {
+			startBlockNum = before
+		}
+	}
+	if startBlockNum > endBlockNum {
+		return nil
+	}
+
 	fmt.Println(">> Verifying log db <<")
 	pb := pb.New64(int64(endBlockNum)).
-		Set64(0).
+		Set64(int64(startBlockNum - 1)).
 		SetMaxWidth(90).
 		Start()
 	defer func() { pb.NotPrint = true }()
@@ -168,7 +181,7 @@ func verifyLogDB(ctx context.Context, endBlockNum uint32, repo *chain.Repository
 		best        = repo.BestBlockSummary()
 		evLogs      []*logdb.Event
 		trLogs      []*logdb.Transfer
-		logLimit    = uint32(0)
+		logLimit    = startBlockNum - 1
 		splitEvLogs = func(id workshare.Bytes32) (logs []*logdb.Event) {
 			if len(evLogs) == 0 {
 				return
@@ -216,7 +229,7 @@ func verifyLogDB(ctx context.Context, endBlockNum uint32, repo *chain.Repository
 	defer goes.Wait()
 	goes.Go(func() {
 		defer close(ch)
-		pumpErr = pumpBlockAndReceipts(ctx, repo, best.Header.ID(), 1, endBlockNum, ch)
+		pumpErr = pumpBlockAndReceipts(ctx, repo, best.Header.ID(), startBlockNum, endBlockNum, ch)
 	})
 
 	defer cancel()
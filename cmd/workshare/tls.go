@@ -0,0 +1,162 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	cli "gopkg.in/urfave/cli.v1"
+)
+
+// interval to check changes of certificate files.
+const certCheckInterval = 5 * time.Second
+
+// certReloader serves the certificate loaded from files, and reloads it on change.
+type certReloader struct {
+	certFile, keyFile string
+
+	lock      sync.Mutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	lastCheck time.Time
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// reload reloads the certificate if files are modified.
+func (r *certReloader) reload() error {
+	var modTime time.Time
+	for _, file := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+	}
+	if r.cert != nil && modTime.Equal(r.modTime) {
+		return nil
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.cert = &cert
+	r.modTime = modTime
+	return nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate.
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if now := time.Now(); now.Sub(r.lastCheck) > certCheckInterval {
+		r.lastCheck = now
+		if err := r.reload(); err != nil {
+			log.Warn("failed to reload API certificate, keep the old one", "err", err)
+		}
+	}
+	return r.cert, nil
+}
+
+// newSelfSignedCert generates a self-signed certificate for localhost.
+func newSelfSignedCert() (*tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"workshare dev"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
+
+// newAPITLSConfig creates TLS config for the API server. Nil is returned if TLS is disabled.
+func newAPITLSConfig(ctx *cli.Context) (*tls.Config, error) {
+	var (
+		certFile   = ctx.String(apiTLSCertFlag.Name)
+		keyFile    = ctx.String(apiTLSKeyFlag.Name)
+		clientCA   = ctx.String(apiTLSClientCAFlag.Name)
+		selfSigned = ctx.Bool(apiTLSSelfSignedFlag.Name)
+	)
+
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	switch {
+	case selfSigned:
+		if certFile != "" || keyFile != "" {
+			return nil, errors.New("self-signed API certificate conflicts with certificate files")
+		}
+		cert, err := newSelfSignedCert()
+		if err != nil {
+			return nil, errors.Wrap(err, "generate self-signed API certificate")
+		}
+		config.Certificates = []tls.Certificate{*cert}
+	case certFile != "" && keyFile != "":
+		reloader, err := newCertReloader(certFile, keyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "load API certificate")
+		}
+		config.GetCertificate = reloader.GetCertificate
+	case certFile != "" || keyFile != "":
+		return nil, errors.New("both API certificate and key files are required")
+	default:
+		if clientCA != "" {
+			return nil, errors.New("client CA requires API TLS enabled")
+		}
+		return nil, nil
+	}
+
+	if clientCA != "" {
+		data, err := ioutil.ReadFile(clientCA)
+		if err != nil {
+			return nil, errors.Wrap(err, "read client CA")
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, errors.New("no valid certificate in client CA")
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, nil
+}
@@ -0,0 +1,307 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"flag"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	cli "gopkg.in/urfave/cli.v1"
+)
+
+// testCert is a certificate with its key, written into PEM files.
+type testCert struct {
+	cert              *x509.Certificate
+	key               *ecdsa.PrivateKey
+	certFile, keyFile string
+}
+
+// newTestCert creates a certificate in dir, signed by parent, or self-signed if parent is nil.
+func newTestCert(t *testing.T, dir, name string, isCA bool, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	if isCA {
+		template.KeyUsage |= x509.KeyUsageCertSign
+	}
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &testCert{
+		cert:     cert,
+		key:      key,
+		certFile: filepath.Join(dir, name+".crt"),
+		keyFile:  filepath.Join(dir, name+".key"),
+	}
+	c.write(t, c.certFile, c.keyFile)
+	return c
+}
+
+// write writes the certificate and key into the given files.
+func (c *testCert) write(t *testing.T, certFile, keyFile string) {
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// touch sets the modification time of files to be later than any before.
+func touch(t *testing.T, mtime time.Time, files ...string) {
+	for _, file := range files {
+		if err := os.Chtimes(file, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	cert1 := newTestCert(t, dir, "server", false, nil)
+	cert2 := newTestCert(t, dir, "server2", false, nil)
+
+	r, err := newCertReloader(cert1.certFile, cert1.keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serving := func() []byte {
+		c, err := r.GetCertificate(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c.Certificate[0]
+	}
+	assert.Equal(t, cert1.cert.Raw, serving())
+
+	// files replaced, but not checked within the interval
+	cert2.write(t, cert1.certFile, cert1.keyFile)
+	touch(t, time.Now().Add(time.Minute), cert1.certFile, cert1.keyFile)
+	assert.Equal(t, cert1.cert.Raw, serving())
+
+	// reloaded once the interval passed
+	r.lastCheck = time.Now().Add(-certCheckInterval - time.Second)
+	assert.Equal(t, cert2.cert.Raw, serving())
+
+	// broken files are ignored, and the loaded one is kept
+	if err := ioutil.WriteFile(cert1.keyFile, []byte("broken"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	touch(t, time.Now().Add(2*time.Minute), cert1.certFile, cert1.keyFile)
+	r.lastCheck = time.Time{}
+	assert.Equal(t, cert2.cert.Raw, serving())
+
+	// missing files are ignored too
+	if err := os.Remove(cert1.certFile); err != nil {
+		t.Fatal(err)
+	}
+	r.lastCheck = time.Time{}
+	assert.Equal(t, cert2.cert.Raw, serving())
+
+	_, err = newCertReloader(cert1.certFile, cert1.keyFile)
+	assert.Error(t, err)
+}
+
+// newTLSContext creates the context with the given API TLS flags set.
+func newTLSContext(t *testing.T, values map[string]string) *cli.Context {
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	for _, f := range []cli.Flag{apiTLSCertFlag, apiTLSKeyFlag, apiTLSClientCAFlag, apiTLSSelfSignedFlag} {
+		f.Apply(set)
+	}
+	for name, value := range values {
+		if err := set.Set(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return cli.NewContext(nil, set, nil)
+}
+
+func TestNewAPITLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	server := newTestCert(t, dir, "server", false, nil)
+	ca := newTestCert(t, dir, "ca", true, nil)
+	badCA := filepath.Join(dir, "bad-ca.crt")
+	if err := ioutil.WriteFile(badCA, []byte("not a cert"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		cert       = apiTLSCertFlag.Name
+		key        = apiTLSKeyFlag.Name
+		clientCA   = apiTLSClientCAFlag.Name
+		selfSigned = apiTLSSelfSignedFlag.Name
+	)
+	tests := []struct {
+		name    string
+		values  map[string]string
+		wantErr string
+	}{
+		{"cert without key", map[string]string{cert: server.certFile}, "both API certificate and key files are required"},
+		{"key without cert", map[string]string{key: server.keyFile}, "both API certificate and key files are required"},
+		{"client CA without TLS", map[string]string{clientCA: ca.certFile}, "client CA requires API TLS enabled"},
+		{"self-signed with files", map[string]string{selfSigned: "true", cert: server.certFile, key: server.keyFile}, "self-signed API certificate conflicts with certificate files"},
+		{"missing cert file", map[string]string{cert: filepath.Join(dir, "none"), key: server.keyFile}, "load API certificate"},
+		{"missing client CA", map[string]string{cert: server.certFile, key: server.keyFile, clientCA: filepath.Join(dir, "none")}, "read client CA"},
+		{"invalid client CA", map[string]string{cert: server.certFile, key: server.keyFile, clientCA: badCA}, "no valid certificate in client CA"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newAPITLSConfig(newTLSContext(t, tt.values))
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		config, err := newAPITLSConfig(newTLSContext(t, nil))
+		assert.Nil(t, err)
+		assert.Nil(t, config)
+	})
+
+	t.Run("files", func(t *testing.T) {
+		config, err := newAPITLSConfig(newTLSContext(t, map[string]string{cert: server.certFile, key: server.keyFile}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, tls.NoClientCert, config.ClientAuth)
+
+		pool := x509.NewCertPool()
+		pool.AddCert(server.cert)
+		assert.Nil(t, handshake(t, config, &tls.Config{RootCAs: pool}))
+	})
+
+	t.Run("self-signed", func(t *testing.T) {
+		config, err := newAPITLSConfig(newTLSContext(t, map[string]string{selfSigned: "true"}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if assert.Len(t, config.Certificates, 1) {
+			leaf, err := x509.ParseCertificate(config.Certificates[0].Certificate[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			pool := x509.NewCertPool()
+			pool.AddCert(leaf)
+			assert.Nil(t, handshake(t, config, &tls.Config{RootCAs: pool, ServerName: "localhost"}))
+		}
+	})
+
+	t.Run("client CA", func(t *testing.T) {
+		config, err := newAPITLSConfig(newTLSContext(t, map[string]string{cert: server.certFile, key: server.keyFile, clientCA: ca.certFile}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, tls.RequireAndVerifyClientCert, config.ClientAuth)
+
+		pool := x509.NewCertPool()
+		pool.AddCert(server.cert)
+		clientCert := func(c *testCert) []tls.Certificate {
+			return []tls.Certificate{{Certificate: [][]byte{c.cert.Raw}, PrivateKey: c.key}}
+		}
+
+		// the client signed by the CA is accepted
+		client := newTestCert(t, dir, "client", false, ca)
+		assert.Nil(t, handshake(t, config, &tls.Config{RootCAs: pool, Certificates: clientCert(client)}))
+
+		// no client cert, or one not signed by the CA is rejected
+		assert.Error(t, handshake(t, config, &tls.Config{RootCAs: pool}))
+		stranger := newTestCert(t, dir, "stranger", false, nil)
+		assert.Error(t, handshake(t, config, &tls.Config{RootCAs: pool, Certificates: clientCert(stranger)}))
+	})
+}
+
+// handshake completes a TLS handshake between server and client of the given configs, and
+// returns the error seen by the server.
+func handshake(t *testing.T, server, client *tls.Config) error {
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	errCh := make(chan error, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			errCh <- err
+			return
+		}
+		defer conn.Close()
+		errCh <- conn.(*tls.Conn).Handshake()
+	}()
+
+	if client.ServerName == "" {
+		client.ServerName = "localhost"
+	}
+	conn, err := tls.Dial("tcp", listener.Addr().String(), client)
+	if err == nil {
+		// the server verifies the client cert after the client finishes its handshake
+		conn.Read(make([]byte, 1))
+		conn.Close()
+	}
+	return <-errCh
+}
+
+func TestSelfSignedSoloOnly(t *testing.T) {
+	hasFlag := func(flags []cli.Flag) bool {
+		for _, f := range flags {
+			if f.GetName() == apiTLSSelfSignedFlag.Name {
+				return true
+			}
+		}
+		return false
+	}
+	app := newApp()
+	assert.False(t, hasFlag(app.Flags), "default command")
+	for _, cmd := range app.Commands {
+		assert.Equal(t, cmd.Name == "solo", hasFlag(cmd.Flags), cmd.Name)
+	}
+}
@@ -8,6 +8,7 @@ package main
 import (
 	"context"
 	"crypto/ecdsa"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -33,8 +34,12 @@ import (
 	"github.com/ethereum/go-ethereum/p2p/discover"
 	"github.com/ethereum/go-ethereum/p2p/nat"
 	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/gorilla/mux"
 	"github.com/inconshreveable/log15"
 	tty "github.com/mattn/go-tty"
+	"github.com/miniBamboo/workshare/api/abis"
+	"github.com/miniBamboo/workshare/api/admin"
+	"github.com/miniBamboo/workshare/api/auth"
 	"github.com/miniBamboo/workshare/api/doc"
 	"github.com/miniBamboo/workshare/chain"
 	"github.com/miniBamboo/workshare/cmd/workshare/node"
@@ -42,6 +47,7 @@ import (
 	"github.com/miniBamboo/workshare/comm"
 	"github.com/miniBamboo/workshare/genesis"
 	"github.com/miniBamboo/workshare/logdb"
+	"github.com/miniBamboo/workshare/metric"
 	"github.com/miniBamboo/workshare/muxdb"
 	"github.com/miniBamboo/workshare/p2psrv"
 	"github.com/miniBamboo/workshare/state"
@@ -241,6 +247,18 @@ func makeConfigDir(ctx *cli.Context) (string, error) {
 }
 
 func makeInstanceDir(ctx *cli.Context, gene *genesis.Genesis) (string, error) {
+	instanceDir, err := instanceDirPath(ctx, gene)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(instanceDir, 0700); err != nil {
+		return "", errors.Wrapf(err, "create instance dir [%v]", instanceDir)
+	}
+	return instanceDir, nil
+}
+
+// instanceDirPath returns the path of the instance dir, without creating it.
+func instanceDirPath(ctx *cli.Context, gene *genesis.Genesis) (string, error) {
 	dataDir := ctx.String(dataDirFlag.Name)
 	if dataDir == "" {
 		return "", fmt.Errorf("unable to infer default data dir, use -%s to specify", dataDirFlag.Name)
@@ -250,12 +268,7 @@ func makeInstanceDir(ctx *cli.Context, gene *genesis.Genesis) (string, error) {
 	if ctx.Bool(disablePrunerFlag.Name) {
 		suffix = "-full"
 	}
-
-	instanceDir := filepath.Join(dataDir, fmt.Sprintf("instance-%x-v3", gene.ID().Bytes()[24:])+suffix)
-	if err := os.MkdirAll(instanceDir, 0700); err != nil {
-		return "", errors.Wrapf(err, "create instance dir [%v]", instanceDir)
-	}
-	return instanceDir, nil
+	return filepath.Join(dataDir, fmt.Sprintf("instance-%x-v3", gene.ID().Bytes()[24:])+suffix), nil
 }
 
 func openMainDB(ctx *cli.Context, dir string) (*muxdb.MuxDB, error) {
@@ -505,12 +518,34 @@ func (p *p2pComm) Stop() {
 	}
 }
 
+// loadAPIAuth loads API keys if specified. Nil is returned if API authentication is disabled.
+func loadAPIAuth(ctx *cli.Context) (*auth.Auth, error) {
+	path := ctx.String(apiKeysFlag.Name)
+	if path == "" {
+		return nil, nil
+	}
+	a, err := auth.New(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "load API keys")
+	}
+	return a, nil
+}
+
 func startAPIServer(ctx *cli.Context, handler http.Handler, genesisID workshare.Bytes32) (string, func(), error) {
+	tlsConfig, err := newAPITLSConfig(ctx)
+	if err != nil {
+		return "", nil, err
+	}
 	addr := ctx.String(apiAddrFlag.Name)
 	listener, err := net.Listen("tcp", addr)
 	if err != nil {
 		return "", nil, errors.Wrapf(err, "listen API addr [%v]", addr)
 	}
+	scheme := "http"
+	if tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
+		scheme = "https"
+	}
 	timeout := ctx.Int(apiTimeoutFlag.Name)
 	if timeout > 0 {
 		handler = handleAPITimeout(handler, time.Duration(timeout)*time.Millisecond)
@@ -523,7 +558,56 @@ func startAPIServer(ctx *cli.Context, handler http.Handler, genesisID workshare.
 	goes.Go(func() {
 		srv.Serve(listener)
 	})
-	return "http://" + listener.Addr().String() + "/", func() {
+	return scheme + "://" + listener.Addr().String() + "/", func() {
+		srv.Close()
+		goes.Wait()
+	}, nil
+}
+
+// startMetricsServer serves metrics on the separate address if specified.
+func startMetricsServer(ctx *cli.Context) (func(), error) {
+	addr := ctx.String(metricsAddrFlag.Name)
+	if addr == "" {
+		return func() {}, nil
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listen metrics addr [%v]", addr)
+	}
+	serveMux := http.NewServeMux()
+	serveMux.Handle("/metrics", metric.Handler())
+	srv := &http.Server{Handler: serveMux}
+	var goes co.Goes
+	goes.Go(func() {
+		srv.Serve(listener)
+	})
+	log.Info("metrics server started", "url", "http://"+listener.Addr().String()+"/metrics")
+	return func() {
+		srv.Close()
+		goes.Wait()
+	}, nil
+}
+
+// startAdminServer serves admin API on the separate address if specified.
+func startAdminServer(ctx *cli.Context, p2pSrv *p2psrv.Server, abiRegistry *abis.Registry) (func(), error) {
+	addr := ctx.String(adminAddrFlag.Name)
+	if addr == "" {
+		return func() {}, nil
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listen admin addr [%v]", addr)
+	}
+	router := mux.NewRouter()
+	admin.New(p2pSrv).Mount(router, "/admin")
+	abis.New(abiRegistry).Mount(router, "/admin/abis")
+	srv := &http.Server{Handler: requestBodyLimit(router)}
+	var goes co.Goes
+	goes.Go(func() {
+		srv.Serve(listener)
+	})
+	log.Info("admin server started", "url", "http://"+listener.Addr().String()+"/admin")
+	return func() {
 		srv.Close()
 		goes.Wait()
 	}, nil
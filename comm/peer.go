@@ -57,7 +57,7 @@ func newPeer(peer *p2p.Peer, rw p2p.MsgReadWriter) *Peer {
 	knownBlocks, _ := lru.New(maxKnownBlocks)
 	return &Peer{
 		Peer:        peer,
-		RPC:         rpc.New(peer, rw),
+		RPC:         rpc.New(peer, &meteredMsgReadWriter{rw}),
 		logger:      log.New(ctx...),
 		createdTime: mclock.Now(),
 		knownTxs:    knownTxs,
@@ -156,6 +156,7 @@ func (ps *PeerSet) Add(peer *Peer) {
 	ps.lock.Lock()
 	defer ps.lock.Unlock()
 	ps.m[peer.ID()] = peer
+	metricPeers.Set(float64(len(ps.m)))
 }
 
 // Find find peer for given nodeID.
@@ -171,6 +172,7 @@ func (ps *PeerSet) Remove(nodeID discover.NodeID) *Peer {
 	defer ps.lock.Unlock()
 	if peer, ok := ps.m[nodeID]; ok {
 		delete(ps.m, nodeID)
+		metricPeers.Set(float64(len(ps.m)))
 		return peer
 	}
 	return nil
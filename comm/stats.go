@@ -6,9 +6,18 @@
 package comm
 
 import (
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/miniBamboo/workshare/comm/proto"
+	"github.com/miniBamboo/workshare/metric"
 	"github.com/miniBamboo/workshare/workshare"
 )
 
+var (
+	metricPeers    = metric.NewGauge("comm_peers", "Number of connected peers.")
+	metricMessages = metric.NewCounterVec("comm_messages_total", "Number of p2p messages by message and direction.", "msg", "dir")
+	metricBytes    = metric.NewCounterVec("comm_message_bytes_total", "Size of p2p message payloads by message and direction.", "msg", "dir")
+)
+
 // type Traffic struct {
 // 	Bytes    uint64
 // 	Requests uint64
@@ -25,3 +34,30 @@ type PeerStats struct {
 	Inbound     bool
 	Duration    uint64 // in seconds
 }
+
+// meteredMsgReadWriter counts traffic of messages.
+type meteredMsgReadWriter struct {
+	p2p.MsgReadWriter
+}
+
+func (rw *meteredMsgReadWriter) ReadMsg() (p2p.Msg, error) {
+	msg, err := rw.MsgReadWriter.ReadMsg()
+	if err == nil {
+		meterMsg(&msg, "in")
+	}
+	return msg, err
+}
+
+func (rw *meteredMsgReadWriter) WriteMsg(msg p2p.Msg) error {
+	if err := rw.MsgReadWriter.WriteMsg(msg); err != nil {
+		return err
+	}
+	meterMsg(&msg, "out")
+	return nil
+}
+
+func meterMsg(msg *p2p.Msg, dir string) {
+	name := proto.MsgName(msg.Code)
+	metricMessages.With(name, dir).Inc()
+	metricBytes.With(name, dir).Add(float64(msg.Size))
+}
@@ -8,12 +8,16 @@ package logdb
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"math"
 	"math/big"
+	"sync"
+	"time"
 
 	sqlite3 "github.com/mattn/go-sqlite3"
 	"github.com/miniBamboo/workshare/block"
+	"github.com/miniBamboo/workshare/metric"
 	"github.com/miniBamboo/workshare/tx"
 	"github.com/miniBamboo/workshare/workshare"
 )
@@ -22,6 +26,12 @@ const (
 	refIDQuery = "(SELECT id FROM ref WHERE data=?)"
 )
 
+var (
+	metricWriteDuration = metric.NewHistogramVec("logdb_write_duration_seconds", "Latency of writing logs by operation.", nil, "op")
+	metricWrite         = metricWriteDuration.With("write")
+	metricCommit        = metricWriteDuration.With("commit")
+)
+
 type LogDB struct {
 	path          string
 	driverVersion string
@@ -29,6 +39,10 @@ type LogDB struct {
 	wconn         *sql.Conn
 	wconnSyncOff  *sql.Conn
 	stmtCache     *stmtCache
+	wlock         chan struct{} // held during a write transaction
+
+	prunedLock sync.RWMutex
+	pruned     map[Table]uint32
 }
 
 // New create or open log db at given path.
@@ -43,10 +57,22 @@ func New(path string) (logDB *LogDB, err error) {
 		}
 	}()
 
-	if _, err := db.Exec(refTableScheme + eventTableSchema + transferTableSchema); err != nil {
+	hasTokenTransfer, err := hasTable(db, "tokenTransfer")
+	if err != nil {
+		return nil, err
+	}
+
+	// indexes missing in dbs created by earlier versions are also built here, which may take a while
+	if _, err := db.Exec(refTableScheme + eventTableSchema + transferTableSchema + tokenTransferTableSchema + prunedTableSchema); err != nil {
 		return nil, err
 	}
 
+	if !hasTokenTransfer {
+		if err := migrateTokenTransfers(db); err != nil {
+			return nil, err
+		}
+	}
+
 	wconn1, err := db.Conn(context.Background())
 	if err != nil {
 		return nil, err
@@ -62,14 +88,81 @@ func New(path string) (logDB *LogDB, err error) {
 	}
 
 	driverVer, _, _ := sqlite3.Version()
-	return &LogDB{
+	logDB = &LogDB{
 		path:          path,
 		driverVersion: driverVer,
 		db:            db,
 		wconn:         wconn1,
 		wconnSyncOff:  wconn2,
 		stmtCache:     newStmtCache(db),
-	}, nil
+		wlock:         make(chan struct{}, 1),
+		pruned:        make(map[Table]uint32),
+	}
+	if err := logDB.loadPruned(); err != nil {
+		return nil, err
+	}
+	return logDB, nil
+}
+
+// NewReadOnly opens the existing log db at given path in read-only mode, which is safe
+// to read while the db is being written by another process. Writers are not available.
+func NewReadOnly(path string) (logDB *LogDB, err error) {
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = db.Close()
+		}
+	}()
+
+	hasEvent, err := hasTable(db, "event")
+	if err != nil {
+		return nil, err
+	}
+	if !hasEvent {
+		return nil, errors.New("not a log db")
+	}
+	hasPruned, err := hasTable(db, "pruned")
+	if err != nil {
+		return nil, err
+	}
+
+	driverVer, _, _ := sqlite3.Version()
+	logDB = &LogDB{
+		path:          path,
+		driverVersion: driverVer,
+		db:            db,
+		stmtCache:     newStmtCache(db),
+		pruned:        make(map[Table]uint32),
+	}
+	if hasPruned {
+		if err := logDB.loadPruned(); err != nil {
+			return nil, err
+		}
+	}
+	return logDB, nil
+}
+
+func hasTable(db *sql.DB, name string) (has bool, err error) {
+	err = db.QueryRow("SELECT COUNT(*) > 0 FROM sqlite_master WHERE type='table' AND name=?", name).Scan(&has)
+	return
+}
+
+// migrateTokenTransfers fills the newly created token transfers table from recorded events.
+func migrateTokenTransfers(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	for _, query := range []string{tokenTransferRefMigration, tokenTransferMigration} {
+		if _, err := tx.Exec(query, TokenTransferEventID[:]); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
 }
 
 // NewMem create a log db in ram.
@@ -79,9 +172,11 @@ func NewMem() (*LogDB, error) {
 
 // Close close the log db.
 func (db *LogDB) Close() (err error) {
-	err = db.wconn.Close()
-	if err1 := db.wconnSyncOff.Close(); err == nil {
-		err = err1
+	if db.wconn != nil {
+		err = db.wconn.Close()
+		if err1 := db.wconnSyncOff.Close(); err == nil {
+			err = err1
+		}
 	}
 	db.stmtCache.Clear()
 	if err1 := db.db.Close(); err == nil {
@@ -109,50 +204,20 @@ FROM (%v) e
 	LEFT JOIN ref r8 ON e.topic4 = r8.id`
 
 	if filter == nil {
-		return db.queryEvents(ctx, fmt.Sprintf(query, "event"))
-	}
-
-	var (
-		subQuery = "SELECT seq FROM event WHERE 1"
-		args     []interface{}
-	)
-
-	if filter.Range != nil {
-		subQuery += " AND seq >= ?"
-		args = append(args, newSequence(filter.Range.From, 0))
-		if filter.Range.To >= filter.Range.From {
-			subQuery += " AND seq <= ?"
-			args = append(args, newSequence(filter.Range.To, uint32(math.MaxInt32)))
-		}
-	}
-
-	if len(filter.CriteriaSet) > 0 {
-		subQuery += " AND ("
-
-		for i, c := range filter.CriteriaSet {
-			cond, cargs := c.toWhereCondition()
-			if i > 0 {
-				subQuery += " OR"
-			}
-			subQuery += " (" + cond + ")"
-			args = append(args, cargs...)
+		if err := db.checkPruned(EventTable, nil); err != nil {
+			return nil, err
 		}
-		subQuery += ")"
+		return db.queryEvents(ctx, fmt.Sprintf(query, "event"))
 	}
 
-	if filter.Order == DESC {
-		subQuery += " ORDER BY seq DESC "
-	} else {
-		subQuery += " ORDER BY seq ASC "
+	criteria := make([]criterion, 0, len(filter.CriteriaSet))
+	for _, c := range filter.CriteriaSet {
+		criteria = append(criteria, c)
 	}
-
-	if filter.Options != nil {
-		subQuery += " LIMIT ?, ?"
-		args = append(args, filter.Options.Offset, filter.Options.Limit)
+	subQuery, args, err := db.filterQuery(EventTable, filter.Range, filter.Options, filter.Order, criteria)
+	if err != nil {
+		return nil, err
 	}
-
-	subQuery = "SELECT e.* FROM (" + subQuery + ") s LEFT JOIN event e ON s.seq = e.seq"
-
 	return db.queryEvents(ctx, fmt.Sprintf(query, subQuery), args...)
 }
 
@@ -167,26 +232,90 @@ FROM (%v) t
 	LEFT JOIN ref r4 ON t.recipient = r4.id`
 
 	if filter == nil {
+		if err := db.checkPruned(TransferTable, nil); err != nil {
+			return nil, err
+		}
 		return db.queryTransfers(ctx, fmt.Sprintf(query, "transfer"))
 	}
 
+	criteria := make([]criterion, 0, len(filter.CriteriaSet))
+	for _, c := range filter.CriteriaSet {
+		criteria = append(criteria, c)
+	}
+	subQuery, args, err := db.filterQuery(TransferTable, filter.Range, filter.Options, filter.Order, criteria)
+	if err != nil {
+		return nil, err
+	}
+	return db.queryTransfers(ctx, fmt.Sprintf(query, subQuery), args...)
+}
+
+func (db *LogDB) FilterTokenTransfers(ctx context.Context, filter *TokenTransferFilter) ([]*TokenTransfer, error) {
+
+	const query = `SELECT t.seq, r0.data, t.blockTime, r1.data, r2.data, t.clauseIndex, r3.data, r4.data, r5.data, t.amount
+FROM (%v) t
+	LEFT JOIN ref r0 ON t.blockID = r0.id
+	LEFT JOIN ref r1 ON t.txID = r1.id
+	LEFT JOIN ref r2 ON t.txOrigin = r2.id
+	LEFT JOIN ref r3 ON t.token = r3.id
+	LEFT JOIN ref r4 ON t.sender = r4.id
+	LEFT JOIN ref r5 ON t.recipient = r5.id`
+
+	if filter == nil {
+		if err := db.checkPruned(TokenTransferTable, nil); err != nil {
+			return nil, err
+		}
+		return db.queryTokenTransfers(ctx, fmt.Sprintf(query, "tokenTransfer"))
+	}
+
+	criteria := make([]criterion, 0, len(filter.CriteriaSet))
+	for _, c := range filter.CriteriaSet {
+		criteria = append(criteria, c)
+	}
+	subQuery, args, err := db.filterQuery(TokenTransferTable, filter.Range, filter.Options, filter.Order, criteria)
+	if err != nil {
+		return nil, err
+	}
+	return db.queryTokenTransfers(ctx, fmt.Sprintf(query, subQuery), args...)
+}
+
+// criterion is a criteria of log filters, which is converted into the WHERE condition.
+type criterion interface {
+	toWhereCondition() (cond string, args []interface{})
+}
+
+// filterQuery builds the query of the logs in the table, which are in the range, after the cursor and
+// match any of the criteria, sorted and paged as the options specify.
+func (db *LogDB) filterQuery(table Table, rng *Range, options *Options, order Order, criteria []criterion) (string, []interface{}, error) {
+	if err := db.checkPruned(table, rng); err != nil {
+		return "", nil, err
+	}
+
 	var (
-		subQuery = "SELECT seq FROM transfer WHERE 1"
+		subQuery = "SELECT seq FROM " + string(table) + " WHERE 1"
 		args     []interface{}
 	)
 
-	if filter.Range != nil {
+	if rng != nil {
 		subQuery += " AND seq >= ?"
-		args = append(args, newSequence(filter.Range.From, 0))
-		if filter.Range.To >= filter.Range.From {
+		args = append(args, newSequence(rng.From, 0))
+		if rng.To >= rng.From {
 			subQuery += " AND seq <= ?"
-			args = append(args, newSequence(filter.Range.To, uint32(math.MaxInt32)))
+			args = append(args, newSequence(rng.To, uint32(math.MaxInt32)))
+		}
+	}
+
+	if options != nil && options.Cursor != nil {
+		if order == DESC {
+			subQuery += " AND seq < ?"
+		} else {
+			subQuery += " AND seq > ?"
 		}
+		args = append(args, options.Cursor.seq)
 	}
 
-	if len(filter.CriteriaSet) > 0 {
+	if len(criteria) > 0 {
 		subQuery += " AND ("
-		for i, c := range filter.CriteriaSet {
+		for i, c := range criteria {
 			cond, cargs := c.toWhereCondition()
 			if i > 0 {
 				subQuery += " OR"
@@ -197,19 +326,18 @@ FROM (%v) t
 		subQuery += ")"
 	}
 
-	if filter.Order == DESC {
+	if order == DESC {
 		subQuery += " ORDER BY seq DESC"
 	} else {
 		subQuery += " ORDER BY seq ASC"
 	}
 
-	if filter.Options != nil {
+	if options != nil {
 		subQuery += " LIMIT ?, ?"
-		args = append(args, filter.Options.Offset, filter.Options.Limit)
+		args = append(args, options.Offset, options.Limit)
 	}
 
-	subQuery = "SELECT e.* FROM (" + subQuery + ") s LEFT JOIN transfer e ON s.seq = e.seq"
-	return db.queryTransfers(ctx, fmt.Sprintf(query, subQuery), args...)
+	return "SELECT e.* FROM (" + subQuery + ") s LEFT JOIN " + string(table) + " e ON s.seq = e.seq", args, nil
 }
 
 func (db *LogDB) queryEvents(ctx context.Context, query string, args ...interface{}) ([]*Event, error) {
@@ -336,6 +464,65 @@ func (db *LogDB) queryTransfers(ctx context.Context, query string, args ...inter
 	return transfers, nil
 }
 
+func (db *LogDB) queryTokenTransfers(ctx context.Context, query string, args ...interface{}) ([]*TokenTransfer, error) {
+	rows, err := db.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	var transfers []*TokenTransfer
+	for rows.Next() {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+		var (
+			seq         sequence
+			blockID     []byte
+			blockTime   uint64
+			txID        []byte
+			txOrigin    []byte
+			clauseIndex uint32
+			token       []byte
+			sender      []byte
+			recipient   []byte
+			amount      []byte
+		)
+		if err := rows.Scan(
+			&seq,
+			&blockID,
+			&blockTime,
+			&txID,
+			&txOrigin,
+			&clauseIndex,
+			&token,
+			&sender,
+			&recipient,
+			&amount,
+		); err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, &TokenTransfer{
+			BlockNumber: seq.BlockNumber(),
+			Index:       seq.Index(),
+			BlockID:     workshare.BytesToBytes32(blockID),
+			BlockTime:   blockTime,
+			TxID:        workshare.BytesToBytes32(txID),
+			TxOrigin:    workshare.BytesToAddress(txOrigin),
+			ClauseIndex: clauseIndex,
+			Token:       workshare.BytesToAddress(token),
+			Sender:      workshare.BytesToAddress(sender),
+			Recipient:   workshare.BytesToAddress(recipient),
+			Amount:      new(big.Int).SetBytes(amount),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return transfers, nil
+}
+
 // NewestBlockID query newest written block id.
 func (db *LogDB) NewestBlockID() (workshare.Bytes32, error) {
 	var data []byte
@@ -372,12 +559,12 @@ func (db *LogDB) HasBlockID(id workshare.Bytes32) (bool, error) {
 
 // NewWriter creates a log writer.
 func (db *LogDB) NewWriter() *Writer {
-	return &Writer{conn: db.wconn, stmtCache: db.stmtCache}
+	return &Writer{conn: db.wconn, stmtCache: db.stmtCache, lock: db.wlock}
 }
 
 // NewWriterSyncOff creates a log writer which applied 'pragma synchronous = off'.
 func (db *LogDB) NewWriterSyncOff() *Writer {
-	return &Writer{conn: db.wconnSyncOff, stmtCache: db.stmtCache}
+	return &Writer{conn: db.wconnSyncOff, stmtCache: db.stmtCache, lock: db.wlock}
 }
 
 func topicValue(topics []workshare.Bytes32, i int) []byte {
@@ -391,12 +578,14 @@ func topicValue(topics []workshare.Bytes32, i int) []byte {
 type Writer struct {
 	conn      *sql.Conn
 	stmtCache *stmtCache
+	lock      chan struct{} // shared with the pruner, held from the first write until commit or rollback
 
 	tx               *sql.Tx
 	uncommittedCount int
 }
 
 // Truncate truncates the database by deleting logs after blockNum (included).
+// On failure, all uncommitted logs are rolled back.
 func (w *Writer) Truncate(blockNum uint32) error {
 	seq := newSequence(blockNum, 0)
 	if err := w.exec("DELETE FROM event WHERE seq >= ?", seq); err != nil {
@@ -405,11 +594,17 @@ func (w *Writer) Truncate(blockNum uint32) error {
 	if err := w.exec("DELETE FROM transfer WHERE seq >= ?", seq); err != nil {
 		return err
 	}
+	if err := w.exec("DELETE FROM tokenTransfer WHERE seq >= ?", seq); err != nil {
+		return err
+	}
 	return nil
 }
 
 // Write writes all logs of the given block.
+// On failure, all uncommitted logs are rolled back.
 func (w *Writer) Write(b *block.Block, receipts tx.Receipts) error {
+	defer func(start time.Time) { metricWrite.ObserveDuration(time.Since(start)) }(time.Now())
+
 	var (
 		blockID        = b.Header().ID()
 		blockNum       = b.Header().Number()
@@ -504,6 +699,11 @@ func (w *Writer) Write(b *block.Block, receipts tx.Receipts) error {
 					topicValue(ev.Topics, 4)); err != nil {
 					return err
 				}
+				if sender, recipient, _, ok := DecodeTokenTransfer(ev); ok {
+					if err := w.writeTokenTransfer(newSequence(blockNum, eventCount), sender, recipient); err != nil {
+						return err
+					}
+				}
 				eventCount++
 			}
 
@@ -542,18 +742,35 @@ func (w *Writer) Write(b *block.Block, receipts tx.Receipts) error {
 	return nil
 }
 
+// writeTokenTransfer writes the token transfer decoded from the event, which is just written with the same seq.
+func (w *Writer) writeTokenTransfer(seq sequence, sender, recipient workshare.Address) error {
+	if err := w.exec(
+		"INSERT OR IGNORE INTO ref (data) VALUES(?),(?)",
+		sender[:],
+		recipient[:]); err != nil {
+		return err
+	}
+	const query = "INSERT OR IGNORE INTO tokenTransfer(seq, blockID, blockTime, txID, txOrigin, clauseIndex, amount, token, sender, recipient) " +
+		"SELECT seq, blockID, blockTime, txID, txOrigin, clauseIndex, data, address," +
+		refIDQuery + "," +
+		refIDQuery + " FROM event WHERE seq = ?"
+
+	return w.exec(query, sender[:], recipient[:], seq)
+}
+
 // Commit commits accumulated logs.
 func (w *Writer) Commit() (err error) {
 	if w.tx == nil {
 		return nil
 	}
 
-	defer func() {
+	defer func(start time.Time) {
+		// the tx is done even if commit failed
+		w.release()
 		if err == nil {
-			w.tx = nil
-			w.uncommittedCount = 0
+			metricCommit.ObserveDuration(time.Since(start))
 		}
-	}()
+	}(time.Now())
 	return w.tx.Commit()
 }
 
@@ -562,15 +779,17 @@ func (w *Writer) Rollback() (err error) {
 	if w.tx == nil {
 		return nil
 	}
-	defer func() {
-		if err == nil {
-			w.tx = nil
-			w.uncommittedCount = 0
-		}
-	}()
+	defer w.release()
 	return w.tx.Rollback()
 }
 
+// release resets the writer and releases the write lock, after the tx is done.
+func (w *Writer) release() {
+	w.tx = nil
+	w.uncommittedCount = 0
+	<-w.lock
+}
+
 // UncommittedCount returns the count of uncommitted logs.
 func (w *Writer) UncommittedCount() int {
 	return w.uncommittedCount
@@ -578,11 +797,17 @@ func (w *Writer) UncommittedCount() int {
 
 func (w *Writer) exec(query string, args ...interface{}) (err error) {
 	if w.tx == nil {
+		w.lock <- struct{}{}
 		if w.tx, err = w.conn.BeginTx(context.Background(), nil); err != nil {
+			<-w.lock
 			return
 		}
 	}
 	if _, err = w.tx.Stmt(w.stmtCache.MustPrepare(query)).Exec(args...); err != nil {
+		// the writer may be abandoned by the caller on error, so the tx is ended here
+		// rather than holding the write lock until a Commit or Rollback that never comes
+		w.tx.Rollback()
+		w.release()
 		return
 	}
 	w.uncommittedCount++
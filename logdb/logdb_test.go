@@ -8,8 +8,12 @@ package logdb_test
 import (
 	"context"
 	"crypto/rand"
+	"database/sql"
+	"math"
 	"math/big"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/miniBamboo/workshare/block"
@@ -164,13 +168,26 @@ func TestEvents(t *testing.T) {
 			{"query all events asc", &logdb.EventFilter{Order: logdb.ASC}, allEvents},
 			{"query all events desc", &logdb.EventFilter{Order: logdb.DESC}, allEvents.Reverse()},
 			{"query all events limit offset", &logdb.EventFilter{Options: &logdb.Options{Offset: 1, Limit: 10}}, allEvents[1:11]},
+			{"query all events cursor", &logdb.EventFilter{Options: &logdb.Options{Limit: 10, Cursor: allEvents[9].Cursor()}}, allEvents[10:20]},
+			{"query all events cursor desc", &logdb.EventFilter{Options: &logdb.Options{Limit: 10, Cursor: allEvents[20].Cursor()}, Order: logdb.DESC}, allEvents[10:20].Reverse()},
 			{"query all events range", &logdb.EventFilter{Range: &logdb.Range{From: 10, To: 20}}, allEvents.Filter(func(ev *logdb.Event) bool { return ev.BlockNumber >= 10 && ev.BlockNumber <= 20 })},
-			{"query all events with criteria", &logdb.EventFilter{CriteriaSet: []*logdb.EventCriteria{{Address: &allEvents[1].Address}}}, allEvents.Filter(func(ev *logdb.Event) bool {
+			{"query all events with criteria", &logdb.EventFilter{CriteriaSet: []*logdb.EventCriteria{{Address: []workshare.Address{allEvents[1].Address}}}}, allEvents.Filter(func(ev *logdb.Event) bool {
 				return ev.Address == allEvents[1].Address
 			})},
-			{"query all events with multi-criteria", &logdb.EventFilter{CriteriaSet: []*logdb.EventCriteria{{Address: &allEvents[1].Address}, {Topics: [5]*workshare.Bytes32{allEvents[2].Topics[0]}}, {Topics: [5]*workshare.Bytes32{allEvents[3].Topics[0]}}}}, allEvents.Filter(func(ev *logdb.Event) bool {
+			{"query all events with multi-criteria", &logdb.EventFilter{CriteriaSet: []*logdb.EventCriteria{{Address: []workshare.Address{allEvents[1].Address}}, {Topics: [5][]workshare.Bytes32{{*allEvents[2].Topics[0]}}}, {Topics: [5][]workshare.Bytes32{{*allEvents[3].Topics[0]}}}}}, allEvents.Filter(func(ev *logdb.Event) bool {
 				return ev.Address == allEvents[1].Address || *ev.Topics[0] == *allEvents[2].Topics[0] || *ev.Topics[0] == *allEvents[3].Topics[0]
 			})},
+			{"query all events with criteria sets", &logdb.EventFilter{CriteriaSet: []*logdb.EventCriteria{{Address: []workshare.Address{allEvents[1].Address, allEvents[2].Address}, Topics: [5][]workshare.Bytes32{{*allEvents[1].Topics[0], *allEvents[2].Topics[0], *allEvents[3].Topics[0]}}}}}, allEvents.Filter(func(ev *logdb.Event) bool {
+				return (ev.Address == allEvents[1].Address || ev.Address == allEvents[2].Address) &&
+					(*ev.Topics[0] == *allEvents[1].Topics[0] || *ev.Topics[0] == *allEvents[2].Topics[0] || *ev.Topics[0] == *allEvents[3].Topics[0])
+			})},
+			{"query all events by tx id", &logdb.EventFilter{CriteriaSet: []*logdb.EventCriteria{{TxID: []workshare.Bytes32{allEvents[1].TxID, allEvents[5].TxID}}}}, eventLogs{allEvents[1], allEvents[5]}},
+			{"query all events by tx origin", &logdb.EventFilter{CriteriaSet: []*logdb.EventCriteria{{TxOrigin: []workshare.Address{allEvents[2].TxOrigin}}}}, allEvents.Filter(func(ev *logdb.Event) bool {
+				return ev.TxOrigin == allEvents[2].TxOrigin
+			})},
+			{"query all events by tx id and address", &logdb.EventFilter{CriteriaSet: []*logdb.EventCriteria{{TxID: []workshare.Bytes32{allEvents[1].TxID}, Address: []workshare.Address{allEvents[2].Address}}}}, allEvents.Filter(func(ev *logdb.Event) bool {
+				return ev.TxID == allEvents[1].TxID && ev.Address == allEvents[2].Address
+			})},
 		}
 
 		for _, tt := range tests {
@@ -192,6 +209,8 @@ func TestEvents(t *testing.T) {
 			{"query all transfers asc", &logdb.TransferFilter{Order: logdb.ASC}, allTransfers},
 			{"query all transfers desc", &logdb.TransferFilter{Order: logdb.DESC}, allTransfers.Reverse()},
 			{"query all transfers limit offset", &logdb.TransferFilter{Options: &logdb.Options{Offset: 1, Limit: 10}}, allTransfers[1:11]},
+			{"query all transfers cursor", &logdb.TransferFilter{Options: &logdb.Options{Limit: 10, Cursor: allTransfers[9].Cursor()}}, allTransfers[10:20]},
+			{"query all transfers cursor desc", &logdb.TransferFilter{Options: &logdb.Options{Limit: 10, Cursor: allTransfers[20].Cursor()}, Order: logdb.DESC}, allTransfers[10:20].Reverse()},
 			{"query all transfers range", &logdb.TransferFilter{Range: &logdb.Range{From: 10, To: 20}}, allTransfers.Filter(func(tr *logdb.Transfer) bool { return tr.BlockNumber >= 10 && tr.BlockNumber <= 20 })},
 			{"query all transfers with criteria", &logdb.TransferFilter{CriteriaSet: []*logdb.TransferCriteria{{Sender: &allTransfers[1].Sender}}}, allTransfers.Filter(func(tr *logdb.Transfer) bool {
 				return tr.Sender == allTransfers[1].Sender
@@ -199,6 +218,10 @@ func TestEvents(t *testing.T) {
 			{"query all transfers with multi-criteria", &logdb.TransferFilter{CriteriaSet: []*logdb.TransferCriteria{{Sender: &allTransfers[1].Sender}, {Recipient: &allTransfers[2].Recipient}}}, allTransfers.Filter(func(tr *logdb.Transfer) bool {
 				return tr.Sender == allTransfers[1].Sender || tr.Recipient == allTransfers[2].Recipient
 			})},
+			{"query all transfers by tx id", &logdb.TransferFilter{CriteriaSet: []*logdb.TransferCriteria{{TxID: &allTransfers[3].TxID}}}, transferLogs{allTransfers[3]}},
+			{"query all transfers by tx origin", &logdb.TransferFilter{CriteriaSet: []*logdb.TransferCriteria{{TxOrigin: &allTransfers[4].TxOrigin}}}, allTransfers.Filter(func(tr *logdb.Transfer) bool {
+				return tr.TxOrigin == allTransfers[4].TxOrigin
+			})},
 		}
 
 		for _, tt := range tests {
@@ -210,3 +233,319 @@ func TestEvents(t *testing.T) {
 		}
 	}
 }
+
+func TestCursor(t *testing.T) {
+	c := logdb.NewCursor(100, 3)
+
+	text, err := c.MarshalText()
+	assert.Nil(t, err)
+
+	var decoded logdb.Cursor
+	assert.Nil(t, decoded.UnmarshalText(text))
+	assert.Equal(t, c, &decoded)
+
+	assert.NotNil(t, decoded.UnmarshalText([]byte("invalid")))
+}
+
+func newTokenTransferEvent(token, sender, recipient workshare.Address, amount *big.Int) *tx.Event {
+	return &tx.Event{
+		Address: token,
+		Topics: []workshare.Bytes32{
+			logdb.TokenTransferEventID,
+			workshare.BytesToBytes32(sender.Bytes()),
+			workshare.BytesToBytes32(recipient.Bytes()),
+		},
+		Data: workshare.BytesToBytes32(amount.Bytes()).Bytes(),
+	}
+}
+
+func writeTokenTransfers(t *testing.T, db *logdb.LogDB, token workshare.Address, holders []workshare.Address) (all []*logdb.TokenTransfer) {
+	b := new(block.Builder).Build()
+	for i := 0; i < 10; i++ {
+		b = new(block.Builder).
+			ParentID(b.Header().ID()).
+			Transaction(newTx()).
+			Build()
+		trx := b.Transactions()[0]
+		origin, _ := trx.Origin()
+
+		sender, recipient := holders[i%len(holders)], holders[(i+1)%len(holders)]
+		amount := big.NewInt(int64(i + 1))
+		receipt := newReceipt()
+		// the random event comes first, then the token transfer
+		receipt.Outputs[0].Events = append(receipt.Outputs[0].Events, newTokenTransferEvent(token, sender, recipient, amount))
+		// not a token transfer, since topics are not padded addresses
+		receipt.Outputs[0].Events = append(receipt.Outputs[0].Events, &tx.Event{
+			Address: token,
+			Topics:  []workshare.Bytes32{logdb.TokenTransferEventID, randBytes32(), randBytes32()},
+			Data:    randBytes32().Bytes(),
+		})
+
+		all = append(all, &logdb.TokenTransfer{
+			BlockNumber: b.Header().Number(),
+			Index:       1,
+			BlockID:     b.Header().ID(),
+			BlockTime:   b.Header().Timestamp(),
+			TxID:        trx.ID(),
+			TxOrigin:    origin,
+			Token:       token,
+			Sender:      sender,
+			Recipient:   recipient,
+			Amount:      amount,
+		})
+
+		w := db.NewWriter()
+		if err := w.Write(b, tx.Receipts{receipt}); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return
+}
+
+func TestTokenTransfers(t *testing.T) {
+	db, err := logdb.NewMem()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	token := randAddress()
+	holders := []workshare.Address{randAddress(), randAddress(), randAddress()}
+	all := writeTokenTransfers(t, db, token, holders)
+	other := randAddress()
+
+	tests := []struct {
+		name string
+		arg  *logdb.TokenTransferFilter
+		want []*logdb.TokenTransfer
+	}{
+		{"query all", &logdb.TokenTransferFilter{}, all},
+		{"query by token", &logdb.TokenTransferFilter{CriteriaSet: []*logdb.TokenTransferCriteria{{Token: &token}}}, all},
+		{"query by other token", &logdb.TokenTransferFilter{CriteriaSet: []*logdb.TokenTransferCriteria{{Token: &other}}}, nil},
+		{"query by sender", &logdb.TokenTransferFilter{CriteriaSet: []*logdb.TokenTransferCriteria{{Sender: &holders[0]}}}, []*logdb.TokenTransfer{all[0], all[3], all[6], all[9]}},
+		{"query by holder", &logdb.TokenTransferFilter{CriteriaSet: []*logdb.TokenTransferCriteria{{Sender: &holders[1]}, {Recipient: &holders[1]}}}, []*logdb.TokenTransfer{all[0], all[1], all[3], all[4], all[6], all[7], all[9]}},
+		{"query desc cursor", &logdb.TokenTransferFilter{Options: &logdb.Options{Limit: 2, Cursor: all[5].Cursor()}, Order: logdb.DESC}, []*logdb.TokenTransfer{all[4], all[3]}},
+		{"query range", &logdb.TokenTransferFilter{Range: &logdb.Range{From: 3, To: 4}}, all[1:3]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := db.FilterTokenTransfers(context.Background(), tt.arg)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	w := db.NewWriter()
+	if err := w.Truncate(5); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := db.FilterTokenTransfers(context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, all[:3], got)
+}
+
+func TestTokenTransfersMigration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs.db")
+	db, err := logdb.New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	all := writeTokenTransfers(t, db, randAddress(), []workshare.Address{randAddress(), randAddress()})
+	db.Close()
+
+	// simulate the db created before token transfers recorded
+	raw, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = raw.Exec("DROP TABLE tokenTransfer")
+	raw.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err = logdb.New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	got, err := db.FilterTokenTransfers(context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, all, got)
+}
+
+func TestTxIndexesMigration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs.db")
+	db, err := logdb.New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	// simulate the db created before tx indexes added
+	raw, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer raw.Close()
+	if _, err := raw.Exec("DROP INDEX event_i6; DROP INDEX event_i7; DROP INDEX transfer_i3"); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err = logdb.New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for _, index := range []string{"event_i6", "event_i7", "transfer_i3"} {
+		var n int
+		if err := raw.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type='index' AND name=?", index).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 1, n, index)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs.db")
+	db, err := logdb.New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTokenTransfers(t, db, randAddress(), []workshare.Address{randAddress(), randAddress()})
+	events, err := db.FilterEvents(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transfers, err := db.FilterTransfers(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer raw.Close()
+	hasRef := func(data workshare.Bytes32) bool {
+		var count int
+		if err := raw.QueryRow("SELECT COUNT(*) FROM ref WHERE data=?", data[:]).Scan(&count); err != nil {
+			t.Fatal(err)
+		}
+		return count > 0
+	}
+
+	// events of blocks 2, 3, 4
+	n, err := db.Prune(context.Background(), logdb.EventTable, 5, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(9), n)
+	assert.Equal(t, uint32(5), db.PrunedBefore(logdb.EventTable))
+	assert.Equal(t, uint32(0), db.PrunedBefore(logdb.TransferTable))
+
+	got, err := db.FilterEvents(context.Background(), &logdb.EventFilter{Range: &logdb.Range{From: 5, To: math.MaxUint32}})
+	assert.Nil(t, err)
+	assert.Equal(t, eventLogs(events[9:]), eventLogs(got))
+
+	_, err = db.FilterEvents(context.Background(), &logdb.EventFilter{Range: &logdb.Range{From: 4, To: 10}})
+	assert.Equal(t, &logdb.PrunedError{Table: logdb.EventTable, Before: 5}, err)
+	// no range covers pruned blocks too
+	_, err = db.FilterEvents(context.Background(), nil)
+	assert.Equal(t, &logdb.PrunedError{Table: logdb.EventTable, Before: 5}, err)
+	_, err = db.FilterEvents(context.Background(), &logdb.EventFilter{})
+	assert.Equal(t, &logdb.PrunedError{Table: logdb.EventTable, Before: 5}, err)
+	got, err = db.FilterEvents(context.Background(), &logdb.EventFilter{Range: &logdb.Range{From: 5, To: 5}})
+	assert.Nil(t, err)
+	assert.Equal(t, eventLogs(events[9:12]), eventLogs(got))
+
+	gotTransfers, err := db.FilterTransfers(context.Background(), &logdb.TransferFilter{Range: &logdb.Range{From: 0, To: 100}})
+	assert.Nil(t, err)
+	assert.Equal(t, transferLogs(transfers), transferLogs(gotTransfers))
+
+	// random topics of pruned events are deleted, shared ones are kept
+	assert.False(t, hasRef(*events[0].Topics[0]))
+	assert.True(t, hasRef(logdb.TokenTransferEventID))
+	// still referred by transfers
+	assert.True(t, hasRef(events[0].BlockID))
+	assert.True(t, hasRef(events[0].TxID))
+
+	for _, table := range []logdb.Table{logdb.TransferTable, logdb.TokenTransferTable} {
+		if _, err := db.Prune(context.Background(), table, 5, 100); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assert.False(t, hasRef(events[0].BlockID))
+	assert.False(t, hasRef(events[0].TxID))
+	assert.True(t, hasRef(events[9].BlockID))
+
+	// never shrinks
+	n, err = db.Prune(context.Background(), logdb.EventTable, 3, 100)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), n)
+	assert.Equal(t, uint32(5), db.PrunedBefore(logdb.EventTable))
+
+	_, err = db.Prune(context.Background(), logdb.Table("ref"), 5, 100)
+	assert.NotNil(t, err)
+
+	// waits for the writer to commit
+	w := db.NewWriter()
+	if err := w.Truncate(100); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = db.Prune(ctx, logdb.EventTable, 6, 100)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	if err := w.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	db.Close()
+	db, err = logdb.New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	assert.Equal(t, uint32(5), db.PrunedBefore(logdb.EventTable))
+	assert.Equal(t, uint32(5), db.PrunedBefore(logdb.TokenTransferTable))
+}
+
+func TestNewReadOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs.db")
+	_, err := logdb.NewReadOnly(path)
+	assert.NotNil(t, err, "not exist")
+
+	db, err := logdb.New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	writeTokenTransfers(t, db, randAddress(), []workshare.Address{randAddress(), randAddress()})
+	if _, err := db.Prune(context.Background(), logdb.EventTable, 3, 100); err != nil {
+		t.Fatal(err)
+	}
+	filter := &logdb.EventFilter{Range: &logdb.Range{From: 3, To: math.MaxUint32}}
+	want, err := db.FilterEvents(context.Background(), filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// opened while being written
+	ro, err := logdb.NewReadOnly(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ro.Close()
+	got, err := ro.FilterEvents(context.Background(), filter)
+	assert.Nil(t, err)
+	assert.Equal(t, want, got)
+	assert.Equal(t, uint32(3), ro.PrunedBefore(logdb.EventTable))
+	_, err = ro.FilterEvents(context.Background(), nil)
+	assert.Equal(t, &logdb.PrunedError{Table: logdb.EventTable, Before: 3}, err)
+}
@@ -0,0 +1,245 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package logdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+)
+
+// Table is the name of a log table.
+type Table string
+
+const (
+	EventTable         Table = "event"
+	TransferTable      Table = "transfer"
+	TokenTransferTable Table = "tokenTransfer"
+)
+
+// Tables lists all log tables.
+var Tables = []Table{EventTable, TransferTable, TokenTransferTable}
+
+// hashRefColumns returns columns of the table which refer to hashes.
+func (t Table) hashRefColumns() ([]string, error) {
+	switch t {
+	case EventTable:
+		return []string{"blockID", "txID", "topic0", "topic1", "topic2", "topic3", "topic4"}, nil
+	case TransferTable, TokenTransferTable:
+		return []string{"blockID", "txID"}, nil
+	}
+	return nil, fmt.Errorf("unknown table %q", string(t))
+}
+
+// PrunedError is returned when filtering logs in the range that has been pruned.
+type PrunedError struct {
+	Table  Table
+	Before uint32
+}
+
+func (e *PrunedError) Error() string {
+	return fmt.Sprintf("%v logs before block %v have been pruned", e.Table, e.Before)
+}
+
+// PrunedBefore returns the block number before which logs of the table have been pruned.
+func (db *LogDB) PrunedBefore(table Table) uint32 {
+	db.prunedLock.RLock()
+	defer db.prunedLock.RUnlock()
+	return db.pruned[table]
+}
+
+// checkPruned returns PrunedError if the range covers pruned logs of the table.
+// A nil range covers all blocks from genesis.
+func (db *LogDB) checkPruned(table Table, rng *Range) error {
+	var from uint32
+	if rng != nil {
+		from = rng.From
+	}
+	if before := db.PrunedBefore(table); from < before {
+		return &PrunedError{table, before}
+	}
+	return nil
+}
+
+func (db *LogDB) loadPruned() error {
+	rows, err := db.db.Query("SELECT name, blockNum FROM pruned")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var (
+			name     string
+			blockNum uint32
+		)
+		if err := rows.Scan(&name, &blockNum); err != nil {
+			return err
+		}
+		db.pruned[Table(name)] = blockNum
+	}
+	return rows.Err()
+}
+
+// Prune deletes logs of the table before the block number, and hash refs no longer referenced.
+// Logs are deleted in batches of at most batchSize rows, and each batch is committed in a short transaction,
+// so writers are blocked for at most one batch.
+//
+// The range before the block number is reported as pruned once it's called, and it never shrinks.
+func (db *LogDB) Prune(ctx context.Context, table Table, before uint32, batchSize int) (int64, error) {
+	columns, err := table.hashRefColumns()
+	if err != nil {
+		return 0, err
+	}
+	if err := db.markPruned(ctx, table, before); err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for {
+		n, err := db.pruneBatch(ctx, table, columns, newSequence(before, 0), batchSize)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		if n < int64(batchSize) {
+			return total, nil
+		}
+	}
+}
+
+func (db *LogDB) markPruned(ctx context.Context, table Table, before uint32) error {
+	if before <= db.PrunedBefore(table) {
+		return nil
+	}
+	if err := db.lockWrite(ctx); err != nil {
+		return err
+	}
+	defer db.unlockWrite()
+
+	if _, err := db.db.ExecContext(ctx, "INSERT OR REPLACE INTO pruned(name, blockNum) VALUES(?, ?)", string(table), before); err != nil {
+		return err
+	}
+
+	db.prunedLock.Lock()
+	db.pruned[table] = before
+	db.prunedLock.Unlock()
+	return nil
+}
+
+// pruneBatch deletes at most batchSize oldest logs of the table before the seq.
+func (db *LogDB) pruneBatch(ctx context.Context, table Table, columns []string, before sequence, batchSize int) (n int64, err error) {
+	if err := db.lockWrite(ctx); err != nil {
+		return 0, err
+	}
+	defer db.unlockWrite()
+
+	tx, err := db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	query := "SELECT seq"
+	for _, col := range columns {
+		query += ", " + col
+	}
+	query += fmt.Sprintf(" FROM %v WHERE seq < ? ORDER BY seq LIMIT ?", table)
+
+	minSeq, maxSeq, refs, err := collectRefs(ctx, tx, query, len(columns), before, batchSize)
+	if err != nil {
+		return 0, err
+	}
+	if len(refs) == 0 {
+		// no rows, as each row refers to its block
+		return 0, tx.Commit()
+	}
+
+	// the collected rows are the oldest ones
+	res, err := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %v WHERE seq <= ?", table), maxSeq)
+	if err != nil {
+		return 0, err
+	}
+	if n, err = res.RowsAffected(); err != nil {
+		return 0, err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, pruneRefQuery)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = stmt.Close() }()
+
+	var (
+		from = newSequence(minSeq.BlockNumber(), 0)
+		to   = newSequence(maxSeq.BlockNumber(), uint32(math.MaxInt32))
+	)
+	for id := range refs {
+		if _, err := stmt.ExecContext(ctx, id, from, to); err != nil {
+			return 0, err
+		}
+	}
+	return n, tx.Commit()
+}
+
+// collectRefs queries rows of seq and ref columns, and returns the seq range and the set of non-null refs.
+func collectRefs(ctx context.Context, tx *sql.Tx, query string, nColumns int, args ...interface{}) (minSeq, maxSeq sequence, refs map[int64]bool, err error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var (
+		seq   sequence
+		ids   = make([]sql.NullInt64, nColumns)
+		dests = make([]interface{}, nColumns+1)
+		first = true
+	)
+	refs = make(map[int64]bool)
+	dests[0] = &seq
+	for i := range ids {
+		dests[i+1] = &ids[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dests...); err != nil {
+			return 0, 0, nil, err
+		}
+		if first {
+			minSeq = seq
+			first = false
+		}
+		maxSeq = seq
+		for _, id := range ids {
+			if id.Valid {
+				refs[id.Int64] = true
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, 0, nil, err
+	}
+	return minSeq, maxSeq, refs, nil
+}
+
+// lockWrite acquires the exclusive right to write, which is held by a writer during its transaction.
+func (db *LogDB) lockWrite(ctx context.Context) error {
+	select {
+	case db.wlock <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (db *LogDB) unlockWrite() {
+	<-db.wlock
+}
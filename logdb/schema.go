@@ -32,7 +32,10 @@ CREATE INDEX IF NOT EXISTS event_i0 ON event(address);
 CREATE INDEX IF NOT EXISTS event_i1 ON event(topic0, address);
 CREATE INDEX IF NOT EXISTS event_i2 ON event(topic1, topic0, address) WHERE topic1 IS NOT NULL;
 CREATE INDEX IF NOT EXISTS event_i3 ON event(topic2, topic0, address) WHERE topic2 IS NOT NULL;
-CREATE INDEX IF NOT EXISTS event_i4 ON event(topic3, topic0, address) WHERE topic3 IS NOT NULL;`
+CREATE INDEX IF NOT EXISTS event_i4 ON event(topic3, topic0, address) WHERE topic3 IS NOT NULL;
+CREATE INDEX IF NOT EXISTS event_i5 ON event(topic4) WHERE topic4 IS NOT NULL;
+CREATE INDEX IF NOT EXISTS event_i6 ON event(txID);
+CREATE INDEX IF NOT EXISTS event_i7 ON event(txOrigin);`
 
 	// create transfers table
 	transferTableSchema = `CREATE TABLE IF NOT EXISTS transfer (
@@ -49,5 +52,62 @@ CREATE INDEX IF NOT EXISTS event_i4 ON event(topic3, topic0, address) WHERE topi
 
 CREATE INDEX IF NOT EXISTS transfer_i0 ON transfer(txOrigin);
 CREATE INDEX IF NOT EXISTS transfer_i1 ON transfer(sender);
-CREATE INDEX IF NOT EXISTS transfer_i2 ON transfer(recipient);`
+CREATE INDEX IF NOT EXISTS transfer_i2 ON transfer(recipient);
+CREATE INDEX IF NOT EXISTS transfer_i3 ON transfer(txID);`
+
+	// create token transfers table, which is derived from standard Transfer(address,address,uint256) events.
+	// seq is the same as the source event.
+	tokenTransferTableSchema = `CREATE TABLE IF NOT EXISTS tokenTransfer (
+	seq INTEGER PRIMARY KEY NOT NULL,
+	blockID	INTEGER NOT NULL,
+	blockTime INTEGER NOT NULL,
+	txID INTEGER NOT NULL,
+	txOrigin INTEGER NOT NULL,
+	clauseIndex INTEGER NOT NULL,
+	token INTEGER NOT NULL,
+	sender INTEGER NOT NULL,
+	recipient INTEGER NOT NULL,
+	amount BLOB(32)
+);
+
+CREATE INDEX IF NOT EXISTS tokenTransfer_i0 ON tokenTransfer(token);
+CREATE INDEX IF NOT EXISTS tokenTransfer_i1 ON tokenTransfer(sender, token);
+CREATE INDEX IF NOT EXISTS tokenTransfer_i2 ON tokenTransfer(recipient, token);`
+
+	// records the block number before which logs of each table have been pruned.
+	prunedTableSchema = `CREATE TABLE IF NOT EXISTS pruned (
+	name TEXT PRIMARY KEY NOT NULL,
+	blockNum INTEGER NOT NULL
+);`
+
+	// deletes the hash ref ?1 if it's no longer referenced, after logs in blocks of seq range [?2, ?3] are pruned.
+	// Block and tx IDs are unique to blocks, so only logs in the same blocks are checked for them.
+	// Refs of addresses are few and widely shared, and are always kept.
+	pruneRefQuery = `DELETE FROM ref WHERE id = ?1 AND length(data) = 32
+	AND NOT EXISTS (SELECT 1 FROM event WHERE topic0 = ?1)
+	AND NOT EXISTS (SELECT 1 FROM event WHERE topic1 = ?1)
+	AND NOT EXISTS (SELECT 1 FROM event WHERE topic2 = ?1)
+	AND NOT EXISTS (SELECT 1 FROM event WHERE topic3 = ?1)
+	AND NOT EXISTS (SELECT 1 FROM event WHERE topic4 = ?1)
+	AND NOT EXISTS (SELECT 1 FROM event WHERE seq BETWEEN ?2 AND ?3 AND (blockID = ?1 OR txID = ?1))
+	AND NOT EXISTS (SELECT 1 FROM transfer WHERE seq BETWEEN ?2 AND ?3 AND (blockID = ?1 OR txID = ?1))
+	AND NOT EXISTS (SELECT 1 FROM tokenTransfer WHERE seq BETWEEN ?2 AND ?3 AND (blockID = ?1 OR txID = ?1))`
+
+	// the two statements fill the token transfers table from existing events, for dbs created before the table.
+	// ?1 is bound to the event id, and the padded address topics are truncated into address refs.
+	tokenTransferRefMigration = `INSERT OR IGNORE INTO ref(data)
+	SELECT substr(r.data, 13) FROM event e JOIN ref r ON r.id IN (e.topic1, e.topic2)
+	WHERE e.topic0 = (SELECT id FROM ref WHERE data=?1) AND e.topic2 IS NOT NULL AND e.topic3 IS NULL AND length(e.data) = 32
+		AND substr(r.data, 1, 12) = zeroblob(12)`
+
+	tokenTransferMigration = `INSERT OR IGNORE INTO tokenTransfer(seq, blockID, blockTime, txID, txOrigin, clauseIndex, token, sender, recipient, amount)
+	SELECT e.seq, e.blockID, e.blockTime, e.txID, e.txOrigin, e.clauseIndex, e.address,
+		(SELECT id FROM ref WHERE data=substr(r1.data, 13)),
+		(SELECT id FROM ref WHERE data=substr(r2.data, 13)),
+		e.data
+	FROM event e
+		JOIN ref r1 ON e.topic1 = r1.id
+		JOIN ref r2 ON e.topic2 = r2.id
+	WHERE e.topic0 = (SELECT id FROM ref WHERE data=?1) AND e.topic3 IS NULL AND length(e.data) = 32
+		AND substr(r1.data, 1, 12) = zeroblob(12) AND substr(r2.data, 1, 12) = zeroblob(12)`
 )
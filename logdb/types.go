@@ -6,9 +6,15 @@
 package logdb
 
 import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"math/big"
+	"strings"
 
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/miniBamboo/workshare/tx"
 	"github.com/miniBamboo/workshare/workshare"
 )
 
@@ -40,6 +46,42 @@ type Transfer struct {
 	Amount      *big.Int
 }
 
+// TokenTransfer represents a standard token Transfer event that can be stored in db.
+type TokenTransfer struct {
+	BlockNumber uint32
+	Index       uint32 // index of the source event
+	BlockID     workshare.Bytes32
+	BlockTime   uint64
+	TxID        workshare.Bytes32
+	TxOrigin    workshare.Address
+	ClauseIndex uint32
+	Token       workshare.Address // the token contract
+	Sender      workshare.Address
+	Recipient   workshare.Address
+	Amount      *big.Int
+}
+
+// TokenTransferEventID is the topic0 of the standard Transfer(address,address,uint256) event,
+// emitted by ERC20/VIP180 tokens and the builtin Energy contract.
+var TokenTransferEventID = workshare.Bytes32(crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)")))
+
+// DecodeTokenTransfer decodes the event as a standard token transfer.
+// ok is false if the event doesn't match Transfer(address indexed, address indexed, uint256).
+func DecodeTokenTransfer(ev *tx.Event) (sender, recipient workshare.Address, amount *big.Int, ok bool) {
+	if len(ev.Topics) != 3 || ev.Topics[0] != TokenTransferEventID || len(ev.Data) != 32 {
+		return
+	}
+	for _, topic := range ev.Topics[1:] {
+		if !workshare.BytesToBytes32(topic[:12]).IsZero() {
+			return
+		}
+	}
+	return workshare.BytesToAddress(ev.Topics[1][12:]),
+		workshare.BytesToAddress(ev.Topics[2][12:]),
+		new(big.Int).SetBytes(ev.Data),
+		true
+}
+
 type Order string
 
 const (
@@ -55,28 +97,111 @@ type Range struct {
 type Options struct {
 	Offset uint64
 	Limit  uint64
+	Cursor *Cursor // if set, only logs after the cursor in the order are returned
 }
 
+// Cursor is the opaque position of an event or transfer log, to continue filtering
+// from where the previous page ended. It's built on the seq of the log, so paging with it
+// is not affected by new logs.
+type Cursor struct {
+	seq sequence
+}
+
+// NewCursor create a cursor at the given log position.
+func NewCursor(blockNum uint32, index uint32) *Cursor {
+	return &Cursor{newSequence(blockNum, index)}
+}
+
+// Cursor returns the cursor positioned at the event.
+func (e *Event) Cursor() *Cursor {
+	return NewCursor(e.BlockNumber, e.Index)
+}
+
+// Cursor returns the cursor positioned at the transfer.
+func (t *Transfer) Cursor() *Cursor {
+	return NewCursor(t.BlockNumber, t.Index)
+}
+
+// Cursor returns the cursor positioned at the token transfer.
+func (t *TokenTransfer) Cursor() *Cursor {
+	return NewCursor(t.BlockNumber, t.Index)
+}
+
+// String returns the encoded cursor.
+func (c *Cursor) String() string {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(c.seq))
+	return base64.RawURLEncoding.EncodeToString(b[:])
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (c *Cursor) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (c *Cursor) UnmarshalText(text []byte) error {
+	b, err := base64.RawURLEncoding.DecodeString(string(text))
+	if err != nil || len(b) != 8 {
+		return errors.New("invalid cursor")
+	}
+	seq := sequence(binary.BigEndian.Uint64(b))
+	if seq < 0 {
+		return errors.New("invalid cursor")
+	}
+	c.seq = seq
+	return nil
+}
+
+// EventCriteria matches events by address, topics and the emitting transaction. Each field is an OR-set, that
+// an empty set matches any value.
 type EventCriteria struct {
-	Address *workshare.Address // always a contract address
-	Topics  [5]*workshare.Bytes32
+	Address  []workshare.Address // always contract addresses
+	Topics   [5][]workshare.Bytes32
+	TxID     []workshare.Bytes32
+	TxOrigin []workshare.Address
 }
 
 func (c *EventCriteria) toWhereCondition() (cond string, args []interface{}) {
 	cond = "1"
-	if c.Address != nil {
-		cond += " AND address = " + refIDQuery
-		args = append(args, c.Address.Bytes())
-	}
-	for i, topic := range c.Topics {
-		if topic != nil {
-			cond += fmt.Sprintf(" AND topic%v = ", i) + refIDQuery
-			args = append(args, topic.Bytes())
+	if len(c.Address) > 0 {
+		cond += " AND address " + refIDSetQuery(len(c.Address))
+		for _, addr := range c.Address {
+			args = append(args, addr.Bytes())
+		}
+	}
+	if len(c.TxID) > 0 {
+		cond += " AND txID " + refIDSetQuery(len(c.TxID))
+		for _, id := range c.TxID {
+			args = append(args, id.Bytes())
+		}
+	}
+	if len(c.TxOrigin) > 0 {
+		cond += " AND txOrigin " + refIDSetQuery(len(c.TxOrigin))
+		for _, addr := range c.TxOrigin {
+			args = append(args, addr.Bytes())
+		}
+	}
+	for i, topics := range c.Topics {
+		if len(topics) > 0 {
+			cond += fmt.Sprintf(" AND topic%v ", i) + refIDSetQuery(len(topics))
+			for _, topic := range topics {
+				args = append(args, topic.Bytes())
+			}
 		}
 	}
 	return
 }
 
+// refIDSetQuery returns the condition to match ref ids of n values.
+// Equality is used for a single value, so that the planner sees the same query as before sets supported.
+func refIDSetQuery(n int) string {
+	if n == 1 {
+		return "= " + refIDQuery
+	}
+	return "IN (SELECT id FROM ref WHERE data IN (?" + strings.Repeat(",?", n-1) + "))"
+}
+
 //EventFilter filter
 type EventFilter struct {
 	CriteriaSet []*EventCriteria
@@ -86,6 +211,7 @@ type EventFilter struct {
 }
 
 type TransferCriteria struct {
+	TxID      *workshare.Bytes32 //the transaction
 	TxOrigin  *workshare.Address //who send transaction
 	Sender    *workshare.Address //who transferred tokens
 	Recipient *workshare.Address //who recieved tokens
@@ -93,6 +219,10 @@ type TransferCriteria struct {
 
 func (c *TransferCriteria) toWhereCondition() (cond string, args []interface{}) {
 	cond = "1"
+	if c.TxID != nil {
+		cond += " AND txID = " + refIDQuery
+		args = append(args, c.TxID.Bytes())
+	}
 	if c.TxOrigin != nil {
 		cond += " AND txOrigin = " + refIDQuery
 		args = append(args, c.TxOrigin.Bytes())
@@ -114,3 +244,34 @@ type TransferFilter struct {
 	Options     *Options
 	Order       Order //default asc
 }
+
+// TokenTransferCriteria matches token transfers. Nil fields match any value.
+type TokenTransferCriteria struct {
+	Token     *workshare.Address // the token contract
+	Sender    *workshare.Address
+	Recipient *workshare.Address
+}
+
+func (c *TokenTransferCriteria) toWhereCondition() (cond string, args []interface{}) {
+	cond = "1"
+	if c.Token != nil {
+		cond += " AND token = " + refIDQuery
+		args = append(args, c.Token.Bytes())
+	}
+	if c.Sender != nil {
+		cond += " AND sender = " + refIDQuery
+		args = append(args, c.Sender.Bytes())
+	}
+	if c.Recipient != nil {
+		cond += " AND recipient = " + refIDQuery
+		args = append(args, c.Recipient.Bytes())
+	}
+	return
+}
+
+type TokenTransferFilter struct {
+	CriteriaSet []*TokenTransferCriteria
+	Range       *Range
+	Options     *Options
+	Order       Order //default asc
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package logdb
+
+import (
+	"context"
+	"database/sql"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/miniBamboo/workshare/block"
+	"github.com/miniBamboo/workshare/tx"
+	"github.com/miniBamboo/workshare/workshare"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriterReleaseLockOnFailure(t *testing.T) {
+	db, err := NewMem()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	b := new(block.Builder).Build()
+	receipts := tx.Receipts{{Outputs: []*tx.Output{{Events: tx.Events{{Address: workshare.BytesToAddress([]byte("addr"))}}}}}}
+
+	for _, end := range []func(w *Writer) error{(*Writer).Commit, (*Writer).Rollback} {
+		w := db.NewWriter()
+		if err := w.Write(b, receipts); err != nil {
+			t.Fatal(err)
+		}
+		// the tx is ended underneath, so that committing or rolling back the writer fails
+		if err := w.tx.Rollback(); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, sql.ErrTxDone, end(w))
+		assert.Equal(t, 0, w.UncommittedCount())
+
+		// the write lock is released
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		err := db.lockWrite(ctx)
+		cancel()
+		if !assert.Nil(t, err) {
+			return
+		}
+		db.unlockWrite()
+	}
+
+	// the writer is still usable
+	w := db.NewWriter()
+	if err := w.Write(b, receipts); err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, w.Commit())
+	events, err := db.FilterEvents(context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(events))
+}
+
+func TestWriterRollbackOnWriteFailure(t *testing.T) {
+	db, err := NewMem()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	b := new(block.Builder).Build()
+	receipts := tx.Receipts{{Outputs: []*tx.Output{{
+		Events:    tx.Events{{Address: workshare.BytesToAddress([]byte("addr"))}},
+		Transfers: tx.Transfers{{Sender: workshare.BytesToAddress([]byte("sender")), Amount: big.NewInt(1)}},
+	}}}}
+
+	// the event is inserted, then the transfer fails
+	if _, err := db.db.Exec("CREATE TRIGGER fail BEFORE INSERT ON transfer BEGIN SELECT RAISE(ABORT, 'failed'); END"); err != nil {
+		t.Fatal(err)
+	}
+	w := db.NewWriter()
+	assert.Error(t, w.Write(b, receipts))
+	assert.Equal(t, 0, w.UncommittedCount())
+
+	// the writer is abandoned without Commit or Rollback, and the pruner still progresses
+	done := make(chan error, 1)
+	go func() {
+		_, err := db.Prune(context.Background(), TokenTransferTable, 1, 10)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		assert.Nil(t, err)
+	case <-time.After(time.Second):
+		w.Rollback()
+		t.Fatal("pruner blocked by the failed writer")
+	}
+
+	// the event written before the failure is rolled back
+	events, err := db.FilterEvents(context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(events))
+
+	if _, err := db.db.Exec("DROP TRIGGER fail"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(new(block.Builder).ParentID(b.Header().ID()).Build(), receipts); err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, w.Commit())
+	transfers, err := db.FilterTransfers(context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(transfers))
+}
@@ -0,0 +1,212 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package metric
+
+import (
+	"bufio"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefBuckets are the default histogram buckets, for durations in seconds.
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// atomicFloat is a float64 value which can be updated atomically.
+type atomicFloat struct {
+	bits uint64
+}
+
+func (f *atomicFloat) Load() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&f.bits))
+}
+
+func (f *atomicFloat) Store(v float64) {
+	atomic.StoreUint64(&f.bits, math.Float64bits(v))
+}
+
+func (f *atomicFloat) Add(v float64) {
+	for {
+		old := atomic.LoadUint64(&f.bits)
+		if atomic.CompareAndSwapUint64(&f.bits, old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+// Counter is a value that only goes up.
+type Counter struct {
+	value atomicFloat
+}
+
+// Inc increases the counter by 1.
+func (c *Counter) Inc() { c.value.Add(1) }
+
+// Add increases the counter by v, which must not be negative.
+func (c *Counter) Add(v float64) {
+	if v < 0 {
+		panic("metric: counter cannot decrease")
+	}
+	c.value.Add(v)
+}
+
+// Value returns the current value.
+func (c *Counter) Value() float64 { return c.value.Load() }
+
+func (c *Counter) write(w *bufio.Writer, name, labels string) {
+	writeSample(w, name, labels, c.Value())
+}
+
+// Gauge is a value that can go up and down.
+type Gauge struct {
+	value atomicFloat
+}
+
+// Set sets the gauge to v.
+func (g *Gauge) Set(v float64) { g.value.Store(v) }
+
+// Add adds v to the gauge.
+func (g *Gauge) Add(v float64) { g.value.Add(v) }
+
+// Inc increases the gauge by 1.
+func (g *Gauge) Inc() { g.value.Add(1) }
+
+// Dec decreases the gauge by 1.
+func (g *Gauge) Dec() { g.value.Add(-1) }
+
+// Value returns the current value.
+func (g *Gauge) Value() float64 { return g.value.Load() }
+
+func (g *Gauge) write(w *bufio.Writer, name, labels string) {
+	writeSample(w, name, labels, g.Value())
+}
+
+// Histogram counts observations in buckets.
+type Histogram struct {
+	upperBounds []float64
+
+	lock   sync.Mutex
+	counts []uint64 // count of each bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+func newHistogram(buckets []float64) *Histogram {
+	upperBounds := append([]float64(nil), buckets...)
+	sort.Float64s(upperBounds)
+	return &Histogram{
+		upperBounds: upperBounds,
+		counts:      make([]uint64, len(upperBounds)),
+	}
+}
+
+// Observe adds an observation.
+func (h *Histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.upperBounds, v)
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if i < len(h.counts) {
+		h.counts[i]++
+	}
+	h.count++
+	h.sum += v
+}
+
+// ObserveDuration adds an observation of the duration in seconds.
+func (h *Histogram) ObserveDuration(d time.Duration) {
+	h.Observe(d.Seconds())
+}
+
+func (h *Histogram) write(w *bufio.Writer, name, labels string) {
+	h.lock.Lock()
+	counts := append([]uint64(nil), h.counts...)
+	count, sum := h.count, h.sum
+	h.lock.Unlock()
+
+	sep := ""
+	if labels != "" {
+		sep = ","
+	}
+	var cumulative uint64
+	for i, bound := range h.upperBounds {
+		cumulative += counts[i]
+		writeSample(w, name+"_bucket", labels+sep+`le="`+formatFloat(bound)+`"`, float64(cumulative))
+	}
+	writeSample(w, name+"_bucket", labels+sep+`le="+Inf"`, float64(count))
+	writeSample(w, name+"_sum", labels, sum)
+	writeSample(w, name+"_count", labels, float64(count))
+}
+
+// CounterVec is a set of counters partitioned by label values.
+type CounterVec struct {
+	f *family
+}
+
+// With returns the counter of the given label values, which must match the label names in order.
+func (v *CounterVec) With(values ...string) *Counter {
+	return v.f.child(values).(*Counter)
+}
+
+// GaugeVec is a set of gauges partitioned by label values.
+type GaugeVec struct {
+	f *family
+}
+
+// With returns the gauge of the given label values, which must match the label names in order.
+func (v *GaugeVec) With(values ...string) *Gauge {
+	return v.f.child(values).(*Gauge)
+}
+
+// HistogramVec is a set of histograms partitioned by label values.
+type HistogramVec struct {
+	f *family
+}
+
+// With returns the histogram of the given label values, which must match the label names in order.
+func (v *HistogramVec) With(values ...string) *Histogram {
+	return v.f.child(values).(*Histogram)
+}
+
+// NewCounter creates and registers a counter into the default registry.
+func NewCounter(name, help string) *Counter {
+	return DefaultRegistry.register(name, help, "counter", nil, func() metric { return &Counter{} }).child(nil).(*Counter)
+}
+
+// NewCounterVec creates and registers a counter vector into the default registry.
+func NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	return &CounterVec{DefaultRegistry.register(name, help, "counter", labelNames, func() metric { return &Counter{} })}
+}
+
+// NewGauge creates and registers a gauge into the default registry.
+func NewGauge(name, help string) *Gauge {
+	return DefaultRegistry.register(name, help, "gauge", nil, func() metric { return &Gauge{} }).child(nil).(*Gauge)
+}
+
+// NewGaugeVec creates and registers a gauge vector into the default registry.
+func NewGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	return &GaugeVec{DefaultRegistry.register(name, help, "gauge", labelNames, func() metric { return &Gauge{} })}
+}
+
+// NewHistogram creates and registers a histogram into the default registry.
+// DefBuckets is used if buckets is nil.
+func NewHistogram(name, help string, buckets []float64) *Histogram {
+	if buckets == nil {
+		buckets = DefBuckets
+	}
+	return DefaultRegistry.register(name, help, "histogram", nil, func() metric { return newHistogram(buckets) }).child(nil).(*Histogram)
+}
+
+// NewHistogramVec creates and registers a histogram vector into the default registry.
+// DefBuckets is used if buckets is nil.
+func NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefBuckets
+	}
+	return &HistogramVec{DefaultRegistry.register(name, help, "histogram", labelNames, func() metric { return newHistogram(buckets) })}
+}
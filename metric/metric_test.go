@@ -0,0 +1,55 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package metric
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	r := newRegistry()
+
+	c := r.register("test_total", "test counter", "counter", nil, func() metric { return &Counter{} }).child(nil).(*Counter)
+	c.Inc()
+	c.Add(2)
+	assert.Panics(t, func() { c.Add(-1) })
+
+	g := &GaugeVec{r.register("test_gauge", "test gauge", "gauge", []string{"name"}, func() metric { return &Gauge{} })}
+	g.With(`a"b`).Set(5)
+	g.With(`a"b`).Dec()
+	assert.Panics(t, func() { g.With() })
+
+	h := &HistogramVec{r.register("test_seconds", "test histogram", "histogram", []string{"op"}, func() metric { return newHistogram([]float64{1, 0.1}) })}
+	h.With("read").Observe(0.05)
+	h.With("read").Observe(0.5)
+	h.With("read").Observe(2)
+
+	assert.Panics(t, func() {
+		r.register("test_total", "", "counter", nil, func() metric { return &Counter{} })
+	})
+
+	var buf bytes.Buffer
+	n, err := r.WriteTo(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(buf.Len()), n)
+	assert.Equal(t, `# HELP workshare_test_gauge test gauge
+# TYPE workshare_test_gauge gauge
+workshare_test_gauge{name="a\"b"} 4
+# HELP workshare_test_seconds test histogram
+# TYPE workshare_test_seconds histogram
+workshare_test_seconds_bucket{op="read",le="0.1"} 1
+workshare_test_seconds_bucket{op="read",le="1"} 2
+workshare_test_seconds_bucket{op="read",le="+Inf"} 3
+workshare_test_seconds_sum{op="read"} 2.55
+workshare_test_seconds_count{op="read"} 3
+# HELP workshare_test_total test counter
+# TYPE workshare_test_total counter
+workshare_test_total 3
+`, buf.String())
+}
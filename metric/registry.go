@@ -0,0 +1,187 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package metric
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Namespace is prefixed to names of all registered metrics.
+const Namespace = "workshare"
+
+// DefaultRegistry is the registry metrics are registered into.
+var DefaultRegistry = newRegistry()
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+)
+
+type metric interface {
+	write(w *bufio.Writer, name, labels string)
+}
+
+// family is a named metric with all its label values.
+type family struct {
+	name, help, typ string
+	labelNames      []string
+	newMetric       func() metric
+
+	lock     sync.RWMutex
+	children map[string]metric // keyed by formatted labels
+}
+
+func (f *family) child(values []string) metric {
+	if len(values) != len(f.labelNames) {
+		panic(fmt.Sprintf("metric: %v: expected %d label values, got %d", f.name, len(f.labelNames), len(values)))
+	}
+	labels := formatLabels(f.labelNames, values)
+
+	f.lock.RLock()
+	m, ok := f.children[labels]
+	f.lock.RUnlock()
+	if ok {
+		return m
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if m, ok := f.children[labels]; ok {
+		return m
+	}
+	m = f.newMetric()
+	f.children[labels] = m
+	return m
+}
+
+func (f *family) write(w *bufio.Writer) {
+	f.lock.RLock()
+	labels := make([]string, 0, len(f.children))
+	for l := range f.children {
+		labels = append(labels, l)
+	}
+	f.lock.RUnlock()
+	if len(labels) == 0 {
+		return
+	}
+	sort.Strings(labels)
+
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, helpEscaper.Replace(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.typ)
+	for _, l := range labels {
+		f.lock.RLock()
+		m := f.children[l]
+		f.lock.RUnlock()
+		m.write(w, f.name, l)
+	}
+}
+
+// Registry holds metrics and exports them in the Prometheus text format.
+type Registry struct {
+	lock     sync.Mutex
+	families map[string]*family
+}
+
+// newRegistry creates an empty registry.
+func newRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// register registers a metric family. It panics if the name is already registered.
+func (r *Registry) register(name, help, typ string, labelNames []string, newMetric func() metric) *family {
+	name = Namespace + "_" + name
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if _, ok := r.families[name]; ok {
+		panic("metric: duplicated metric " + name)
+	}
+	f := &family{
+		name:       name,
+		help:       help,
+		typ:        typ,
+		labelNames: labelNames,
+		newMetric:  newMetric,
+		children:   make(map[string]metric),
+	}
+	r.families[name] = f
+	return f
+}
+
+// WriteTo writes all metrics in the Prometheus text format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.lock.Lock()
+	families := make([]*family, 0, len(r.families))
+	for _, f := range r.families {
+		families = append(families, f)
+	}
+	r.lock.Unlock()
+	sort.Slice(families, func(i, j int) bool {
+		return families[i].name < families[j].name
+	})
+
+	var size StorageSize
+	bw := bufio.NewWriter(io.MultiWriter(w, &size))
+	for _, f := range families {
+		f.write(bw)
+	}
+	err := bw.Flush()
+	return size.Int64(), err
+}
+
+// Handler returns the http handler to serve metrics of the default registry.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		DefaultRegistry.WriteTo(w)
+	})
+}
+
+func formatLabels(names, values []string) string {
+	var b strings.Builder
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(name)
+		b.WriteString(`="`)
+		b.WriteString(labelEscaper.Replace(values[i]))
+		b.WriteByte('"')
+	}
+	return b.String()
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func writeSample(w *bufio.Writer, name, labels string, value float64) {
+	w.WriteString(name)
+	if labels != "" {
+		w.WriteByte('{')
+		w.WriteString(labels)
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
@@ -12,10 +12,13 @@ import (
 	"time"
 
 	lru "github.com/hashicorp/golang-lru"
+	"github.com/miniBamboo/workshare/metric"
 	"github.com/miniBamboo/workshare/trie"
 	"github.com/qianbin/directcache"
 )
 
+var metricCacheLookups = metric.NewCounterVec("trie_cache_lookups_total", "Number of trie cache lookups by cache and result.", "cache", "result")
+
 // Cache is the cache layer for trie.
 type Cache struct {
 	// caches recently queried node blobs. Using full node key as key.
@@ -36,6 +39,8 @@ func NewCache(sizeMB int, rootCap int) *Cache {
 	cache.queriedNodes = directcache.New(sizeBytes / 4)
 	cache.committedNodes = directcache.New(sizeBytes - sizeBytes/4)
 	cache.roots, _ = lru.NewARC(rootCap)
+	cache.nodeStats.init("node")
+	cache.rootStats.init("root")
 	cache.lastLogTime = time.Now().UnixNano()
 	return &cache
 }
@@ -186,10 +191,24 @@ func (c *Cache) GetRootNode(name string, seq uint64, peek bool) (trie.Node, bool
 type cacheStats struct {
 	hit, miss int64
 	flag      int32
+
+	metricHit, metricMiss *metric.Counter
+}
+
+func (cs *cacheStats) init(name string) {
+	cs.metricHit = metricCacheLookups.With(name, "hit")
+	cs.metricMiss = metricCacheLookups.With(name, "miss")
 }
 
-func (cs *cacheStats) Hit() int64  { return atomic.AddInt64(&cs.hit, 1) }
-func (cs *cacheStats) Miss() int64 { return atomic.AddInt64(&cs.miss, 1) }
+func (cs *cacheStats) Hit() int64 {
+	cs.metricHit.Inc()
+	return atomic.AddInt64(&cs.hit, 1)
+}
+
+func (cs *cacheStats) Miss() int64 {
+	cs.metricMiss.Inc()
+	return atomic.AddInt64(&cs.miss, 1)
+}
 
 func (cs *cacheStats) ShouldLog(msg string) (func(), bool) {
 	hit := atomic.LoadInt64(&cs.hit)
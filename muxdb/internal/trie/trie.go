@@ -252,6 +252,12 @@ func (t *Trie) FastGet(key []byte, steadyCommitNum uint32) ([]byte, []byte, erro
 	return val, meta, nil
 }
 
+// Prove constructs a merkle proof for key. The result contains all encoded nodes
+// on the path to the value at key. See trie.Trie.Prove.
+func (t *Trie) Prove(key []byte, proofDb trie.DatabaseWriter) error {
+	return t.ext.Prove(key, 0, proofDb)
+}
+
 // Update associates key with value in the trie. Subsequent calls to
 // Get will return value. If value has length zero, any existing value
 // is deleted from the trie and calls to Get will return nil.
@@ -193,6 +193,18 @@ func (net *Network) ReadRandomNodes(buf []*Node) (n int) {
 	return n
 }
 
+// TableNodes returns all nodes in the table, without network-related states.
+func (net *Network) TableNodes() (nodes []*Node) {
+	net.reqTableOp(func() {
+		for _, b := range &net.tab.buckets {
+			for _, n := range b.entries {
+				nodes = append(nodes, NewNode(n.ID, n.IP, n.UDP, n.TCP))
+			}
+		}
+	})
+	return nodes
+}
+
 // SetFallbackNodes sets the initial points of contact. These nodes
 // are used to connect to the network if the table is empty and there
 // are no known nodes in the database.
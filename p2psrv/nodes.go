@@ -7,6 +7,7 @@ package p2psrv
 
 import (
 	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/p2p/discover"
 	"github.com/ethereum/go-ethereum/rlp"
@@ -74,3 +75,59 @@ func (nm *nodeMap) Len() int {
 	defer nm.lock.Unlock()
 	return len(nm.m)
 }
+
+// thread-safe list of banned nodes, with expiration time.
+type banList struct {
+	m    map[discover.NodeID]time.Time
+	lock sync.Mutex
+}
+
+func newBanList() *banList {
+	return &banList{
+		m: make(map[discover.NodeID]time.Time),
+	}
+}
+
+func (bl *banList) Add(id discover.NodeID, until time.Time) {
+	bl.lock.Lock()
+	defer bl.lock.Unlock()
+	bl.m[id] = until
+}
+
+func (bl *banList) Remove(id discover.NodeID) bool {
+	bl.lock.Lock()
+	defer bl.lock.Unlock()
+	if _, ok := bl.m[id]; ok {
+		delete(bl.m, id)
+		return true
+	}
+	return false
+}
+
+func (bl *banList) Contains(id discover.NodeID) bool {
+	bl.lock.Lock()
+	defer bl.lock.Unlock()
+	if until, ok := bl.m[id]; ok {
+		if time.Now().Before(until) {
+			return true
+		}
+		delete(bl.m, id)
+	}
+	return false
+}
+
+// All returns unexpired bans.
+func (bl *banList) All() map[discover.NodeID]time.Time {
+	bl.lock.Lock()
+	defer bl.lock.Unlock()
+	now := time.Now()
+	all := make(map[discover.NodeID]time.Time, len(bl.m))
+	for id, until := range bl.m {
+		if now.Before(until) {
+			all[id] = until
+		} else {
+			delete(bl.m, id)
+		}
+	}
+	return all
+}
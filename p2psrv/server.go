@@ -35,6 +35,7 @@ type Server struct {
 	knownNodes      *cache.PrioCache
 	discoveredNodes *cache.RandCache
 	dialingNodes    *nodeMap
+	bannedNodes     *banList
 }
 
 // New create a p2p server.
@@ -66,6 +67,7 @@ func New(opts *Options) *Server {
 		knownNodes:      knownNodes,
 		discoveredNodes: discoveredNodes,
 		dialingNodes:    newNodeMap(),
+		bannedNodes:     newBanList(),
 	}
 }
 
@@ -87,6 +89,10 @@ func (s *Server) Start(protocols []*p2p.Protocol, topic discv5.Topic) error {
 			}
 			log := log.New("peer", peer, "dir", dir)
 
+			if s.bannedNodes.Contains(peer.ID()) {
+				log.Debug("banned peer rejected")
+				return errors.New("banned")
+			}
 			log.Debug("peer connected")
 			startTime := mclock.Now()
 			defer func() {
@@ -164,6 +170,68 @@ func (s *Server) NodeInfo() *p2p.NodeInfo {
 	return s.srv.NodeInfo()
 }
 
+// AddTrusted adds the given node to the trusted set, which is allowed to connect even if slots are full.
+func (s *Server) AddTrusted(node *discover.Node) {
+	s.srv.AddTrustedPeer(node)
+}
+
+// RemoveTrusted removes the given node from the trusted set.
+func (s *Server) RemoveTrusted(node *discover.Node) {
+	s.srv.RemoveTrustedPeer(node)
+}
+
+// PeersInfo returns metadata of connected peers.
+func (s *Server) PeersInfo() []*p2p.PeerInfo {
+	return s.srv.PeersInfo()
+}
+
+// Disconnect disconnects the peer of the given ID.
+// False returned if the peer is not connected.
+func (s *Server) Disconnect(id discover.NodeID) bool {
+	for _, peer := range s.srv.Peers() {
+		if peer.ID() == id {
+			peer.Disconnect(p2p.DiscRequested)
+			return true
+		}
+	}
+	return false
+}
+
+// Ban disconnects the peer of the given ID, and refuses connections with it for the duration.
+func (s *Server) Ban(id discover.NodeID, duration time.Duration) {
+	s.bannedNodes.Add(id, time.Now().Add(duration))
+	s.Disconnect(id)
+}
+
+// Unban lifts the ban of the node. False returned if the node is not banned.
+func (s *Server) Unban(id discover.NodeID) bool {
+	return s.bannedNodes.Remove(id)
+}
+
+// BannedNodes returns banned nodes with their ban expiration time.
+func (s *Server) BannedNodes() map[discover.NodeID]time.Time {
+	return s.bannedNodes.All()
+}
+
+// DiscoveredNodes returns nodes found by discovery, which are candidates to dial.
+func (s *Server) DiscoveredNodes() Nodes {
+	nodes := make([]*discover.Node, 0, s.discoveredNodes.Len())
+	s.discoveredNodes.ForEach(func(ent *cache.Entry) bool {
+		nodes = append(nodes, ent.Value.(*discover.Node))
+		return true
+	})
+	return nodes
+}
+
+// TableNodes returns nodes in the discovery table.
+// Nil returned if discovery is disabled.
+func (s *Server) TableNodes() []*discv5.Node {
+	if s.discv5 == nil {
+		return nil
+	}
+	return s.discv5.TableNodes()
+}
+
 func (s *Server) listenDiscV5() (err error) {
 	// borrowed from ethereum/p2p.Server.Start
 	addr, err := net.ResolveUDPAddr("udp", s.opts.ListenAddr)
@@ -291,7 +359,7 @@ func (s *Server) dialLoop() {
 			}
 
 			node := entry.Value.(*discover.Node)
-			if s.dialingNodes.Contains(node.ID) {
+			if s.dialingNodes.Contains(node.ID) || s.bannedNodes.Contains(node.ID) {
 				continue
 			}
 
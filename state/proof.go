@@ -0,0 +1,132 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package state
+
+import (
+	"bytes"
+
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/miniBamboo/workshare/muxdb"
+	"github.com/miniBamboo/workshare/trie"
+	"github.com/miniBamboo/workshare/workshare"
+	"github.com/pkg/errors"
+)
+
+var emptyRoot = workshare.Blake2b(rlp.EmptyString)
+
+// ProofList is a merkle proof, which contains encoded trie nodes on the path from the root to the value.
+type ProofList [][]byte
+
+// Put implements trie.DatabaseWriter.
+func (l *ProofList) Put(key, value []byte) error {
+	*l = append(*l, append([]byte(nil), value...))
+	return nil
+}
+
+// Get implements trie.DatabaseReader. The key is the hash of the node.
+func (l ProofList) Get(key []byte) ([]byte, error) {
+	for _, node := range l {
+		if hash := workshare.Blake2b(node); bytes.Equal(hash[:], key) {
+			return node, nil
+		}
+	}
+	return nil, errors.New("not found")
+}
+
+// StorageProof is the merkle proof of a storage value.
+type StorageProof struct {
+	Key   workshare.Bytes32
+	Value workshare.Bytes32
+	Proof ProofList
+}
+
+// AccountProof is the merkle proof of an account and its storage values.
+type AccountProof struct {
+	Account       *Account
+	Proof         ProofList
+	StorageProofs []*StorageProof
+}
+
+// Prove generates merkle proofs of the account and its storage values of given keys.
+// The proofs are against the root the state is created with, so uncommitted changes are not covered.
+func (s *State) Prove(addr workshare.Address, keys []workshare.Bytes32) (*AccountProof, error) {
+	acc, meta, err := loadAccount(s.trie, addr, s.steadyBlockNum)
+	if err != nil {
+		return nil, &Error{err}
+	}
+	proof := &AccountProof{Account: acc}
+	hashedAddr := workshare.Blake2b(addr[:])
+	if err := s.trie.Prove(hashedAddr[:], &proof.Proof); err != nil {
+		return nil, &Error{err}
+	}
+
+	var storageTrie *muxdb.Trie
+	if len(acc.StorageRoot) > 0 {
+		storageTrie = s.db.NewTrie(
+			StorageTrieName(meta.StorageID),
+			workshare.BytesToBytes32(acc.StorageRoot),
+			meta.StorageCommitNum,
+			meta.StorageDistinctNum)
+	}
+	for _, key := range keys {
+		sp := &StorageProof{Key: key}
+		if storageTrie != nil {
+			raw, err := loadStorage(storageTrie, key, s.steadyBlockNum)
+			if err != nil {
+				return nil, &Error{err}
+			}
+			if sp.Value, err = decodeStorageValue(raw); err != nil {
+				return nil, &Error{err}
+			}
+			hashedKey := workshare.Blake2b(key[:])
+			if err := storageTrie.Prove(hashedKey[:], &sp.Proof); err != nil {
+				return nil, &Error{err}
+			}
+		}
+		proof.StorageProofs = append(proof.StorageProofs, sp)
+	}
+	return proof, nil
+}
+
+// VerifyAccountProof verifies the merkle proof of the account against the state root,
+// and returns the proven account. Empty account is returned if the proof proves the absence.
+func VerifyAccountProof(root workshare.Bytes32, addr workshare.Address, proof ProofList) (*Account, error) {
+	hashedAddr := workshare.Blake2b(addr[:])
+	data, err := verifyProof(root, hashedAddr[:], proof)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return emptyAccount(), nil
+	}
+	var acc Account
+	if err := rlp.DecodeBytes(data, &acc); err != nil {
+		return nil, err
+	}
+	return &acc, nil
+}
+
+// VerifyStorageProof verifies the merkle proof of the storage value against the storage root of the account,
+// and returns the proven value. Zero value is returned if the proof proves the absence.
+func VerifyStorageProof(storageRoot []byte, key workshare.Bytes32, proof ProofList) (workshare.Bytes32, error) {
+	hashedKey := workshare.Blake2b(key[:])
+	raw, err := verifyProof(workshare.BytesToBytes32(storageRoot), hashedKey[:], proof)
+	if err != nil {
+		return workshare.Bytes32{}, err
+	}
+	return decodeStorageValue(raw)
+}
+
+func verifyProof(root workshare.Bytes32, key []byte, proof ProofList) ([]byte, error) {
+	if root.IsZero() || root == emptyRoot {
+		if len(proof) > 0 {
+			return nil, errors.New("non-empty proof for empty trie")
+		}
+		return nil, nil
+	}
+	value, err, _ := trie.VerifyProof(root, key, proof)
+	return value, err
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2022 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/miniBamboo/workshare/muxdb"
+	"github.com/miniBamboo/workshare/workshare"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProve(t *testing.T) {
+	db := muxdb.NewMem()
+	st := New(db, workshare.Bytes32{}, 0, 0, 0)
+
+	addr := workshare.BytesToAddress([]byte("addr"))
+	key := workshare.BytesToBytes32([]byte("key"))
+	value := workshare.BytesToBytes32([]byte("value"))
+	for i := 0; i < 100; i++ {
+		st.SetBalance(workshare.BytesToAddress([]byte{byte(i)}), big.NewInt(int64(i+1)))
+		st.SetStorage(addr, workshare.BytesToBytes32([]byte{byte(i)}), value)
+	}
+	st.SetBalance(addr, big.NewInt(1))
+	st.SetStorage(addr, key, value)
+
+	stage, err := st.Stage(1, 0)
+	assert.Nil(t, err)
+	root, err := stage.Commit()
+	assert.Nil(t, err)
+
+	st = New(db, root, 1, 0, 0)
+	missingKey := workshare.BytesToBytes32([]byte("missing"))
+	proof, err := st.Prove(addr, []workshare.Bytes32{key, missingKey})
+	assert.Nil(t, err)
+
+	acc, err := VerifyAccountProof(root, addr, proof.Proof)
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(1), acc.Balance)
+	assert.Equal(t, proof.Account.StorageRoot, acc.StorageRoot)
+
+	assert.Equal(t, value, proof.StorageProofs[0].Value)
+	assert.Equal(t, M(value, nil), M(VerifyStorageProof(acc.StorageRoot, key, proof.StorageProofs[0].Proof)))
+	assert.Equal(t, M(workshare.Bytes32{}, nil), M(VerifyStorageProof(acc.StorageRoot, missingKey, proof.StorageProofs[1].Proof)), "should prove absence")
+
+	// absent account
+	other := workshare.BytesToAddress([]byte("other"))
+	proof, err = st.Prove(other, []workshare.Bytes32{key})
+	assert.Nil(t, err)
+	acc, err = VerifyAccountProof(root, other, proof.Proof)
+	assert.Nil(t, err)
+	assert.True(t, acc.IsEmpty())
+	assert.Equal(t, 0, len(proof.StorageProofs[0].Proof))
+
+	// tampered proof
+	_, err = VerifyAccountProof(workshare.Blake2b([]byte("root")), addr, proof.Proof)
+	assert.NotNil(t, err)
+}
@@ -229,12 +229,21 @@ func (s *State) GetStorage(addr workshare.Address, key workshare.Bytes32) (works
 	if err != nil {
 		return workshare.Bytes32{}, &Error{err}
 	}
+	v, err := decodeStorageValue(raw)
+	if err != nil {
+		return workshare.Bytes32{}, &Error{err}
+	}
+	return v, nil
+}
+
+// decodeStorageValue decodes the raw storage value into bytes32.
+func decodeStorageValue(raw rlp.RawValue) (workshare.Bytes32, error) {
 	if len(raw) == 0 {
 		return workshare.Bytes32{}, nil
 	}
 	kind, content, _, err := rlp.Split(raw)
 	if err != nil {
-		return workshare.Bytes32{}, &Error{err}
+		return workshare.Bytes32{}, err
 	}
 	if kind == rlp.List {
 		// special case for rlp list, it should be customized storage value
@@ -254,6 +263,19 @@ func (s *State) SetStorage(addr workshare.Address, key, value workshare.Bytes32)
 	s.SetRawStorage(addr, key, v)
 }
 
+// ClearStorage removes all storage values of the given address, while other fields of the account are kept.
+func (s *State) ClearStorage(addr workshare.Address) error {
+	cpy, err := s.getAccountCopy(addr)
+	if err != nil {
+		return &Error{err}
+	}
+	cpy.StorageRoot = nil
+	s.updateAccount(addr, &cpy)
+	// increase the barrier value
+	s.setStorageBarrier(addr, s.getStorageBarrier(addr)+1)
+	return nil
+}
+
 // GetRawStorage returns storage value in rlp raw for given address and key.
 func (s *State) GetRawStorage(addr workshare.Address, key workshare.Bytes32) (rlp.RawValue, error) {
 	data, _, err := s.sm.Get(storageKey{addr, s.getStorageBarrier(addr), key})
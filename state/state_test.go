@@ -173,3 +173,35 @@ func TestStorageBarrier(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, 0, len(acc.StorageRoot), "should skip storage writes when account deleteed then recreated")
 }
+
+func TestClearStorage(t *testing.T) {
+	db := muxdb.NewMem()
+	st := New(db, workshare.Bytes32{}, 0, 0, 0)
+
+	addr := workshare.BytesToAddress([]byte("addr"))
+	key1 := workshare.BytesToBytes32([]byte("key1"))
+	key2 := workshare.BytesToBytes32([]byte("key2"))
+	value := workshare.BytesToBytes32([]byte("value"))
+
+	st.SetBalance(addr, big.NewInt(1))
+	st.SetStorage(addr, key1, value)
+	stage, err := st.Stage(0, 0)
+	assert.Nil(t, err)
+	root, err := stage.Commit()
+	assert.Nil(t, err)
+
+	st = New(db, root, 0, 0, 0)
+	assert.Nil(t, st.ClearStorage(addr))
+	assert.Equal(t, M(workshare.Bytes32{}, nil), M(st.GetStorage(addr, key1)), "should read empty storage when cleared")
+	assert.Equal(t, M(big.NewInt(1), nil), M(st.GetBalance(addr)), "should keep balance")
+
+	st.SetStorage(addr, key2, value)
+	stage, err = st.Stage(1, 0)
+	assert.Nil(t, err)
+	root, err = stage.Commit()
+	assert.Nil(t, err)
+
+	st = New(db, root, 1, 0, 0)
+	assert.Equal(t, M(workshare.Bytes32{}, nil), M(st.GetStorage(addr, key1)))
+	assert.Equal(t, M(value, nil), M(st.GetStorage(addr, key2)))
+}
@@ -5,7 +5,11 @@
 
 package trie
 
-import "github.com/miniBamboo/workshare/workshare"
+import (
+	"errors"
+
+	"github.com/miniBamboo/workshare/workshare"
+)
 
 // ExtendedTrie is an extended Merkle Patricia Trie which supports nodes sequence number
 // and leaf metadata.
@@ -117,6 +121,14 @@ func (e *ExtendedTrie) Get(key []byte) (val, meta []byte, err error) {
 	return nil, nil, nil
 }
 
+// Prove constructs a merkle proof for key. See Trie.Prove.
+func (e *ExtendedTrie) Prove(key []byte, fromLevel uint, proofDb DatabaseWriter) error {
+	if e.nonCrypto {
+		return errors.New("non-crypto trie can not be proven")
+	}
+	return e.trie.Prove(key, fromLevel, proofDb)
+}
+
 // Update associates key with value and metadata in the trie. Subsequent calls to
 // Get will return value. If value has length zero, any existing value
 // is deleted from the trie and calls to Get will return nil.
@@ -36,6 +36,7 @@ import (
 func (t *Trie) Prove(key []byte, fromLevel uint, proofDb DatabaseWriter) error {
 	// Collect all nodes on the path to key.
 	key = keybytesToHex(key)
+	hexKey := key
 	nodes := []node{}
 	tn := t.root
 	for len(key) > 0 && tn != nil {
@@ -55,7 +56,8 @@ func (t *Trie) Prove(key []byte, fromLevel uint, proofDb DatabaseWriter) error {
 			nodes = append(nodes, n)
 		case *hashNode:
 			var err error
-			tn, err = t.resolveHash(n, nil)
+			// the path prefix is required by databases keyed by node path
+			tn, err = t.resolveHash(n, hexKey[:len(hexKey)-len(key)])
 			if err != nil {
 				log.Error(fmt.Sprintf("Unhandled trie error: %v", err))
 				return err
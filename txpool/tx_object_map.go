@@ -115,6 +115,22 @@ func (m *txObjectMap) Fill(txObjs []*txObject) {
 	}
 }
 
+// Quota returns the count of txs originated from the given account.
+func (m *txObjectMap) Quota(origin workshare.Address) int {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return m.quota[origin]
+}
+
+// Accounts returns the count of accounts having txs in the map.
+func (m *txObjectMap) Accounts() int {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return len(m.quota)
+}
+
 func (m *txObjectMap) Len() int {
 	m.lock.RLock()
 	defer m.lock.RUnlock()
@@ -19,6 +19,7 @@ import (
 	"github.com/miniBamboo/workshare/chain"
 	"github.com/miniBamboo/workshare/co"
 	"github.com/miniBamboo/workshare/consensus/builtin"
+	"github.com/miniBamboo/workshare/metric"
 	"github.com/miniBamboo/workshare/state"
 	"github.com/miniBamboo/workshare/tx"
 	"github.com/miniBamboo/workshare/workshare"
@@ -31,6 +32,9 @@ const (
 
 var (
 	log = log15.New("pkg", "txpool")
+
+	metricPoolSize     = metric.NewGauge("txpool_size", "Number of txs in the pool.")
+	metricWashDuration = metric.NewHistogram("txpool_wash_duration_seconds", "Time spent washing the pool.", nil)
 )
 
 // Options options for tx pool.
@@ -48,6 +52,26 @@ type TxEvent struct {
 	Executable *bool
 }
 
+// TxStatus describes a pooled tx, with its status evaluated against the best block.
+type TxStatus struct {
+	Tx             *tx.Transaction
+	Origin         workshare.Address
+	Delegator      *workshare.Address
+	LocalSubmitted bool
+	TimeAdded      time.Time
+	Executable     bool
+	Reason         string // why the tx is not executable
+}
+
+// Stats contains the size of the pool versus its limits.
+type Stats struct {
+	Total           int
+	Executables     int
+	Accounts        int
+	Limit           int
+	LimitPerAccount int
+}
+
 // TxPool maintains unprocessed transactions.
 type TxPool struct {
 	options   Options
@@ -103,6 +127,7 @@ func (p *TxPool) housekeeping() {
 				headSummary = newHeadSummary
 				headBlockChanged = true
 			}
+			metricPoolSize.Set(float64(p.all.Len()))
 			if !isChainSynced(uint64(time.Now().Unix()), headSummary.Header.Timestamp()) {
 				// skip washing txs if not synced
 				continue
@@ -121,6 +146,7 @@ func (p *TxPool) housekeeping() {
 				startTime := mclock.Now()
 				executables, removed, err := p.wash(headSummary)
 				elapsed := mclock.Now() - startTime
+				metricWashDuration.ObserveDuration(time.Duration(elapsed))
 
 				ctx := []interface{}{
 					"len", poolLen,
@@ -343,6 +369,87 @@ func (p *TxPool) Dump() tx.Transactions {
 	return p.all.ToTxs()
 }
 
+// Stats returns the size of the pool versus its limits.
+func (p *TxPool) Stats() Stats {
+	return Stats{
+		Total:           p.all.Len(),
+		Executables:     len(p.Executables()),
+		Accounts:        p.all.Accounts(),
+		Limit:           p.options.Limit,
+		LimitPerAccount: p.options.LimitPerAccount,
+	}
+}
+
+// Quota returns the count of pooled txs originated from the given account.
+func (p *TxPool) Quota(origin workshare.Address) int {
+	return p.all.Quota(origin)
+}
+
+// Inspect evaluates the status of pooled txs against the best block.
+// Txs not matched by the filter are skipped, and a nil filter matches all.
+func (p *TxPool) Inspect(filter func(txStatus *TxStatus) bool) []*TxStatus {
+	headSummary := p.repo.BestBlockSummary()
+	chain := p.repo.NewChain(headSummary.Header.ID())
+
+	var statuses []*TxStatus
+	for _, txObj := range p.all.ToTxObjects() {
+		status := newTxStatus(txObj)
+		if filter != nil && !filter(status) {
+			continue
+		}
+		p.evaluate(txObj, status, chain, headSummary)
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// InspectTx is like Inspect, but for the tx of the given id.
+// nil returned if the tx is not in the pool.
+func (p *TxPool) InspectTx(id workshare.Bytes32) *TxStatus {
+	txObj := p.all.GetByID(id)
+	if txObj == nil {
+		return nil
+	}
+	headSummary := p.repo.BestBlockSummary()
+	status := newTxStatus(txObj)
+	p.evaluate(txObj, status, p.repo.NewChain(headSummary.Header.ID()), headSummary)
+	return status
+}
+
+func newTxStatus(txObj *txObject) *TxStatus {
+	return &TxStatus{
+		Tx:             txObj.Transaction,
+		Origin:         txObj.Origin(),
+		Delegator:      txObj.resolved.Delegator,
+		LocalSubmitted: txObj.localSubmitted,
+		TimeAdded:      time.Unix(0, txObj.timeAdded),
+	}
+}
+
+// evaluate fills the executable status of the tx object, and the reason if it's not executable.
+func (p *TxPool) evaluate(txObj *txObject, status *TxStatus, chain *chain.Chain, headSummary *chain.BlockSummary) {
+	if workshare.IsOriginBlocked(txObj.Origin()) || p.blocklist.Contains(txObj.Origin()) {
+		status.Reason = "origin blocked"
+		return
+	}
+	// the state is modified by buying gas
+	state := p.stater.NewState(headSummary.Header.StateRoot(), headSummary.Header.Number(), headSummary.Conflicts, headSummary.SteadyNum)
+	executable, err := txObj.Executable(chain, state, headSummary.Header)
+	if err != nil {
+		// such txs will be washed out
+		status.Reason = err.Error()
+		return
+	}
+	status.Executable = executable
+	if !executable {
+		if txObj.BlockRef().Number() > headSummary.Header.Number() {
+			status.Reason = "future block ref"
+		} else {
+			status.Reason = "dependency not settled"
+		}
+	}
+}
+
 // wash to evict txs that are over limit, out of lifetime, out of energy, settled, expired or dep broken.
 // this method should only be called in housekeeping go routine
 func (p *TxPool) wash(headSummary *chain.BlockSummary) (executables tx.Transactions, removed int, err error) {
@@ -213,3 +213,46 @@ func TestBeforeVIP191Add(t *testing.T) {
 
 	assert.Equal(t, "tx rejected: unsupported features", err.Error())
 }
+
+func TestInspect(t *testing.T) {
+	pool := newPool(LIMIT, LIMIT_PER_ACCOUNT)
+	defer pool.Close()
+
+	dep := workshare.BytesToBytes32([]byte("dep"))
+	tx1 := newTx(pool.repo.ChainTag(), nil, 21000, tx.BlockRef{}, 100, nil, tx.Features(0), genesis.DevAccounts()[0])
+	tx2 := newTx(pool.repo.ChainTag(), nil, 21000, tx.NewBlockRef(10), 100, nil, tx.Features(0), genesis.DevAccounts()[0])
+	tx3 := newTx(pool.repo.ChainTag(), nil, 21000, tx.BlockRef{}, 100, &dep, tx.Features(0), genesis.DevAccounts()[1])
+	for _, trx := range []*tx.Transaction{tx1, tx2, tx3} {
+		txObj, _ := resolveTx(trx, false)
+		assert.Nil(t, pool.all.Add(txObj, LIMIT_PER_ACCOUNT))
+	}
+
+	status := pool.InspectTx(tx1.ID())
+	assert.True(t, status.Executable)
+	assert.Equal(t, "", status.Reason)
+	assert.Equal(t, genesis.DevAccounts()[0].Address, status.Origin)
+
+	status = pool.InspectTx(tx2.ID())
+	assert.False(t, status.Executable)
+	assert.Equal(t, "future block ref", status.Reason)
+
+	status = pool.InspectTx(tx3.ID())
+	assert.False(t, status.Executable)
+	assert.Equal(t, "dependency not settled", status.Reason)
+
+	assert.Nil(t, pool.InspectTx(dep))
+
+	statuses := pool.Inspect(func(s *TxStatus) bool {
+		return s.Origin == genesis.DevAccounts()[0].Address
+	})
+	assert.Equal(t, 2, len(statuses))
+
+	assert.Equal(t, Stats{
+		Total:           3,
+		Executables:     0,
+		Accounts:        2,
+		Limit:           LIMIT,
+		LimitPerAccount: LIMIT_PER_ACCOUNT,
+	}, pool.Stats())
+	assert.Equal(t, 2, pool.Quota(genesis.DevAccounts()[0].Address))
+}
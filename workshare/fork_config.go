@@ -20,11 +20,18 @@ type ForkConfig struct {
 	VIP214    uint32
 }
 
-func (fc ForkConfig) String() string {
-	var strs []string
+// Fork is a fork with its activation block number.
+type Fork struct {
+	Name     string
+	BlockNum uint32
+}
+
+// Forks returns scheduled forks in order.
+func (fc ForkConfig) Forks() []Fork {
+	var forks []Fork
 	push := func(name string, blockNum uint32) {
 		if blockNum != math.MaxUint32 {
-			strs = append(strs, fmt.Sprintf("%v: #%v", name, blockNum))
+			forks = append(forks, Fork{name, blockNum})
 		}
 	}
 
@@ -34,6 +41,14 @@ func (fc ForkConfig) String() string {
 	push("ETH_IST", fc.ETH_IST)
 	push("VIP214", fc.VIP214)
 
+	return forks
+}
+
+func (fc ForkConfig) String() string {
+	var strs []string
+	for _, f := range fc.Forks() {
+		strs = append(strs, fmt.Sprintf("%v: #%v", f.Name, f.BlockNum))
+	}
 	return strings.Join(strs, ", ")
 }
 